@@ -0,0 +1,198 @@
+package bitops
+
+import (
+    "fmt"
+    "sync/atomic"
+)
+
+// AtomicSetBit32 atomically set the specified bit of *addr to 1
+func AtomicSetBit32(addr *uint32, pos uint) (error) {
+    if pos >= 32 {
+        return fmt.Errorf("invalid position(%v)", pos)
+    }
+
+    atomic.OrUint32(addr, uint32(1) << pos)
+    return nil
+}
+
+// AtomicSetBit64 atomically set the specified bit of *addr to 1
+func AtomicSetBit64(addr *uint64, pos uint) (error) {
+    if pos >= 64 {
+        return fmt.Errorf("invalid position(%v)", pos)
+    }
+
+    atomic.OrUint64(addr, uint64(1) << pos)
+    return nil
+}
+
+// AtomicClearBit32 atomically set the specified bit of *addr to 0
+func AtomicClearBit32(addr *uint32, pos uint) (error) {
+    if pos >= 32 {
+        return fmt.Errorf("invalid position(%v)", pos)
+    }
+
+    atomic.AndUint32(addr, ^(uint32(1) << pos))
+    return nil
+}
+
+// AtomicClearBit64 atomically set the specified bit of *addr to 0
+func AtomicClearBit64(addr *uint64, pos uint) (error) {
+    if pos >= 64 {
+        return fmt.Errorf("invalid position(%v)", pos)
+    }
+
+    atomic.AndUint64(addr, ^(uint64(1) << pos))
+    return nil
+}
+
+// AtomicToggleBit32 atomically toggle the specified bit of *addr
+func AtomicToggleBit32(addr *uint32, pos uint) (error) {
+    if pos >= 32 {
+        return fmt.Errorf("invalid position(%v)", pos)
+    }
+
+    for {
+        old := atomic.LoadUint32(addr)
+        if atomic.CompareAndSwapUint32(addr, old, old ^ (uint32(1) << pos)) {
+            return nil
+        }
+    }
+}
+
+// AtomicToggleBit64 atomically toggle the specified bit of *addr
+func AtomicToggleBit64(addr *uint64, pos uint) (error) {
+    if pos >= 64 {
+        return fmt.Errorf("invalid position(%v)", pos)
+    }
+
+    for {
+        old := atomic.LoadUint64(addr)
+        if atomic.CompareAndSwapUint64(addr, old, old ^ (uint64(1) << pos)) {
+            return nil
+        }
+    }
+}
+
+// AtomicTestBit32 atomically load *addr and return whether the specified bit is 1
+func AtomicTestBit32(addr *uint32, pos uint) (bool, error) {
+    if pos >= 32 {
+        return false, fmt.Errorf("invalid position(%v)", pos)
+    }
+
+    return atomic.LoadUint32(addr) & (uint32(1) << pos) != 0, nil
+}
+
+// AtomicTestBit64 atomically load *addr and return whether the specified bit is 1
+func AtomicTestBit64(addr *uint64, pos uint) (bool, error) {
+    if pos >= 64 {
+        return false, fmt.Errorf("invalid position(%v)", pos)
+    }
+
+    return atomic.LoadUint64(addr) & (uint64(1) << pos) != 0, nil
+}
+
+// AtomicTestAndSet32 atomically set the specified bit of *addr to 1 and return
+// the previous value of the bit
+func AtomicTestAndSet32(addr *uint32, pos uint) (bool, error) {
+    if pos >= 32 {
+        return false, fmt.Errorf("invalid position(%v)", pos)
+    }
+
+    mask := uint32(1) << pos
+    return atomic.OrUint32(addr, mask) & mask != 0, nil
+}
+
+// AtomicTestAndSet64 atomically set the specified bit of *addr to 1 and return
+// the previous value of the bit
+func AtomicTestAndSet64(addr *uint64, pos uint) (bool, error) {
+    if pos >= 64 {
+        return false, fmt.Errorf("invalid position(%v)", pos)
+    }
+
+    mask := uint64(1) << pos
+    return atomic.OrUint64(addr, mask) & mask != 0, nil
+}
+
+// AtomicTestAndClear32 atomically set the specified bit of *addr to 0 and return
+// the previous value of the bit
+func AtomicTestAndClear32(addr *uint32, pos uint) (bool, error) {
+    if pos >= 32 {
+        return false, fmt.Errorf("invalid position(%v)", pos)
+    }
+
+    mask := uint32(1) << pos
+    return atomic.AndUint32(addr, ^mask) & mask != 0, nil
+}
+
+// AtomicTestAndClear64 atomically set the specified bit of *addr to 0 and return
+// the previous value of the bit
+func AtomicTestAndClear64(addr *uint64, pos uint) (bool, error) {
+    if pos >= 64 {
+        return false, fmt.Errorf("invalid position(%v)", pos)
+    }
+
+    mask := uint64(1) << pos
+    return atomic.AndUint64(addr, ^mask) & mask != 0, nil
+}
+
+// real implementation for AtomicDeposit32 and AtomicSetField32
+func atomicDeposit32(addr *uint32, start uint, length uint, field uint32) (uint32, error) {
+    for {
+        old := atomic.LoadUint32(addr)
+        value, _ := deposit32(old, start, length, field)
+        if atomic.CompareAndSwapUint32(addr, old, value) {
+            return old, nil
+        }
+    }
+}
+
+// real implementation for AtomicDeposit64 and AtomicSetField64
+func atomicDeposit64(addr *uint64, start uint, length uint, field uint64) (uint64, error) {
+    for {
+        old := atomic.LoadUint64(addr)
+        value, _ := deposit64(old, start, length, field)
+        if atomic.CompareAndSwapUint64(addr, old, value) {
+            return old, nil
+        }
+    }
+}
+
+// AtomicDeposit32 atomically deposit field to *addr by starting position and length
+// LSB/MSB are 0/31. It return the previous word and *addr is untouched if error occurs
+func AtomicDeposit32(addr *uint32, start uint, length uint, field uint32) (uint32, error) {
+    if start > 31 || length > 32 - start {
+        return atomic.LoadUint32(addr), fmt.Errorf("invalid start(%v) or length(%v)", start, length)
+    }
+
+    return atomicDeposit32(addr, start, length, field)
+}
+
+// AtomicDeposit64 atomically deposit field to *addr by starting position and length
+// LSB/MSB are 0/63. It return the previous word and *addr is untouched if error occurs
+func AtomicDeposit64(addr *uint64, start uint, length uint, field uint64) (uint64, error) {
+    if start > 63 || length > 64 - start {
+        return atomic.LoadUint64(addr), fmt.Errorf("invalid start(%v) or length(%v)", start, length)
+    }
+
+    return atomicDeposit64(addr, start, length, field)
+}
+
+// AtomicSetField32 atomically set field between high and low bit of *addr
+// LSB/MSB are 0/31. It return the previous word and *addr is untouched if error occurs
+func AtomicSetField32(addr *uint32, high uint, low uint, field uint32) (uint32, error) {
+    if high > 31 || low > 31 || high < low {
+        return atomic.LoadUint32(addr), fmt.Errorf("invalid high(%v) or low(%v)", high, low)
+    }
+
+    return atomicDeposit32(addr, low, high - low + 1, field)
+}
+
+// AtomicSetField64 atomically set field between high and low bit of *addr
+// LSB/MSB are 0/63. It return the previous word and *addr is untouched if error occurs
+func AtomicSetField64(addr *uint64, high uint, low uint, field uint64) (uint64, error) {
+    if high > 63 || low > 63 || high < low {
+        return atomic.LoadUint64(addr), fmt.Errorf("invalid high(%v) or low(%v)", high, low)
+    }
+
+    return atomicDeposit64(addr, low, high - low + 1, field)
+}
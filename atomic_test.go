@@ -0,0 +1,400 @@
+package bitops
+
+import (
+    "sync"
+    "testing"
+)
+
+// number of goroutines and iterations used by the stress tests
+const (
+    stressWorkers = 8
+    stressRounds  = 1000
+)
+
+func TestAtomicSetBit32(t *testing.T) {
+    var value uint32
+
+    if err := AtomicSetBit32(&value, 32); err == nil || value != 0 {
+        t.Fail()
+        t.Log("32nd bit error")
+    }
+
+    //every worker own a distinct bit of the same word
+    var wg sync.WaitGroup
+    for i := 0; i < 32; i++ {
+        wg.Add(1)
+        go func(pos uint) {
+            defer wg.Done()
+            for j := 0; j < stressRounds; j++ {
+                AtomicSetBit32(&value, pos)
+            }
+        }(uint(i))
+    }
+    wg.Wait()
+
+    if value != 0xFFFFFFFF {
+        t.Fail()
+        t.Logf("expect all bits set but get %x", value)
+    }
+}
+
+func TestAtomicSetBit64(t *testing.T) {
+    var value uint64
+
+    if err := AtomicSetBit64(&value, 64); err == nil || value != 0 {
+        t.Fail()
+        t.Log("64th bit error")
+    }
+
+    var wg sync.WaitGroup
+    for i := 0; i < 64; i++ {
+        wg.Add(1)
+        go func(pos uint) {
+            defer wg.Done()
+            for j := 0; j < stressRounds; j++ {
+                AtomicSetBit64(&value, pos)
+            }
+        }(uint(i))
+    }
+    wg.Wait()
+
+    if value != 0xFFFFFFFFFFFFFFFF {
+        t.Fail()
+        t.Logf("expect all bits set but get %x", value)
+    }
+}
+
+func TestAtomicClearBit32(t *testing.T) {
+    var value uint32 = 0xFFFFFFFF
+
+    if err := AtomicClearBit32(&value, 32); err == nil || value != 0xFFFFFFFF {
+        t.Fail()
+        t.Log("32nd bit error")
+    }
+
+    var wg sync.WaitGroup
+    for i := 0; i < 32; i += 2 {
+        wg.Add(1)
+        go func(pos uint) {
+            defer wg.Done()
+            AtomicClearBit32(&value, pos)
+        }(uint(i))
+    }
+    wg.Wait()
+
+    if value != 0xAAAAAAAA {
+        t.Fail()
+        t.Logf("expect %x but get %x", 0xAAAAAAAA, value)
+    }
+}
+
+func TestAtomicClearBit64(t *testing.T) {
+    var value uint64 = 0xFFFFFFFFFFFFFFFF
+
+    if err := AtomicClearBit64(&value, 64); err == nil || value != 0xFFFFFFFFFFFFFFFF {
+        t.Fail()
+        t.Log("64th bit error")
+    }
+
+    var wg sync.WaitGroup
+    for i := 1; i < 64; i += 2 {
+        wg.Add(1)
+        go func(pos uint) {
+            defer wg.Done()
+            AtomicClearBit64(&value, pos)
+        }(uint(i))
+    }
+    wg.Wait()
+
+    if value != 0x5555555555555555 {
+        t.Fail()
+        t.Logf("expect %x but get %x", uint64(0x5555555555555555), value)
+    }
+}
+
+func TestAtomicToggleBit32(t *testing.T) {
+    var value uint32
+
+    if err := AtomicToggleBit32(&value, 32); err == nil {
+        t.Fail()
+        t.Log("32nd bit error")
+    }
+
+    //an even number of toggles per bit leave the word unchanged, a lost
+    //update would leave some bit set
+    var wg sync.WaitGroup
+    for i := 0; i < stressWorkers; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for j := 0; j < stressRounds; j++ {
+                AtomicToggleBit32(&value, uint(j % 32))
+            }
+        }()
+    }
+    wg.Wait()
+
+    if value != 0 {
+        t.Fail()
+        t.Logf("expect 0 but get %x", value)
+    }
+}
+
+func TestAtomicToggleBit64(t *testing.T) {
+    var value uint64
+
+    if err := AtomicToggleBit64(&value, 64); err == nil {
+        t.Fail()
+        t.Log("64th bit error")
+    }
+
+    var wg sync.WaitGroup
+    for i := 0; i < stressWorkers; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for j := 0; j < stressRounds; j++ {
+                AtomicToggleBit64(&value, uint(j % 64))
+            }
+        }()
+    }
+    wg.Wait()
+
+    if value != 0 {
+        t.Fail()
+        t.Logf("expect 0 but get %x", value)
+    }
+}
+
+func TestAtomicTestBit32(t *testing.T) {
+    var value uint32 = 0x80000000
+
+    if _, err := AtomicTestBit32(&value, 32); err == nil {
+        t.Fail()
+        t.Log("32nd bit error")
+    }
+    if set, err := AtomicTestBit32(&value, 31); err != nil || !set {
+        t.Fail()
+        t.Log("MSB")
+    }
+}
+
+func TestAtomicTestBit64(t *testing.T) {
+    var value uint64 = 0x1
+
+    if _, err := AtomicTestBit64(&value, 64); err == nil {
+        t.Fail()
+        t.Log("64th bit error")
+    }
+    if set, err := AtomicTestBit64(&value, 63); err != nil || set {
+        t.Fail()
+        t.Log("MSB")
+    }
+}
+
+func TestAtomicTestAndSet32(t *testing.T) {
+    var value uint32
+
+    if _, err := AtomicTestAndSet32(&value, 32); err == nil {
+        t.Fail()
+        t.Log("32nd bit error")
+    }
+
+    //exactly one worker may win each bit
+    var wg sync.WaitGroup
+    var mu sync.Mutex
+    wins := 0
+    for i := 0; i < stressWorkers; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for pos := uint(0); pos < 32; pos++ {
+                if old, _ := AtomicTestAndSet32(&value, pos); !old {
+                    mu.Lock()
+                    wins++
+                    mu.Unlock()
+                }
+            }
+        }()
+    }
+    wg.Wait()
+
+    if wins != 32 || value != 0xFFFFFFFF {
+        t.Fail()
+        t.Logf("expect 32 wins but get %d (%x)", wins, value)
+    }
+}
+
+func TestAtomicTestAndSet64(t *testing.T) {
+    var value uint64
+
+    if _, err := AtomicTestAndSet64(&value, 64); err == nil {
+        t.Fail()
+        t.Log("64th bit error")
+    }
+
+    var wg sync.WaitGroup
+    var mu sync.Mutex
+    wins := 0
+    for i := 0; i < stressWorkers; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for pos := uint(0); pos < 64; pos++ {
+                if old, _ := AtomicTestAndSet64(&value, pos); !old {
+                    mu.Lock()
+                    wins++
+                    mu.Unlock()
+                }
+            }
+        }()
+    }
+    wg.Wait()
+
+    if wins != 64 || value != 0xFFFFFFFFFFFFFFFF {
+        t.Fail()
+        t.Logf("expect 64 wins but get %d (%x)", wins, value)
+    }
+}
+
+func TestAtomicTestAndClear32(t *testing.T) {
+    var value uint32 = 0x1
+
+    if old, err := AtomicTestAndClear32(&value, 0); err != nil || !old || value != 0 {
+        t.Fail()
+        t.Log("clear LSB")
+    }
+    if old, err := AtomicTestAndClear32(&value, 0); err != nil || old {
+        t.Fail()
+        t.Log("clear LSB twice")
+    }
+    if _, err := AtomicTestAndClear32(&value, 32); err == nil {
+        t.Fail()
+        t.Log("32nd bit error")
+    }
+}
+
+func TestAtomicTestAndClear64(t *testing.T) {
+    var value uint64 = 0x8000000000000000
+
+    if old, err := AtomicTestAndClear64(&value, 63); err != nil || !old || value != 0 {
+        t.Fail()
+        t.Log("clear MSB")
+    }
+    if _, err := AtomicTestAndClear64(&value, 64); err == nil {
+        t.Fail()
+        t.Log("64th bit error")
+    }
+}
+
+func TestAtomicDeposit32(t *testing.T) {
+    var value uint32
+
+    if _, err := AtomicDeposit32(&value, 31, 2, 0x3); err == nil || value != 0 {
+        t.Fail()
+        t.Log("invalid length from valid start")
+    }
+
+    //every worker own a distinct 4-bit field and count it up, a lost update
+    //would leave some field behind
+    var wg sync.WaitGroup
+    for i := 0; i < 8; i++ {
+        wg.Add(1)
+        go func(start uint) {
+            defer wg.Done()
+            for j := uint32(1); j <= 15; j++ {
+                AtomicDeposit32(&value, start, 4, j)
+            }
+        }(uint(i * 4))
+    }
+    wg.Wait()
+
+    if value != 0xFFFFFFFF {
+        t.Fail()
+        t.Logf("expect all fields 0xF but get %x", value)
+    }
+
+    old, err := AtomicDeposit32(&value, 4, 4, 0)
+    if err != nil || old != 0xFFFFFFFF || value != 0xFFFFFF0F {
+        t.Fail()
+        t.Logf("expect previous word but get %x", old)
+    }
+}
+
+func TestAtomicDeposit64(t *testing.T) {
+    var value uint64
+
+    if _, err := AtomicDeposit64(&value, 64, 0, 0); err == nil {
+        t.Fail()
+        t.Log("invalid start")
+    }
+
+    var wg sync.WaitGroup
+    for i := 0; i < 16; i++ {
+        wg.Add(1)
+        go func(start uint) {
+            defer wg.Done()
+            for j := uint64(1); j <= 15; j++ {
+                AtomicDeposit64(&value, start, 4, j)
+            }
+        }(uint(i * 4))
+    }
+    wg.Wait()
+
+    if value != 0xFFFFFFFFFFFFFFFF {
+        t.Fail()
+        t.Logf("expect all fields 0xF but get %x", value)
+    }
+}
+
+func TestAtomicSetField32(t *testing.T) {
+    var value uint32 = 0xFFFFFFFF
+
+    if _, err := AtomicSetField32(&value, 10, 20, 0); err == nil || value != 0xFFFFFFFF {
+        t.Fail()
+        t.Log("high < low")
+    }
+
+    var wg sync.WaitGroup
+    for i := 0; i < 4; i++ {
+        wg.Add(1)
+        go func(low uint) {
+            defer wg.Done()
+            for j := 0; j < stressRounds; j++ {
+                AtomicSetField32(&value, low + 7, low, uint32(low))
+            }
+        }(uint(i * 8))
+    }
+    wg.Wait()
+
+    if value != 0x18100800 {
+        t.Fail()
+        t.Logf("expect %x but get %x", 0x18100800, value)
+    }
+}
+
+func TestAtomicSetField64(t *testing.T) {
+    var value uint64
+
+    if _, err := AtomicSetField64(&value, 64, 0, 0); err == nil {
+        t.Fail()
+        t.Log("invalid high")
+    }
+
+    var wg sync.WaitGroup
+    for i := 0; i < 8; i++ {
+        wg.Add(1)
+        go func(low uint) {
+            defer wg.Done()
+            for j := 0; j < stressRounds; j++ {
+                AtomicSetField64(&value, low + 7, low, uint64(low))
+            }
+        }(uint(i * 8))
+    }
+    wg.Wait()
+
+    if value != 0x3830282018100800 {
+        t.Fail()
+        t.Logf("expect %x but get %x", uint64(0x3830282018100800), value)
+    }
+}
@@ -0,0 +1,904 @@
+package bitops
+
+import (
+    "bytes"
+    "encoding/binary"
+    "testing"
+)
+
+// results are stored to the sinks so the compiler can not drop the call
+var (
+    sinkUint   uint
+    sink8      uint8
+    sink16     uint16
+    sink32     uint32
+    sink64     uint64
+    sinkInt32  int32
+    sinkInt64  int64
+    sink128    Uint128
+    sinkVector BitVector
+    sinkBool   bool
+    sinkErr    error
+)
+
+// spread the benchmark input over all bits
+const benchSeed = 0x9E3779B97F4A7C15
+
+func BenchmarkExtract32(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink32, sinkErr = Extract32(uint32(i) * 0x9E3779B9, uint(i) % 24, 8)
+    }
+}
+
+func BenchmarkGetField32(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink32, sinkErr = GetField32(uint32(i) * 0x9E3779B9, uint(i) % 24 + 8, uint(i) % 24)
+    }
+}
+
+func BenchmarkGetSignedField32(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkInt32, sinkErr = GetSignedField32(uint32(i) * 0x9E3779B9, uint(i) % 24 + 8, uint(i) % 24)
+    }
+}
+
+func BenchmarkDeposit32(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink32, sinkErr = Deposit32(uint32(i) * 0x9E3779B9, uint(i) % 24, 8, 0xA5)
+    }
+}
+
+func BenchmarkSetField32(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink32, sinkErr = SetField32(uint32(i) * 0x9E3779B9, uint(i) % 24 + 8, uint(i) % 24, 0xA5)
+    }
+}
+
+func BenchmarkExtract64(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink64, sinkErr = Extract64(uint64(i) * benchSeed, uint(i) % 56, 8)
+    }
+}
+
+func BenchmarkGetField64(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink64, sinkErr = GetField64(uint64(i) * benchSeed, uint(i) % 56 + 8, uint(i) % 56)
+    }
+}
+
+func BenchmarkGetSignedField64(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkInt64, sinkErr = GetSignedField64(uint64(i) * benchSeed, uint(i) % 56 + 8, uint(i) % 56)
+    }
+}
+
+func BenchmarkDeposit64(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink64, sinkErr = Deposit64(uint64(i) * benchSeed, uint(i) % 56, 8, 0xA5)
+    }
+}
+
+func BenchmarkSetField64(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink64, sinkErr = SetField64(uint64(i) * benchSeed, uint(i) % 56 + 8, uint(i) % 56, 0xA5)
+    }
+}
+
+func BenchmarkCountOne8(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkUint = CountOne8(uint8(i))
+    }
+}
+
+func BenchmarkCountOne8Portable(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkUint = portableCountOne8(uint8(i))
+    }
+}
+
+func BenchmarkCountZero8(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkUint = CountZero8(uint8(i))
+    }
+}
+
+func BenchmarkCountOne16(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkUint = CountOne16(uint16(i * 0x9E37))
+    }
+}
+
+func BenchmarkCountOne16Portable(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkUint = portableCountOne16(uint16(i * 0x9E37))
+    }
+}
+
+func BenchmarkCountZero16(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkUint = CountZero16(uint16(i * 0x9E37))
+    }
+}
+
+func BenchmarkCountOne32(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkUint = CountOne32(uint32(i) * 0x9E3779B9)
+    }
+}
+
+func BenchmarkCountOne32Portable(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkUint = portableCountOne32(uint32(i) * 0x9E3779B9)
+    }
+}
+
+func BenchmarkCountZero32(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkUint = CountZero32(uint32(i) * 0x9E3779B9)
+    }
+}
+
+func BenchmarkCountOne64(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkUint = CountOne64(uint64(i) * benchSeed)
+    }
+}
+
+func BenchmarkCountOne64Portable(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkUint = portableCountOne64(uint64(i) * benchSeed)
+    }
+}
+
+func BenchmarkCountZero64(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkUint = CountZero64(uint64(i) * benchSeed)
+    }
+}
+
+func BenchmarkCountTrailZero32(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkUint = CountTrailZero32(uint32(i) * 0x9E3779B9 >> (uint(i) % 32))
+    }
+}
+
+func BenchmarkCountTrailZero32Portable(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkUint = portableCountTrailZero32(uint32(i) * 0x9E3779B9 >> (uint(i) % 32))
+    }
+}
+
+func BenchmarkCountLeadZero32(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkUint = CountLeadZero32(uint32(i) * 0x9E3779B9 >> (uint(i) % 32))
+    }
+}
+
+func BenchmarkCountLeadZero32Portable(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkUint = portableCountLeadZero32(uint32(i) * 0x9E3779B9 >> (uint(i) % 32))
+    }
+}
+
+func BenchmarkCountTrailOne32(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkUint = CountTrailOne32(uint32(i) * 0x9E3779B9)
+    }
+}
+
+func BenchmarkCountLeadOne32(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkUint = CountLeadOne32(uint32(i) * 0x9E3779B9)
+    }
+}
+
+func BenchmarkSetBit32(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink32, sinkErr = SetBit32(uint32(i) * 0x9E3779B9, uint(i) % 32)
+    }
+}
+
+func BenchmarkClearBit32(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink32, sinkErr = ClearBit32(uint32(i) * 0x9E3779B9, uint(i) % 32)
+    }
+}
+
+func BenchmarkToggleBit32(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink32, sinkErr = ToggleBit32(uint32(i) * 0x9E3779B9, uint(i) % 32)
+    }
+}
+
+func BenchmarkTestBit32(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkBool, sinkErr = TestBit32(uint32(i) * 0x9E3779B9, uint(i) % 32)
+    }
+}
+
+func BenchmarkReverse32(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink32 = Reverse32(uint32(i) * 0x9E3779B9)
+    }
+}
+
+func BenchmarkReverse32Portable(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink32 = portableReverse32(uint32(i) * 0x9E3779B9)
+    }
+}
+
+func BenchmarkRotateLeft32(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink32 = RotateLeft32(uint32(i) * 0x9E3779B9, uint(i))
+    }
+}
+
+func BenchmarkRotateLeft32Portable(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink32 = portableRotateLeft32(uint32(i) * 0x9E3779B9, uint(i))
+    }
+}
+
+func BenchmarkRotateRight32(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink32 = RotateRight32(uint32(i) * 0x9E3779B9, uint(i))
+    }
+}
+
+func BenchmarkRotateRight32Portable(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink32 = portableRotateRight32(uint32(i) * 0x9E3779B9, uint(i))
+    }
+}
+
+func BenchmarkCountTrailZero64(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkUint = CountTrailZero64(uint64(i) * benchSeed >> (uint(i) % 64))
+    }
+}
+
+func BenchmarkCountTrailZero64Portable(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkUint = portableCountTrailZero64(uint64(i) * benchSeed >> (uint(i) % 64))
+    }
+}
+
+func BenchmarkCountLeadZero64(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkUint = CountLeadZero64(uint64(i) * benchSeed >> (uint(i) % 64))
+    }
+}
+
+func BenchmarkCountLeadZero64Portable(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkUint = portableCountLeadZero64(uint64(i) * benchSeed >> (uint(i) % 64))
+    }
+}
+
+func BenchmarkCountTrailOne64(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkUint = CountTrailOne64(uint64(i) * benchSeed)
+    }
+}
+
+func BenchmarkCountLeadOne64(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkUint = CountLeadOne64(uint64(i) * benchSeed)
+    }
+}
+
+func BenchmarkSetBit64(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink64, sinkErr = SetBit64(uint64(i) * benchSeed, uint(i) % 64)
+    }
+}
+
+func BenchmarkClearBit64(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink64, sinkErr = ClearBit64(uint64(i) * benchSeed, uint(i) % 64)
+    }
+}
+
+func BenchmarkToggleBit64(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink64, sinkErr = ToggleBit64(uint64(i) * benchSeed, uint(i) % 64)
+    }
+}
+
+func BenchmarkTestBit64(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkBool, sinkErr = TestBit64(uint64(i) * benchSeed, uint(i) % 64)
+    }
+}
+
+func BenchmarkReverse64(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink64 = Reverse64(uint64(i) * benchSeed)
+    }
+}
+
+func BenchmarkReverse64Portable(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink64 = portableReverse64(uint64(i) * benchSeed)
+    }
+}
+
+func BenchmarkRotateLeft64(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink64 = RotateLeft64(uint64(i) * benchSeed, uint(i))
+    }
+}
+
+func BenchmarkRotateLeft64Portable(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink64 = portableRotateLeft64(uint64(i) * benchSeed, uint(i))
+    }
+}
+
+func BenchmarkRotateRight64(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink64 = RotateRight64(uint64(i) * benchSeed, uint(i))
+    }
+}
+
+func BenchmarkRotateRight64Portable(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink64 = portableRotateRight64(uint64(i) * benchSeed, uint(i))
+    }
+}
+
+func BenchmarkExtract128(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink128, sinkErr = Extract128(NewUint128(uint64(i) * benchSeed, ^uint64(i) * benchSeed), uint(i) % 120, 8)
+    }
+}
+
+func BenchmarkGetField128(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink128, sinkErr = GetField128(NewUint128(uint64(i) * benchSeed, ^uint64(i) * benchSeed), uint(i) % 120 + 8, uint(i) % 120)
+    }
+}
+
+func BenchmarkDeposit128(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink128, sinkErr = Deposit128(NewUint128(uint64(i) * benchSeed, ^uint64(i) * benchSeed), uint(i) % 120, 8, Uint128From64(0xA5))
+    }
+}
+
+func BenchmarkSetField128(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink128, sinkErr = SetField128(NewUint128(uint64(i) * benchSeed, ^uint64(i) * benchSeed), uint(i) % 120 + 8, uint(i) % 120, Uint128From64(0xA5))
+    }
+}
+
+func BenchmarkCountOne128(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkUint = CountOne128(NewUint128(uint64(i) * benchSeed, ^uint64(i) * benchSeed))
+    }
+}
+
+func BenchmarkCountZero128(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkUint = CountZero128(NewUint128(uint64(i) * benchSeed, ^uint64(i) * benchSeed))
+    }
+}
+
+func BenchmarkCountTrailZero128(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkUint = CountTrailZero128(NewUint128(uint64(i) * benchSeed, ^uint64(i) * benchSeed))
+    }
+}
+
+func BenchmarkCountTrailOne128(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkUint = CountTrailOne128(NewUint128(uint64(i) * benchSeed, ^uint64(i) * benchSeed))
+    }
+}
+
+func BenchmarkCountLeadZero128(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkUint = CountLeadZero128(NewUint128(uint64(i) * benchSeed, ^uint64(i) * benchSeed))
+    }
+}
+
+func BenchmarkCountLeadOne128(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkUint = CountLeadOne128(NewUint128(uint64(i) * benchSeed, ^uint64(i) * benchSeed))
+    }
+}
+
+func BenchmarkSetBit128(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink128, sinkErr = SetBit128(NewUint128(uint64(i) * benchSeed, ^uint64(i) * benchSeed), uint(i) % 128)
+    }
+}
+
+func BenchmarkClearBit128(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink128, sinkErr = ClearBit128(NewUint128(uint64(i) * benchSeed, ^uint64(i) * benchSeed), uint(i) % 128)
+    }
+}
+
+func BenchmarkToggleBit128(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink128, sinkErr = ToggleBit128(NewUint128(uint64(i) * benchSeed, ^uint64(i) * benchSeed), uint(i) % 128)
+    }
+}
+
+func BenchmarkTestBit128(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sinkBool, sinkErr = TestBit128(NewUint128(uint64(i) * benchSeed, ^uint64(i) * benchSeed), uint(i) % 128)
+    }
+}
+
+func BenchmarkReverse128(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink128 = Reverse128(NewUint128(uint64(i) * benchSeed, ^uint64(i) * benchSeed))
+    }
+}
+
+func BenchmarkRotateLeft128(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink128 = RotateLeft128(NewUint128(uint64(i) * benchSeed, ^uint64(i) * benchSeed), uint(i))
+    }
+}
+
+func BenchmarkRotateRight128(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink128 = RotateRight128(NewUint128(uint64(i) * benchSeed, ^uint64(i) * benchSeed), uint(i))
+    }
+}
+
+func BenchmarkUint128Mul(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink128 = NewUint128(uint64(i) * benchSeed, ^uint64(i) * benchSeed).Mul(NewUint128(benchSeed, uint64(i)))
+    }
+}
+
+func BenchmarkUint128QuoRem(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink128, _, sinkErr = NewUint128(^uint64(i) * benchSeed, uint64(i) * benchSeed).QuoRem(NewUint128(uint64(i) & 0xFFFF, benchSeed))
+    }
+}
+
+func BenchmarkUint128QuoRem64(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink128, _, sinkErr = NewUint128(^uint64(i) * benchSeed, uint64(i) * benchSeed).QuoRem(Uint128From64(benchSeed >> (i & 31)))
+    }
+}
+
+func BenchmarkParseUint128(b *testing.B) {
+    s := NewUint128(benchSeed, ^uint64(benchSeed)).Text(10)
+    for i := 0; i < b.N; i++ {
+        sink128, sinkErr = ParseUint128(s, 10)
+    }
+}
+
+func BenchmarkIterOne8(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        for pos := range IterOne8(uint8(i)) {
+            sinkUint += pos
+        }
+    }
+}
+
+func BenchmarkIterOneReverse8(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        for pos := range IterOneReverse8(uint8(i)) {
+            sinkUint += pos
+        }
+    }
+}
+
+func BenchmarkIterRun8(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        for start, length := range IterRun8(uint8(i)) {
+            sinkUint += start + length
+        }
+    }
+}
+
+func BenchmarkSetPositions8(b *testing.B) {
+    dst := make([]uint8, 8)
+    for i := 0; i < b.N; i++ {
+        sinkUint, sinkErr = SetPositions8(uint8(i), dst)
+    }
+}
+
+func BenchmarkIterOne16(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        for pos := range IterOne16(uint16(i * 0x9E37)) {
+            sinkUint += pos
+        }
+    }
+}
+
+func BenchmarkIterOneReverse16(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        for pos := range IterOneReverse16(uint16(i * 0x9E37)) {
+            sinkUint += pos
+        }
+    }
+}
+
+func BenchmarkIterRun16(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        for start, length := range IterRun16(uint16(i * 0x9E37)) {
+            sinkUint += start + length
+        }
+    }
+}
+
+func BenchmarkSetPositions16(b *testing.B) {
+    dst := make([]uint8, 16)
+    for i := 0; i < b.N; i++ {
+        sinkUint, sinkErr = SetPositions16(uint16(i * 0x9E37), dst)
+    }
+}
+
+func BenchmarkIterOne32(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        for pos := range IterOne32(uint32(i) * 0x9E3779B9) {
+            sinkUint += pos
+        }
+    }
+}
+
+func BenchmarkIterOneReverse32(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        for pos := range IterOneReverse32(uint32(i) * 0x9E3779B9) {
+            sinkUint += pos
+        }
+    }
+}
+
+func BenchmarkIterRun32(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        for start, length := range IterRun32(uint32(i) * 0x9E3779B9) {
+            sinkUint += start + length
+        }
+    }
+}
+
+func BenchmarkSetPositions32(b *testing.B) {
+    dst := make([]uint8, 32)
+    for i := 0; i < b.N; i++ {
+        sinkUint, sinkErr = SetPositions32(uint32(i) * 0x9E3779B9, dst)
+    }
+}
+
+func BenchmarkIterOne64(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        for pos := range IterOne64(uint64(i) * benchSeed) {
+            sinkUint += pos
+        }
+    }
+}
+
+func BenchmarkIterOneReverse64(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        for pos := range IterOneReverse64(uint64(i) * benchSeed) {
+            sinkUint += pos
+        }
+    }
+}
+
+func BenchmarkIterRun64(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        for start, length := range IterRun64(uint64(i) * benchSeed) {
+            sinkUint += start + length
+        }
+    }
+}
+
+func BenchmarkSetPositions64(b *testing.B) {
+    dst := make([]uint8, 64)
+    for i := 0; i < b.N; i++ {
+        sinkUint, sinkErr = SetPositions64(uint64(i) * benchSeed, dst)
+    }
+}
+
+func BenchmarkTranspose8(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink64 = Transpose8(uint64(i) * benchSeed)
+    }
+}
+
+func BenchmarkTranspose64(b *testing.B) {
+    var m [64]uint64
+    for i := range m {
+        m[i] = uint64(i) * benchSeed
+    }
+    for i := 0; i < b.N; i++ {
+        Transpose64(&m)
+    }
+    sink64 = m[0]
+}
+
+// 32KiB of words for the slice benchmarks
+func benchWords() ([]uint64) {
+    words := make([]uint64, 4096)
+    for i := range words {
+        words[i] = uint64(i) * benchSeed
+    }
+    return words
+}
+
+func BenchmarkPopCountSlice(b *testing.B) {
+    words := benchWords()
+    b.SetBytes(int64(len(words) * 8))
+    for i := 0; i < b.N; i++ {
+        sinkUint = PopCountSlice(words)
+    }
+}
+
+func BenchmarkPopCountSliceNaive(b *testing.B) {
+    words := benchWords()
+    b.SetBytes(int64(len(words) * 8))
+    for i := 0; i < b.N; i++ {
+        count := uint(0)
+        for _, w := range words {
+            count += CountOne64(w)
+        }
+        sinkUint = count
+    }
+}
+
+func BenchmarkPopCountBytes(b *testing.B) {
+    words := benchWords()
+    bytes := make([]byte, len(words) * 8)
+    for i, w := range words {
+        bytes[i] = byte(w >> 56)
+    }
+    b.SetBytes(int64(len(bytes)))
+    for i := 0; i < b.N; i++ {
+        sinkUint = PopCountBytes(bytes)
+    }
+}
+
+func BenchmarkPopCountXor(b *testing.B) {
+    x, y := benchWords(), benchWords()[1:]
+    x = x[:len(y)]
+    b.SetBytes(int64(len(x) * 8))
+    for i := 0; i < b.N; i++ {
+        sinkUint, sinkErr = PopCountXor(x, y)
+    }
+}
+
+func BenchmarkPopCountXorNaive(b *testing.B) {
+    x, y := benchWords(), benchWords()[1:]
+    x = x[:len(y)]
+    b.SetBytes(int64(len(x) * 8))
+    for i := 0; i < b.N; i++ {
+        count := uint(0)
+        for j := range x {
+            count += CountOne64(x[j] ^ y[j])
+        }
+        sinkUint = count
+    }
+}
+
+func BenchmarkXorSlice(b *testing.B) {
+    x, y := benchWords(), benchWords()
+    dst := make([]uint64, len(x))
+    b.SetBytes(int64(len(x) * 8))
+    for i := 0; i < b.N; i++ {
+        sinkErr = XorSlice(dst, x, y)
+    }
+}
+
+func BenchmarkXorBytes(b *testing.B) {
+    x := make([]byte, 32768)
+    y := make([]byte, 32768)
+    b.SetBytes(int64(len(x)))
+    for i := 0; i < b.N; i++ {
+        sinkErr = XorBytes(x, x, y)
+    }
+}
+
+func BenchmarkBitVectorAnd(b *testing.B) {
+    x, _ := NewBitVectorWords(256, benchWords())
+    y, _ := NewBitVectorWords(256, benchWords()[4:])
+    for i := 0; i < b.N; i++ {
+        sinkVector = x.And(y)
+    }
+}
+
+func BenchmarkBitVectorLsh(b *testing.B) {
+    x, _ := NewBitVectorWords(256, benchWords())
+    for i := 0; i < b.N; i++ {
+        sinkVector = x.Lsh(uint(i) % 256)
+    }
+}
+
+func BenchmarkAtomicSetBit32(b *testing.B) {
+    var word uint32
+    for i := 0; i < b.N; i++ {
+        sinkErr = AtomicSetBit32(&word, uint(i) & 31)
+    }
+}
+
+func BenchmarkAtomicClearBit32(b *testing.B) {
+    word := ^uint32(0)
+    for i := 0; i < b.N; i++ {
+        sinkErr = AtomicClearBit32(&word, uint(i) & 31)
+    }
+}
+
+func BenchmarkAtomicToggleBit32(b *testing.B) {
+    var word uint32
+    for i := 0; i < b.N; i++ {
+        sinkErr = AtomicToggleBit32(&word, uint(i) & 31)
+    }
+}
+
+func BenchmarkAtomicTestBit32(b *testing.B) {
+    word := uint32(0x9E3779B9)
+    for i := 0; i < b.N; i++ {
+        sinkBool, sinkErr = AtomicTestBit32(&word, uint(i) & 31)
+    }
+}
+
+func BenchmarkAtomicTestAndSet32(b *testing.B) {
+    var word uint32
+    for i := 0; i < b.N; i++ {
+        sinkBool, sinkErr = AtomicTestAndSet32(&word, uint(i) & 31)
+    }
+}
+
+func BenchmarkAtomicTestAndClear32(b *testing.B) {
+    word := ^uint32(0)
+    for i := 0; i < b.N; i++ {
+        sinkBool, sinkErr = AtomicTestAndClear32(&word, uint(i) & 31)
+    }
+}
+
+func BenchmarkAtomicSetBit64(b *testing.B) {
+    var word uint64
+    for i := 0; i < b.N; i++ {
+        sinkErr = AtomicSetBit64(&word, uint(i) & 63)
+    }
+}
+
+func BenchmarkAtomicClearBit64(b *testing.B) {
+    word := ^uint64(0)
+    for i := 0; i < b.N; i++ {
+        sinkErr = AtomicClearBit64(&word, uint(i) & 63)
+    }
+}
+
+func BenchmarkAtomicToggleBit64(b *testing.B) {
+    var word uint64
+    for i := 0; i < b.N; i++ {
+        sinkErr = AtomicToggleBit64(&word, uint(i) & 63)
+    }
+}
+
+func BenchmarkAtomicTestBit64(b *testing.B) {
+    word := uint64(benchSeed)
+    for i := 0; i < b.N; i++ {
+        sinkBool, sinkErr = AtomicTestBit64(&word, uint(i) & 63)
+    }
+}
+
+func BenchmarkAtomicTestAndSet64(b *testing.B) {
+    var word uint64
+    for i := 0; i < b.N; i++ {
+        sinkBool, sinkErr = AtomicTestAndSet64(&word, uint(i) & 63)
+    }
+}
+
+func BenchmarkAtomicTestAndClear64(b *testing.B) {
+    word := ^uint64(0)
+    for i := 0; i < b.N; i++ {
+        sinkBool, sinkErr = AtomicTestAndClear64(&word, uint(i) & 63)
+    }
+}
+
+func BenchmarkAtomicDeposit32(b *testing.B) {
+    var word uint32
+    for i := 0; i < b.N; i++ {
+        sink32, sinkErr = AtomicDeposit32(&word, uint(i) % 24, 8, 0xA5)
+    }
+}
+
+func BenchmarkAtomicSetField32(b *testing.B) {
+    var word uint32
+    for i := 0; i < b.N; i++ {
+        sink32, sinkErr = AtomicSetField32(&word, uint(i) % 24 + 8, uint(i) % 24, 0xA5)
+    }
+}
+
+func BenchmarkAtomicDeposit64(b *testing.B) {
+    var word uint64
+    for i := 0; i < b.N; i++ {
+        sink64, sinkErr = AtomicDeposit64(&word, uint(i) % 56, 8, 0xA5)
+    }
+}
+
+func BenchmarkAtomicSetField64(b *testing.B) {
+    var word uint64
+    for i := 0; i < b.N; i++ {
+        sink64, sinkErr = AtomicSetField64(&word, uint(i) % 56 + 8, uint(i) % 56, 0xA5)
+    }
+}
+
+// bitsets above parallelChunkWords so the bulk operations run in parallel
+func benchBitsets() (*ConcurrentBitset, *ConcurrentBitset) {
+    x, y := NewConcurrentBitset(1 << 22), NewConcurrentBitset(1 << 22)
+    for i := range y.words {
+        y.words[i] = uint64(i) * benchSeed
+    }
+    return x, y
+}
+
+func BenchmarkConcurrentBitsetOr(b *testing.B) {
+    x, y := benchBitsets()
+    b.SetBytes(int64(len(x.words) * 8))
+    for i := 0; i < b.N; i++ {
+        sinkErr = x.Or(y)
+    }
+}
+
+func BenchmarkConcurrentBitsetAnd(b *testing.B) {
+    x, y := benchBitsets()
+    b.SetBytes(int64(len(x.words) * 8))
+    for i := 0; i < b.N; i++ {
+        sinkErr = x.And(y)
+    }
+}
+
+func BenchmarkCarrylessMul64(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        sink128 = CarrylessMul64(uint64(i) * benchSeed, benchSeed)
+    }
+}
+
+func BenchmarkGaloisMul(b *testing.B) {
+    f, _ := NewGaloisField(8, PolyGF256)
+    for i := 0; i < b.N; i++ {
+        sink16 = f.Mul(uint16(i) & 0xFF, uint16(i >> 8) & 0xFF)
+    }
+}
+
+func BenchmarkReedSolomonDecode(b *testing.B) {
+    rs, _ := NewReedSolomon(223, 32)
+    data := make([]byte, 223)
+    for i := range data {
+        data[i] = byte(uint64(i) * benchSeed >> 56)
+    }
+    codeword, _ := rs.Encode(data)
+    received := make([]byte, len(codeword))
+    b.SetBytes(int64(len(codeword)))
+    for i := 0; i < b.N; i++ {
+        copy(received, codeword)
+        for j := 0; j < 16; j++ {
+            received[j * 15] ^= byte(j + 1)
+        }
+        _, sinkErr = rs.Decode(received, nil)
+    }
+}
+
+func BenchmarkLFSRRead(b *testing.B) {
+    l, _ := NewGaloisLFSR(PRBS31, benchSeed)
+    p := make([]byte, 1024)
+    b.SetBytes(int64(len(p)))
+    for i := 0; i < b.N; i++ {
+        l.Read(p)
+    }
+}
+
+func BenchmarkFindByteSWAR(b *testing.B) {
+    p := bytes.Repeat([]byte("abcdefgh"), 128)
+    p[len(p) - 3] = '"'
+    b.SetBytes(int64(len(p)))
+    for i := 0; i < b.N; i++ {
+        for j := 0; j + 8 <= len(p); j += 8 {
+            if lane := FirstLane8x8(EqualLanes8x8(binary.LittleEndian.Uint64(p[j:]), '"')); lane >= 0 {
+                sinkUint = uint(j + lane)
+                break
+            }
+        }
+    }
+}
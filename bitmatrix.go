@@ -0,0 +1,334 @@
+package bitops
+
+import (
+    "fmt"
+    "iter"
+    "slices"
+)
+
+// Transpose8 transpose the 8x8 bit matrix whose row i is byte i of value and column
+// j is bit j of the row
+func Transpose8(value uint64) (uint64) {
+    t := (value ^ (value >> 7)) & 0x00AA00AA00AA00AA
+    value ^= t ^ (t << 7)
+    t = (value ^ (value >> 14)) & 0x0000CCCC0000CCCC
+    value ^= t ^ (t << 14)
+    t = (value ^ (value >> 28)) & 0x00000000F0F0F0F0
+    return value ^ t ^ (t << 28)
+}
+
+// Transpose32 transpose in place the 32x32 bit matrix whose row i is m[i] and column
+// j is bit j of the row. Blocks of 16, 8, 4, 2 and 1 bits are swapped in turn
+func Transpose32(m *[32]uint32) {
+    mask := uint32(0x0000FFFF)
+    for j := uint(16); j != 0; j, mask = j >> 1, mask ^ (mask << (j >> 1)) {
+        for k := uint(0); k < 32; k = (k + j + 1) &^ j {
+            t := ((m[k] >> j) ^ m[k + j]) & mask
+            m[k + j] ^= t
+            m[k] ^= t << j
+        }
+    }
+}
+
+// Transpose64 transpose in place the 64x64 bit matrix whose row i is m[i] and column
+// j is bit j of the row. Blocks of 32, 16, 8, 4, 2 and 1 bits are swapped in turn
+func Transpose64(m *[64]uint64) {
+    mask := uint64(0x00000000FFFFFFFF)
+    for j := uint(32); j != 0; j, mask = j >> 1, mask ^ (mask << (j >> 1)) {
+        for k := uint(0); k < 64; k = (k + j + 1) &^ j {
+            t := ((m[k] >> j) ^ m[k + j]) & mask
+            m[k + j] ^= t
+            m[k] ^= t << j
+        }
+    }
+}
+
+// BitMatrix is a matrix over GF(2). Every row is kept LSB first in its own run of
+// 64-bit words, so row operations are word XORs
+type BitMatrix struct {
+    rows   uint
+    cols   uint
+    stride uint
+    words  []uint64
+}
+
+// NewBitMatrix create a rows x cols zero matrix, the error is returned if a dimension
+// is 0
+func NewBitMatrix(rows uint, cols uint) (*BitMatrix, error) {
+    if rows == 0 || cols == 0 {
+        return nil, fmt.Errorf("invalid rows(%v) or cols(%v)", rows, cols)
+    }
+
+    stride := (cols + 63) / 64
+    return &BitMatrix{rows: rows, cols: cols, stride: stride, words: make([]uint64, rows * stride)}, nil
+}
+
+// IdentityMatrix create the n x n identity matrix
+func IdentityMatrix(n uint) (*BitMatrix, error) {
+    m, err := NewBitMatrix(n, n)
+    if err != nil {
+        return nil, err
+    }
+    for i := uint(0); i < n; i++ {
+        m.words[i * m.stride + i / 64] |= uint64(1) << (i % 64)
+    }
+    return m, nil
+}
+
+// Rows return the number of rows
+func (m *BitMatrix) Rows() (uint) {
+    return m.rows
+}
+
+// Cols return the number of columns
+func (m *BitMatrix) Cols() (uint) {
+    return m.cols
+}
+
+// row return the words of row i
+func (m *BitMatrix) row(i uint) ([]uint64) {
+    return m.words[i * m.stride:(i + 1) * m.stride]
+}
+
+// Get return the bit at row r and column c
+func (m *BitMatrix) Get(r uint, c uint) (bool, error) {
+    if r >= m.rows || c >= m.cols {
+        return false, fmt.Errorf("invalid row(%v) or col(%v)", r, c)
+    }
+    return m.row(r)[c / 64] >> (c % 64) & 1 == 1, nil
+}
+
+// Set store bit at row r and column c
+func (m *BitMatrix) Set(r uint, c uint, bit bool) (error) {
+    if r >= m.rows || c >= m.cols {
+        return fmt.Errorf("invalid row(%v) or col(%v)", r, c)
+    }
+    if bit {
+        m.row(r)[c / 64] |= uint64(1) << (c % 64)
+    } else {
+        m.row(r)[c / 64] &^= uint64(1) << (c % 64)
+    }
+    return nil
+}
+
+// Row return a copy of row r, LSB first
+func (m *BitMatrix) Row(r uint) ([]uint64, error) {
+    if r >= m.rows {
+        return nil, fmt.Errorf("invalid row(%v)", r)
+    }
+    return slices.Clone(m.row(r)), nil
+}
+
+// SetRow replace row r by src, LSB first. Bits beyond the columns are dropped
+func (m *BitMatrix) SetRow(r uint, src []uint64) (error) {
+    if r >= m.rows || uint(len(src)) < m.stride {
+        return fmt.Errorf("invalid row(%v) or src length(%v)", r, len(src))
+    }
+    row := m.row(r)
+    copy(row, src)
+    row[m.stride - 1] &= bvTopMask(m.cols)
+    return nil
+}
+
+// Clone return a copy of m
+func (m *BitMatrix) Clone() (*BitMatrix) {
+    c := *m
+    c.words = slices.Clone(m.words)
+    return &c
+}
+
+// Equal report whether m and n have the same size and bits
+func (m *BitMatrix) Equal(n *BitMatrix) (bool) {
+    return m.rows == n.rows && m.cols == n.cols && slices.Equal(m.words, n.words)
+}
+
+// Transpose return the cols x rows transposed matrix, built from 64x64 tiles
+// transposed by Transpose64
+func (m *BitMatrix) Transpose() (*BitMatrix) {
+    t, _ := NewBitMatrix(m.cols, m.rows)
+    var tile [64]uint64
+    for r0 := uint(0); r0 < m.rows; r0 += 64 {
+        for w := uint(0); w < m.stride; w++ {
+            clear(tile[:])
+            for i := uint(0); i < 64 && r0 + i < m.rows; i++ {
+                tile[i] = m.words[(r0 + i) * m.stride + w]
+            }
+            Transpose64(&tile)
+            for i := uint(0); i < 64 && w * 64 + i < m.cols; i++ {
+                t.words[(w * 64 + i) * t.stride + r0 / 64] = tile[i]
+            }
+        }
+    }
+    return t
+}
+
+// xor the words of src into dst
+func xorRow(dst []uint64, src []uint64) {
+    for i := range dst {
+        dst[i] ^= src[i]
+    }
+}
+
+// Mul return the product m x n, the error is returned if the columns of m do not
+// match the rows of n
+func (m *BitMatrix) Mul(n *BitMatrix) (*BitMatrix, error) {
+    if m.cols != n.rows {
+        return nil, fmt.Errorf("invalid size %vx%v times %vx%v", m.rows, m.cols, n.rows, n.cols)
+    }
+
+    p, _ := NewBitMatrix(m.rows, n.cols)
+    for i := uint(0); i < m.rows; i++ {
+        for w, word := range m.row(i) {
+            for k := range IterOne64(word) {
+                xorRow(p.row(i), n.row(uint(w) * 64 + k))
+            }
+        }
+    }
+    return p, nil
+}
+
+// MulVec return the product m x v of the cols-bit vector v, LSB first
+func (m *BitMatrix) MulVec(v []uint64) ([]uint64, error) {
+    if uint(len(v)) < m.stride {
+        return nil, fmt.Errorf("invalid vector length(%v)", len(v))
+    }
+
+    out := make([]uint64, (m.rows + 63) / 64)
+    for i := uint(0); i < m.rows; i++ {
+        parity := uint(0)
+        for w, word := range m.row(i) {
+            parity += CountOne64(word & v[w])
+        }
+        out[i / 64] |= uint64(parity & 1) << (i % 64)
+    }
+    return out, nil
+}
+
+// real implementation for RowReduce, Inverse and Solve, only the first cols
+// columns are used as pivots
+func (m *BitMatrix) rowReduce(cols uint) (uint) {
+    rank := uint(0)
+    for c := uint(0); c < cols && rank < m.rows; c++ {
+        w, bit := c / 64, uint64(1) << (c % 64)
+        pivot := rank
+        for pivot < m.rows && m.row(pivot)[w] & bit == 0 {
+            pivot++
+        }
+        if pivot == m.rows {
+            continue
+        }
+
+        if pivot != rank {
+            a, b := m.row(pivot), m.row(rank)
+            for i := range a {
+                a[i], b[i] = b[i], a[i]
+            }
+        }
+        for i := uint(0); i < m.rows; i++ {
+            if i != rank && m.row(i)[w] & bit != 0 {
+                xorRow(m.row(i), m.row(rank))
+            }
+        }
+        rank++
+    }
+    return rank
+}
+
+// RowReduce put m in reduced row echelon form by Gaussian elimination and return
+// the rank
+func (m *BitMatrix) RowReduce() (uint) {
+    return m.rowReduce(m.cols)
+}
+
+// Rank return the rank of m
+func (m *BitMatrix) Rank() (uint) {
+    return m.Clone().RowReduce()
+}
+
+// augment return [m | n] with the columns of n after the columns of m
+func (m *BitMatrix) augment(n *BitMatrix) (*BitMatrix) {
+    a, _ := NewBitMatrix(m.rows, m.cols + n.cols)
+    for i := uint(0); i < m.rows; i++ {
+        row := a.row(i)
+        copy(row, m.row(i))
+        for c := range iterBits(n.row(i)) {
+            row[(m.cols + c) / 64] |= uint64(1) << ((m.cols + c) % 64)
+        }
+    }
+    return a
+}
+
+// positions of the 1 bits of words, LSB first
+func iterBits(words []uint64) (iter.Seq[uint]) {
+    return func(yield func(uint) bool) {
+        for w, word := range words {
+            for k := range IterOne64(word) {
+                if !yield(uint(w) * 64 + k) {
+                    return
+                }
+            }
+        }
+    }
+}
+
+// right return the columns from col of m as a new matrix
+func (m *BitMatrix) right(col uint) (*BitMatrix) {
+    r, _ := NewBitMatrix(m.rows, m.cols - col)
+    for i := uint(0); i < m.rows; i++ {
+        for c := range iterBits(m.row(i)) {
+            if c >= col {
+                r.Set(i, c - col, true)
+            }
+        }
+    }
+    return r
+}
+
+// Inverse return the inverse of the square matrix m, the error is returned if m is
+// not square or singular
+func (m *BitMatrix) Inverse() (*BitMatrix, error) {
+    if m.rows != m.cols {
+        return nil, fmt.Errorf("invalid size %vx%v for inverse", m.rows, m.cols)
+    }
+
+    id, _ := IdentityMatrix(m.rows)
+    a := m.augment(id)
+    if a.rowReduce(m.cols) != m.rows {
+        return nil, fmt.Errorf("singular matrix")
+    }
+    return a.right(m.cols), nil
+}
+
+// Solve return a solution x of m x = b with b a rows-bit vector, LSB first. Free
+// variables are 0 and the error is returned if the system has no solution
+func (m *BitMatrix) Solve(b []uint64) ([]uint64, error) {
+    if uint(len(b)) < (m.rows + 63) / 64 {
+        return nil, fmt.Errorf("invalid vector length(%v)", len(b))
+    }
+
+    rhs, _ := NewBitMatrix(m.rows, 1)
+    for i := uint(0); i < m.rows; i++ {
+        rhs.Set(i, 0, b[i / 64] >> (i % 64) & 1 == 1)
+    }
+    a := m.augment(rhs)
+    rank := a.rowReduce(m.cols)
+
+    x := make([]uint64, m.stride)
+    for i := uint(0); i < m.rows; i++ {
+        value, _ := a.Get(i, m.cols)
+        if i >= rank {
+            if value {
+                return nil, fmt.Errorf("inconsistent system")
+            }
+            continue
+        }
+        //the pivot is the lowest set bit of a reduced row
+        for pivot := range iterBits(a.row(i)) {
+            if value {
+                x[pivot / 64] |= uint64(1) << (pivot % 64)
+            }
+            break
+        }
+    }
+    return x, nil
+}
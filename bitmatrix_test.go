@@ -0,0 +1,207 @@
+package bitops
+
+import (
+    "math/rand"
+    "slices"
+    "testing"
+)
+
+// random rows x cols matrix
+func randomBitMatrix(rng *rand.Rand, rows uint, cols uint) (*BitMatrix) {
+    m, _ := NewBitMatrix(rows, cols)
+    for r := uint(0); r < rows; r++ {
+        for c := uint(0); c < cols; c++ {
+            m.Set(r, c, rng.Intn(2) == 1)
+        }
+    }
+    return m
+}
+
+func TestTranspose(t *testing.T) {
+    rng := rand.New(rand.NewSource(1))
+    for n := 0; n < 100; n++ {
+        x := rng.Uint64()
+        ret := Transpose8(x)
+        for r := uint(0); r < 8; r++ {
+            for c := uint(0); c < 8; c++ {
+                if (x >> (8 * r + c)) & 1 != (ret >> (8 * c + r)) & 1 {
+                    t.Fatalf("transpose8 %x get %x", x, ret)
+                }
+            }
+        }
+        if Transpose8(ret) != x {
+            t.Fatalf("transpose8 twice %x", x)
+        }
+
+        var m32 [32]uint32
+        for i := range m32 {
+            m32[i] = rng.Uint32()
+        }
+        t32 := m32
+        Transpose32(&t32)
+        var m64 [64]uint64
+        for i := range m64 {
+            m64[i] = rng.Uint64()
+        }
+        t64 := m64
+        Transpose64(&t64)
+        for r := uint(0); r < 64; r++ {
+            for c := uint(0); c < 64; c++ {
+                if r < 32 && c < 32 && (m32[r] >> c) & 1 != (t32[c] >> r) & 1 {
+                    t.Fatalf("transpose32 differ at %d,%d", r, c)
+                }
+                if (m64[r] >> c) & 1 != (t64[c] >> r) & 1 {
+                    t.Fatalf("transpose64 differ at %d,%d", r, c)
+                }
+            }
+        }
+    }
+
+    m := randomBitMatrix(rng, 100, 70)
+    tr := m.Transpose()
+    if tr.Rows() != 70 || tr.Cols() != 100 {
+        t.Fatalf("transpose size %dx%d", tr.Rows(), tr.Cols())
+    }
+    for r := uint(0); r < 100; r++ {
+        for c := uint(0); c < 70; c++ {
+            a, _ := m.Get(r, c)
+            b, _ := tr.Get(c, r)
+            if a != b {
+                t.Fatalf("transpose differ at %d,%d", r, c)
+            }
+        }
+    }
+    if !tr.Transpose().Equal(m) {
+        t.Fail()
+        t.Log("transpose twice")
+    }
+}
+
+func TestBitMatrixMul(t *testing.T) {
+    rng := rand.New(rand.NewSource(2))
+    a := randomBitMatrix(rng, 30, 80)
+    b := randomBitMatrix(rng, 80, 70)
+    p, err := a.Mul(b)
+    if err != nil {
+        t.Fatal(err)
+    }
+    for r := uint(0); r < 30; r++ {
+        for c := uint(0); c < 70; c++ {
+            sum := false
+            for k := uint(0); k < 80; k++ {
+                x, _ := a.Get(r, k)
+                y, _ := b.Get(k, c)
+                sum = sum != (x && y)
+            }
+            if got, _ := p.Get(r, c); got != sum {
+                t.Fatalf("product differ at %d,%d", r, c)
+            }
+        }
+    }
+
+    //(AB)^T = B^T A^T and A x is the product with a one column matrix
+    q, _ := b.Transpose().Mul(a.Transpose())
+    if !q.Equal(p.Transpose()) {
+        t.Fail()
+        t.Log("transpose of product")
+    }
+    x := randomBitMatrix(rng, 70, 1)
+    v, _ := p.MulVec(x.Transpose().row(0))
+    px, _ := p.Mul(x)
+    if !slices.Equal(v, px.Transpose().row(0)) {
+        t.Fail()
+        t.Logf("mul vec get %x", v)
+    }
+
+    if _, err = a.Mul(a); err == nil {
+        t.Fail()
+        t.Log("size mismatch")
+    }
+    if _, err = NewBitMatrix(0, 3); err == nil {
+        t.Fail()
+        t.Log("0 rows")
+    }
+    if _, err = a.Get(30, 0); err == nil {
+        t.Fail()
+        t.Log("row out of range")
+    }
+}
+
+func TestBitMatrixInverse(t *testing.T) {
+    rng := rand.New(rand.NewSource(3))
+    id, _ := IdentityMatrix(90)
+    inverted := 0
+    for n := 0; n < 20; n++ {
+        m := randomBitMatrix(rng, 90, 90)
+        inv, err := m.Inverse()
+        if m.Rank() < 90 {
+            if err == nil {
+                t.Fatal("singular matrix inverted")
+            }
+            continue
+        }
+        if err != nil {
+            t.Fatal(err)
+        }
+        p, _ := m.Mul(inv)
+        q, _ := inv.Mul(m)
+        if !p.Equal(id) || !q.Equal(id) {
+            t.Fatal("m x inverse is not identity")
+        }
+        inverted++
+    }
+    if inverted == 0 {
+        t.Fatal("no invertible matrix drawn")
+    }
+
+    //rows 0 and 1 add up to row 2
+    m, _ := NewBitMatrix(3, 3)
+    m.SetRow(0, []uint64{0b011})
+    m.SetRow(1, []uint64{0b110})
+    m.SetRow(2, []uint64{0b101})
+    if rank := m.Rank(); rank != 2 {
+        t.Fail()
+        t.Logf("rank expect 2 but get %d", rank)
+    }
+    if rank := m.RowReduce(); rank != 2 {
+        t.Fail()
+        t.Logf("row reduce get %d", rank)
+    }
+    if r, _ := m.Row(0); r[0] != 0b101 {
+        t.Fail()
+        t.Logf("reduced row 0 get %b", r[0])
+    }
+    if _, err := randomBitMatrix(rng, 3, 4).Inverse(); err == nil {
+        t.Fail()
+        t.Log("non square inverse")
+    }
+}
+
+func TestBitMatrixSolve(t *testing.T) {
+    rng := rand.New(rand.NewSource(4))
+    for n := 0; n < 20; n++ {
+        m := randomBitMatrix(rng, 60, 100)
+        x := []uint64{rng.Uint64(), rng.Uint64() & 0xFFFFFFFFF}
+        b, _ := m.MulVec(x)
+        y, err := m.Solve(b)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if c, _ := m.MulVec(y); !slices.Equal(c, b) {
+            t.Fatalf("solution %x give %x instead of %x", y, c, b)
+        }
+    }
+
+    //x0 + x1 = 1 and x0 + x1 = 0
+    m, _ := NewBitMatrix(2, 2)
+    m.SetRow(0, []uint64{3})
+    m.SetRow(1, []uint64{3})
+    if _, err := m.Solve([]uint64{1}); err == nil {
+        t.Fail()
+        t.Log("inconsistent system")
+    }
+    if x, err := m.Solve([]uint64{3}); err != nil || x[0] != 1 {
+        t.Fail()
+        t.Logf("free variable get %v %v", x, err)
+    }
+}
@@ -56,6 +56,28 @@ func GetField64(value uint64, high uint, low uint) (uint64, error) {
     return extract64(value, low, high - low + 1)
 }
 
+// GetSignedField32 specify field between high and low bit from uint32 and sign-extend
+// it from bit high. LSB/MSB are 0/31 and return 0 if error occurs
+func GetSignedField32(value uint32, high uint, low uint) (int32, error) {
+    if high > 31  || low > 31 || high < low {
+        return 0, fmt.Errorf("invalid high(%v) or low(%v)", high, low);
+    }
+
+    shift := 31 - high + low
+    return int32(value << (31 - high)) >> shift, nil
+}
+
+// GetSignedField64 specify field between high and low bit from uint64 and sign-extend
+// it from bit high. LSB/MSB are 0/63 and return 0 if error occurs
+func GetSignedField64(value uint64, high uint, low uint) (int64, error) {
+    if high > 63  || low > 63 || high < low {
+        return 0, fmt.Errorf("invalid high(%v) or low(%v)", high, low);
+    }
+
+    shift := 63 - high + low
+    return int64(value << (63 - high)) >> shift, nil
+}
+
 // real implementation for Depoit32 and SetField32
 func deposit32(value uint32, start uint, length uint, field uint32) (uint32, error) {
     mask := (^uint32(0) >> (32 - length)) << start;
@@ -110,44 +132,22 @@ func SetField64(value uint64, high uint, low uint, field uint64) (uint64, error)
 
 // CountOne8 return number of 1 in uint8 variable
 func CountOne8(value uint8) (uint) {
-    value = (value & 0x55) + ((value >> 1) & 0x55);
-    value = (value & 0x33) + ((value >> 2) & 0x33);
-    value = (value & 0x0f) + ((value >> 4) & 0x0f);
-
-    return uint(value);
+    return countOne8(value)
 }
 
 // CountOne16 return number of 1 in uint16 variable
 func CountOne16(value uint16) (uint) {
-    value = (value & 0x5555) + ((value >> 1) & 0x5555);
-    value = (value & 0x3333) + ((value >> 2) & 0x3333);
-    value = (value & 0x0f0f) + ((value >> 4) & 0x0f0f);
-    value = (value & 0x00ff) + ((value >> 8) & 0x00ff);
-
-    return uint(value);
+    return countOne16(value)
 }
 
 // CountOne32 return number of 1 in uint32 variable
 func CountOne32(value uint32) (uint) {
-    value = (value & 0x55555555) + ((value >>  1) & 0x55555555);
-    value = (value & 0x33333333) + ((value >>  2) & 0x33333333);
-    value = (value & 0x0f0f0f0f) + ((value >>  4) & 0x0f0f0f0f);
-    value = (value & 0x00ff00ff) + ((value >>  8) & 0x00ff00ff);
-    value = (value & 0x0000ffff) + ((value >> 16) & 0x0000ffff);
-
-    return uint(value);
+    return countOne32(value)
 }
 
 // CountOne64 return number of 1 in uint64 variable
 func CountOne64(value uint64) (uint) {
-    value = (value & 0x5555555555555555) + ((value >>  1) & 0x5555555555555555);
-    value = (value & 0x3333333333333333) + ((value >>  2) & 0x3333333333333333);
-    value = (value & 0x0f0f0f0f0f0f0f0f) + ((value >>  4) & 0x0f0f0f0f0f0f0f0f);
-    value = (value & 0x00ff00ff00ff00ff) + ((value >>  8) & 0x00ff00ff00ff00ff);
-    value = (value & 0x0000ffff0000ffff) + ((value >> 16) & 0x0000ffff0000ffff);
-    value = (value & 0x00000000ffffffff) + ((value >> 32) & 0x00000000ffffffff);
-
-    return uint(value);
+    return countOne64(value)
 }
 
 // CountOne8 return number of 0 in uint8 variable
@@ -172,45 +172,12 @@ func CountZero64(value uint64) (uint) {
 
 // CountTrailZero32 return number of trailing zero in a 32-bit value
 func CountTrailZero32(value uint32) (uint) {
-    var count uint = 0
-
-    if (value & 0x0000FFFF) == 0 {
-        count += 16;
-        value >>= 16;
-    }
-    if (value & 0x000000FF) == 0 {
-        count += 8;
-        value >>= 8;
-    }
-    if (value & 0x0000000F) == 0 {
-        count += 4;
-        value >>= 4;
-    }
-    if (value & 0x00000003) == 0 {
-        count += 2;
-        value >>= 2;
-    }
-    if (value & 0x00000001) == 0 {
-        count++;
-        value >>= 1;
-    }
-    if (value & 0x00000001) == 0 {
-        count++;
-    }
-
-    return count
+    return countTrailZero32(value)
 }
 
 // CountTrailZero64 return number of trailing zero in a 32-bit value
 func CountTrailZero64(value uint64) (uint) {
-    var count uint = 0
-
-    if  uint32(value) == 0 {
-        count += 32;
-        value >>= 32;
-    }
-
-    return count + CountTrailZero32(uint32(value))
+    return countTrailZero64(value)
 }
 
 // CountTrailOne32 return number of trailing 1 in a 32-bit value
@@ -225,46 +192,12 @@ func CountTrailOne64(value uint64) (uint) {
 
 // CountLeadZero32 return number of leading 0 in a 32-bit value
 func CountLeadZero32(value uint32) (uint) {
-    var count uint = 0
-
-    if (value & 0xFFFF0000) == 0 {
-        count += 16;
-        value <<= 16;
-    }
-    if (value & 0xFF000000) == 0 {
-        count += 8;
-        value <<= 8;
-    }
-    if (value & 0xF0000000) == 0 {
-        count += 4;
-        value <<= 4;
-    }
-    if (value & 0x30000000) == 0 {
-        count += 2;
-        value <<= 2;
-    }
-    if (value & 0x10000000) == 0 {
-        count++;
-        value <<= 1;
-    }
-    if (value & 0x10000000) == 0 {
-        count++;
-    }
-
-    return count
+    return countLeadZero32(value)
 }
 
 // CountLeadZero64 return number of leading 0 in a 32-bit value
 func CountLeadZero64(value uint64) (uint) {
-    var count uint = 0
-
-    if (value >> 32) == 0 {
-        count += 32
-    } else {
-        value >>= 32;
-    }
-
-    return count + CountLeadZero32(uint32(value))
+    return countLeadZero64(value)
 }
 
 // CountLeadOne32 return number of leading 1 in a 32-bit value
@@ -347,47 +280,30 @@ func TestBit64(value uint64, pos uint) (bool, error) {
 
 // Reverse32 set reverse the bit order for 32-bit variable
 func Reverse32(value uint32) (uint32) {
-    value = ((value >> 1) & 0x55555555) | ((value & 0x55555555) << 1)
-    value = ((value >> 2) & 0x33333333) | ((value & 0x33333333) << 2)
-    value = ((value >> 4) & 0x0F0F0F0F) | ((value & 0x0F0F0F0F) << 4)
-    value = ((value >> 8) & 0x00FF00FF) | ((value & 0x00FF00FF) << 8)
-    value = ( value >> 16             ) | ( value               << 16)
-
-    return value
+    return reverse32(value)
 }
 
 // Reverse64 set reverse the bit order for 64-bit variable
 func Reverse64(value uint64) (uint64) {
-    high :=  Reverse32(uint32(value))
-    low :=  Reverse32(uint32(value >> 32))
-
-    return (uint64(high) << 32) | uint64(low)
+    return reverse64(value)
 }
 
 // RotateRight32 rotate an 32-bit value right
 func RotateRight32(value uint32, shift uint) (uint32) {
-    shift = shift & 0x1F
-
-    return (value >> shift) | (value << (32 - shift))
+    return rotateRight32(value, shift)
 }
 
 // RotateLeft32 rotate an 32-bit value left
-func RotateLeft32(value uint32, shift uint) (uint32){
-    shift = shift & 0x1F
-
-    return (value << shift) | (value >> (32 - shift))
+func RotateLeft32(value uint32, shift uint) (uint32) {
+    return rotateLeft32(value, shift)
 }
 
 // RotateRight64 rotate an 64-bit value right
-func RotateRight64(value uint64, shift uint) (uint64){
-    shift = shift & 0x3F
-
-    return (value >> shift) | (value << (64 - shift))
+func RotateRight64(value uint64, shift uint) (uint64) {
+    return rotateRight64(value, shift)
 }
 
 // RotateLeft64 rotate an 64-bit value left
-func RotateLeft64(value uint64, shift uint) (uint64){
-    shift = shift & 0x3F
-
-    return (value << shift) | (value >> (64 - shift))
+func RotateLeft64(value uint64, shift uint) (uint64) {
+    return rotateLeft64(value, shift)
 }
@@ -230,6 +230,80 @@ func TestGetField64(t *testing.T) {
     }
 }
 
+func TestGetSignedField32(t *testing.T) {
+    var value uint32 = 0x8000F7F0
+    var field int32
+
+    //check error
+    _, err := GetSignedField32(value, 32, 0)
+    if err == nil {
+        t.Fail()
+        t.Log("invalid high")
+    }
+
+    _, err = GetSignedField32(value, 10, 20)
+    if err == nil {
+        t.Fail()
+        t.Log("high < low")
+    }
+
+    //check pass case
+    field, err = GetSignedField32(value, 31, 31)
+    if err != nil || field != -1 {
+        t.Fail()
+        t.Logf("MSB get %d", field)
+    }
+
+    field, err = GetSignedField32(value, 15, 4)
+    if err != nil || field != -129 {
+        t.Fail()
+        t.Logf("negative field get %d", field)
+    }
+
+    field, err = GetSignedField32(value, 11, 4)
+    if err != nil || field != 0x7F {
+        t.Fail()
+        t.Logf("positive field get %d", field)
+    }
+
+    field, err = GetSignedField32(value, 31, 0)
+    if err != nil || field != int32(-0x7FFF0810) {
+        t.Fail()
+        t.Logf("full width get %d", field)
+    }
+}
+
+func TestGetSignedField64(t *testing.T) {
+    var value uint64 = 0xF0F0F0F0F0F0F0F0
+    var field int64
+
+    //check error
+    _, err := GetSignedField64(value, 0, 64)
+    if err == nil {
+        t.Fail()
+        t.Log("invalid low")
+    }
+
+    //check pass case
+    field, err = GetSignedField64(value, 63, 60)
+    if err != nil || field != -1 {
+        t.Fail()
+        t.Logf("MSB get %d", field)
+    }
+
+    field, err = GetSignedField64(value, 7, 4)
+    if err != nil || field != -1 {
+        t.Fail()
+        t.Logf("negative field get %d", field)
+    }
+
+    field, err = GetSignedField64(value, 8, 4)
+    if err != nil || field != 0xF {
+        t.Fail()
+        t.Logf("positive field get %d", field)
+    }
+}
+
 func TestDeposit32(t *testing.T) {
     var value uint32 = 0xF0F0F0F0
     var length uint
@@ -659,6 +733,17 @@ func TestCountLeadZero32(t *testing.T) {
 
         value >>= 8
     }
+
+    value = 0xFFFFFFFF
+    for i = 0; i < 32; i++ {
+        count = CountLeadZero32(value)
+        if expect_cnt = i; count != expect_cnt {
+            t.Fail()
+            t.Logf("expect %2d for %2d but get %.8x", expect_cnt, count, value)
+        }
+
+        value >>= 1
+    }
 }
 
 func TestCountLeadZero64(t *testing.T) {
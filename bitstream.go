@@ -0,0 +1,151 @@
+package bitops
+
+import (
+    "errors"
+    "fmt"
+)
+
+// ErrTruncated is returned when a read or an encoding run past the end of the input
+var ErrTruncated = errors.New("truncated input")
+
+// BitWriter append bits to a byte slice, the first bit written is the MSB of the
+// first byte as in most network and video bitstreams
+type BitWriter struct {
+    buf    []byte
+    length uint64
+}
+
+// NewBitWriter create an empty writer
+func NewBitWriter() (*BitWriter) {
+    return &BitWriter{}
+}
+
+// Len return the number of bits written
+func (w *BitWriter) Len() (uint64) {
+    return w.length
+}
+
+// Bytes return the written bits, the unused low bits of the last byte are 0
+func (w *BitWriter) Bytes() ([]byte) {
+    return w.buf
+}
+
+// WriteBits write the low n bits of value, MSB first. Bits of value above n are
+// ignored and return error if n is larger than 64
+func (w *BitWriter) WriteBits(value uint64, n uint) (error) {
+    if n > 64 {
+        return fmt.Errorf("invalid length(%v)", n)
+    }
+
+    for n > 0 {
+        used := uint(w.length % 8)
+        if used == 0 {
+            w.buf = append(w.buf, 0)
+        }
+        take := min(8 - used, n)
+        chunk := byte(value >> (n - take)) & (0xFF >> (8 - take))
+        w.buf[len(w.buf) - 1] |= chunk << (8 - used - take)
+        w.length += uint64(take)
+        n -= take
+    }
+    return nil
+}
+
+// WriteBit write a single bit
+func (w *BitWriter) WriteBit(bit bool) {
+    if bit {
+        w.WriteBits(1, 1)
+    } else {
+        w.WriteBits(0, 1)
+    }
+}
+
+// WriteByte write 8 bits, the writer does not need to be byte aligned
+func (w *BitWriter) WriteByte(c byte) (error) {
+    return w.WriteBits(uint64(c), 8)
+}
+
+// Align write 0 bits up to the next byte boundary
+func (w *BitWriter) Align() {
+    w.length = (w.length + 7) &^ 7
+}
+
+// BitReader read bits from a byte slice in the order BitWriter write them
+type BitReader struct {
+    buf []byte
+    pos uint64
+}
+
+// NewBitReader create a reader starting at the MSB of buf[0]
+func NewBitReader(buf []byte) (*BitReader) {
+    return &BitReader{buf: buf}
+}
+
+// Pos return the number of bits consumed
+func (r *BitReader) Pos() (uint64) {
+    return r.pos
+}
+
+// Remaining return the number of bits left
+func (r *BitReader) Remaining() (uint64) {
+    return uint64(len(r.buf)) * 8 - r.pos
+}
+
+// PeekBits return the next n bits without consuming them. It return ErrTruncated
+// if less than n bits are left
+func (r *BitReader) PeekBits(n uint) (uint64, error) {
+    if n > 64 {
+        return 0, fmt.Errorf("invalid length(%v)", n)
+    }
+    if uint64(n) > r.Remaining() {
+        return 0, ErrTruncated
+    }
+
+    var value uint64
+    for pos := r.pos; n > 0; {
+        used := uint(pos % 8)
+        take := min(8 - used, n)
+        chunk := (r.buf[pos / 8] >> (8 - used - take)) & (0xFF >> (8 - take))
+        value = (value << take) | uint64(chunk)
+        pos += uint64(take)
+        n -= take
+    }
+    return value, nil
+}
+
+// ReadBits consume the next n bits and return them in the low bits of the result.
+// Nothing is consumed if error occurs
+func (r *BitReader) ReadBits(n uint) (uint64, error) {
+    value, err := r.PeekBits(n)
+    if err == nil {
+        r.pos += uint64(n)
+    }
+    return value, err
+}
+
+// ReadBit consume a single bit
+func (r *BitReader) ReadBit() (bool, error) {
+    value, err := r.ReadBits(1)
+    return value == 1, err
+}
+
+// ReadByte consume 8 bits, the reader does not need to be byte aligned
+func (r *BitReader) ReadByte() (byte, error) {
+    value, err := r.ReadBits(8)
+    return byte(value), err
+}
+
+// SkipBits consume n bits and return ErrTruncated if less than n bits are left
+func (r *BitReader) SkipBits(n uint64) (error) {
+    if n > r.Remaining() {
+        return ErrTruncated
+    }
+
+    r.pos += n
+    return nil
+}
+
+// Align skip the bits up to the next byte boundary
+func (r *BitReader) Align() {
+    r.pos = min((r.pos + 7) &^ 7, uint64(len(r.buf)) * 8)
+}
@@ -0,0 +1,89 @@
+package bitops
+
+import (
+    "bytes"
+    "errors"
+    "testing"
+)
+
+func TestBitWriter(t *testing.T) {
+    w := NewBitWriter()
+    w.WriteBits(0x5, 3)
+    w.WriteBit(true)
+    w.WriteBits(0xFFF0, 8)
+    w.WriteByte(0xA5)
+    if w.Len() != 20 {
+        t.Fail()
+        t.Logf("expect 20 bits but get %d", w.Len())
+    }
+    if b := w.Bytes(); !bytes.Equal(b, []byte{0xBF, 0x0A, 0x50}) {
+        t.Fail()
+        t.Logf("get % x", b)
+    }
+
+    w.Align()
+    w.WriteBits(0x0123456789ABCDEF, 64)
+    if w.Len() != 88 || w.Bytes()[3] != 0x01 || w.Bytes()[10] != 0xEF {
+        t.Fail()
+        t.Logf("aligned write get % x", w.Bytes())
+    }
+
+    if err := w.WriteBits(0, 65); err == nil {
+        t.Fail()
+        t.Log("length 65")
+    }
+}
+
+func TestBitReader(t *testing.T) {
+    r := NewBitReader([]byte{0xBF, 0x0A, 0x50})
+    if v, err := r.ReadBits(3); err != nil || v != 0x5 {
+        t.Fail()
+        t.Logf("read 3 bits get %x", v)
+    }
+    if v, err := r.ReadBit(); err != nil || !v {
+        t.Fail()
+        t.Log("read bit")
+    }
+    if v, err := r.PeekBits(8); err != nil || v != 0xF0 || r.Pos() != 4 {
+        t.Fail()
+        t.Logf("peek 8 bits get %x", v)
+    }
+    r.SkipBits(8)
+    if v, err := r.ReadByte(); err != nil || v != 0xA5 {
+        t.Fail()
+        t.Logf("read byte get %x", v)
+    }
+
+    if r.Remaining() != 4 {
+        t.Fail()
+        t.Logf("remaining get %d", r.Remaining())
+    }
+    if _, err := r.ReadBits(5); !errors.Is(err, ErrTruncated) || r.Pos() != 20 {
+        t.Fail()
+        t.Log("truncated read must not consume")
+    }
+    r.Align()
+    if r.Remaining() != 0 {
+        t.Fail()
+        t.Log("align")
+    }
+    if err := r.SkipBits(1); err != ErrTruncated {
+        t.Fail()
+        t.Log("skip past end")
+    }
+}
+
+func TestBitStreamRoundTrip(t *testing.T) {
+    w := NewBitWriter()
+    for n := uint(0); n <= 64; n++ {
+        w.WriteBits(^uint64(0) >> (64 - n) & 0x5555555555555555, n)
+    }
+
+    r := NewBitReader(w.Bytes())
+    for n := uint(0); n <= 64; n++ {
+        expect := ^uint64(0) >> (64 - n) & 0x5555555555555555
+        if v, err := r.ReadBits(n); err != nil || v != expect {
+            t.Fatalf("%d bits expect %x but get %x", n, expect, v)
+        }
+    }
+}
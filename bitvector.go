@@ -0,0 +1,708 @@
+package bitops
+
+import (
+    "fmt"
+    "math/bits"
+    "strings"
+)
+
+// BitVector is an unsigned bit vector with a fixed width like a Verilog bus. Bits
+// are kept LSB first in 64-bit words and every operation wraps around at the width.
+// A bit can also be unknown (x); z in literals is treated as x. Operations follow
+// Verilog rules for unknown bits: bitwise operators propagate x only when the result
+// depends on it, and arithmetic turns the whole result into x
+type BitVector struct {
+    width uint
+    val   []uint64
+    xz    []uint64
+}
+
+// number of 64-bit words for the given width
+func bvWords(width uint) (int) {
+    return int((width + 63) / 64)
+}
+
+// mask of valid bits in the top word for the given width
+func bvTopMask(width uint) (uint64) {
+    if width % 64 == 0 {
+        return ^uint64(0)
+    }
+    return ^uint64(0) >> (64 - width % 64)
+}
+
+// allocate a zero vector, width must be larger than 0
+func newBitVector(width uint) (BitVector) {
+    n := bvWords(width)
+    return BitVector{width: width, val: make([]uint64, n), xz: make([]uint64, n)}
+}
+
+// clear bits above width and keep val 0 for unknown bits
+func (v BitVector) normalize() (BitVector) {
+    //the zero value returned on errors has no words
+    if v.width == 0 {
+        return v
+    }
+    top := len(v.val) - 1
+    v.val[top] &= bvTopMask(v.width)
+    v.xz[top] &= bvTopMask(v.width)
+    for i := range v.val {
+        v.val[i] &^= v.xz[i]
+    }
+    return v
+}
+
+// return a copy of v zero-extended or truncated to width
+func (v BitVector) resize(width uint) (BitVector) {
+    ret := newBitVector(width)
+    copy(ret.val, v.val)
+    copy(ret.xz, v.xz)
+    return ret.normalize()
+}
+
+// shift words left by s bits into n words
+func shlWords(src []uint64, n int, s uint) ([]uint64) {
+    dst := make([]uint64, n)
+    ws, bs := int(s / 64), s % 64
+    for i := n - 1; i >= ws; i-- {
+        j := i - ws
+        var w uint64
+        if j < len(src) {
+            w = src[j] << bs
+        }
+        if bs != 0 && j - 1 >= 0 && j - 1 < len(src) {
+            w |= src[j - 1] >> (64 - bs)
+        }
+        dst[i] = w
+    }
+    return dst
+}
+
+// shift words right by s bits into n words
+func shrWords(src []uint64, n int, s uint) ([]uint64) {
+    dst := make([]uint64, n)
+    ws, bs := int(s / 64), s % 64
+    for i := 0; i < n; i++ {
+        j := i + ws
+        var w uint64
+        if j < len(src) {
+            w = src[j] >> bs
+        }
+        if bs != 0 && j + 1 < len(src) {
+            w |= src[j + 1] << (64 - bs)
+        }
+        dst[i] = w
+    }
+    return dst
+}
+
+// build a mask of length ones starting at bit start into n words
+func onesWords(n int, start uint, length uint) ([]uint64) {
+    ones := make([]uint64, bvWords(length))
+    for i := range ones {
+        ones[i] = ^uint64(0)
+    }
+    if length % 64 != 0 {
+        ones[len(ones) - 1] = bvTopMask(length)
+    }
+    return shlWords(ones, n, start)
+}
+
+// NewBitVector create a width-bit vector from value. value wraps around at width
+// and the error is returned for 0 width
+func NewBitVector(width uint, value uint64) (BitVector, error) {
+    if width == 0 {
+        return BitVector{}, fmt.Errorf("invalid width(%v)", width)
+    }
+
+    ret := newBitVector(width)
+    ret.val[0] = value
+    return ret.normalize(), nil
+}
+
+// NewBitVectorWords create a width-bit vector from words in LSB first order. Words
+// beyond width are ignored and the error is returned for 0 width
+func NewBitVectorWords(width uint, words []uint64) (BitVector, error) {
+    if width == 0 {
+        return BitVector{}, fmt.Errorf("invalid width(%v)", width)
+    }
+
+    ret := newBitVector(width)
+    copy(ret.val, words)
+    return ret.normalize(), nil
+}
+
+// Width return the declared width of the vector
+func (v BitVector) Width() (uint) {
+    return v.width
+}
+
+// IsUnknown report whether any bit of the vector is x
+func (v BitVector) IsUnknown() (bool) {
+    for _, w := range v.xz {
+        if w != 0 {
+            return true
+        }
+    }
+    return false
+}
+
+// Uint64 return the value as uint64. It fails if any bit is x or the value does
+// not fit in 64 bits
+func (v BitVector) Uint64() (uint64, error) {
+    if v.IsUnknown() {
+        return 0, fmt.Errorf("unknown value(%v)", v)
+    }
+    if v.width == 0 {
+        return 0, nil
+    }
+    for _, w := range v.val[1:] {
+        if w != 0 {
+            return 0, fmt.Errorf("value out of range(%v)", v)
+        }
+    }
+    return v.val[0], nil
+}
+
+// Equal report whether v and w have the same width and the same bits including x,
+// like the === operator
+func (v BitVector) Equal(w BitVector) (bool) {
+    if v.width != w.width {
+        return false
+    }
+    for i := range v.val {
+        if v.val[i] != w.val[i] || v.xz[i] != w.xz[i] {
+            return false
+        }
+    }
+    return true
+}
+
+// TestBit return the specified bit of the vector, the second value is true if the
+// bit is x
+func (v BitVector) TestBit(pos uint) (bool, bool, error) {
+    if pos >= v.width {
+        return false, false, fmt.Errorf("invalid position(%v)", pos)
+    }
+
+    w, b := pos / 64, pos % 64
+    return (v.val[w] >> b) & 1 == 1, (v.xz[w] >> b) & 1 == 1, nil
+}
+
+// GetField return the slice v[high:low] as a new (high-low+1)-bit vector
+func (v BitVector) GetField(high uint, low uint) (BitVector, error) {
+    if high >= v.width || low >= v.width || high < low {
+        return v, fmt.Errorf("invalid high(%v) or low(%v)", high, low)
+    }
+
+    width := high - low + 1
+    ret := BitVector{width: width}
+    ret.val = shrWords(v.val, bvWords(width), low)
+    ret.xz = shrWords(v.xz, bvWords(width), low)
+    return ret.normalize(), nil
+}
+
+// SetField replace the slice v[high:low] by field and return the new vector. field
+// is truncated or zero-extended to high-low+1 bits
+func (v BitVector) SetField(high uint, low uint, field BitVector) (BitVector, error) {
+    if high >= v.width || low >= v.width || high < low {
+        return v, fmt.Errorf("invalid high(%v) or low(%v)", high, low)
+    }
+
+    n := len(v.val)
+    mask := onesWords(n, low, high - low + 1)
+    fval := shlWords(field.val, n, low)
+    fxz := shlWords(field.xz, n, low)
+    ret := newBitVector(v.width)
+    for i := 0; i < n; i++ {
+        ret.val[i] = (v.val[i] &^ mask[i]) | (fval[i] & mask[i])
+        ret.xz[i] = (v.xz[i] &^ mask[i]) | (fxz[i] & mask[i])
+    }
+    return ret.normalize(), nil
+}
+
+// Concat concatenate vectors like {a, b, c} in Verilog, the first vector becomes
+// the most significant part. The error is returned if no vector is given
+func Concat(vs ...BitVector) (BitVector, error) {
+    var width uint = 0
+    for _, v := range vs {
+        width += v.width
+    }
+    if width == 0 {
+        return BitVector{}, fmt.Errorf("invalid width(%v)", width)
+    }
+
+    ret := newBitVector(width)
+    var pos uint = 0
+    for i := len(vs) - 1; i >= 0; i-- {
+        if vs[i].width == 0 {
+            continue
+        }
+        val := shlWords(vs[i].val, len(ret.val), pos)
+        xz := shlWords(vs[i].xz, len(ret.val), pos)
+        for j := range ret.val {
+            ret.val[j] |= val[j]
+            ret.xz[j] |= xz[j]
+        }
+        pos += vs[i].width
+    }
+    return ret.normalize(), nil
+}
+
+// Replicate repeat v count times like {count{v}} in Verilog
+func Replicate(count uint, v BitVector) (BitVector, error) {
+    if count == 0 {
+        return BitVector{}, fmt.Errorf("invalid count(%v)", count)
+    }
+
+    vs := make([]BitVector, count)
+    for i := range vs {
+        vs[i] = v
+    }
+    return Concat(vs...)
+}
+
+// extend both operands to the wider width like Verilog expression sizing
+func bvExtend(v BitVector, w BitVector) (BitVector, BitVector) {
+    if v.width > w.width {
+        return v, w.resize(v.width)
+    }
+    if w.width > v.width {
+        return v.resize(w.width), w
+    }
+    return v, w
+}
+
+// Not return ~v
+func (v BitVector) Not() (BitVector) {
+    ret := newBitVector(v.width)
+    for i := range v.val {
+        ret.val[i] = ^v.val[i] &^ v.xz[i]
+        ret.xz[i] = v.xz[i]
+    }
+    return ret.normalize()
+}
+
+// And return v & w, a known 0 on either side wins over x
+func (v BitVector) And(w BitVector) (BitVector) {
+    v, w = bvExtend(v, w)
+    ret := newBitVector(v.width)
+    for i := range v.val {
+        zero := (^v.val[i] &^ v.xz[i]) | (^w.val[i] &^ w.xz[i])
+        ret.val[i] = v.val[i] & w.val[i]
+        ret.xz[i] = (v.xz[i] | w.xz[i]) &^ zero
+    }
+    return ret.normalize()
+}
+
+// Or return v | w, a known 1 on either side wins over x
+func (v BitVector) Or(w BitVector) (BitVector) {
+    v, w = bvExtend(v, w)
+    ret := newBitVector(v.width)
+    for i := range v.val {
+        one := v.val[i] | w.val[i]
+        ret.val[i] = one
+        ret.xz[i] = (v.xz[i] | w.xz[i]) &^ one
+    }
+    return ret.normalize()
+}
+
+// Xor return v ^ w
+func (v BitVector) Xor(w BitVector) (BitVector) {
+    v, w = bvExtend(v, w)
+    ret := newBitVector(v.width)
+    for i := range v.val {
+        ret.xz[i] = v.xz[i] | w.xz[i]
+        ret.val[i] = v.val[i] ^ w.val[i]
+    }
+    return ret.normalize()
+}
+
+// make a 1-bit vector with a known value or x
+func bvBit(one bool, unknown bool) (BitVector) {
+    ret := newBitVector(1)
+    if unknown {
+        ret.xz[0] = 1
+    } else if one {
+        ret.val[0] = 1
+    }
+    return ret
+}
+
+// ReduceAnd return the 1-bit result of &v
+func (v BitVector) ReduceAnd() (BitVector) {
+    unknown := false
+    for i := range v.val {
+        mask := ^uint64(0)
+        if i == len(v.val) - 1 {
+            mask = bvTopMask(v.width)
+        }
+        if (^v.val[i] &^ v.xz[i]) & mask != 0 {
+            return bvBit(false, false)
+        }
+        unknown = unknown || v.xz[i] != 0
+    }
+    return bvBit(true, unknown)
+}
+
+// ReduceOr return the 1-bit result of |v
+func (v BitVector) ReduceOr() (BitVector) {
+    unknown := false
+    for i := range v.val {
+        if v.val[i] != 0 {
+            return bvBit(true, false)
+        }
+        unknown = unknown || v.xz[i] != 0
+    }
+    return bvBit(false, unknown)
+}
+
+// ReduceXor return the 1-bit result of ^v
+func (v BitVector) ReduceXor() (BitVector) {
+    if v.IsUnknown() {
+        return bvBit(false, true)
+    }
+
+    var count uint = 0
+    for _, w := range v.val {
+        count += CountOne64(w)
+    }
+    return bvBit(count & 1 == 1, false)
+}
+
+// Lsh return v << shift, bits shifted out are dropped
+func (v BitVector) Lsh(shift uint) (BitVector) {
+    ret := BitVector{width: v.width}
+    ret.val = shlWords(v.val, len(v.val), shift)
+    ret.xz = shlWords(v.xz, len(v.val), shift)
+    return ret.normalize()
+}
+
+// Rsh return v >> shift, zero is shifted in
+func (v BitVector) Rsh(shift uint) (BitVector) {
+    ret := BitVector{width: v.width}
+    ret.val = shrWords(v.val, len(v.val), shift)
+    ret.xz = shrWords(v.xz, len(v.val), shift)
+    return ret.normalize()
+}
+
+// Ashr return v >>> shift, the MSB (including x) is shifted in
+func (v BitVector) Ashr(shift uint) (BitVector) {
+    if shift > v.width {
+        shift = v.width
+    }
+    ret := v.Rsh(shift)
+    if shift == 0 {
+        return ret
+    }
+
+    one, unknown, _ := v.TestBit(v.width - 1)
+    fill := onesWords(len(v.val), v.width - shift, shift)
+    for i := range ret.val {
+        if unknown {
+            ret.xz[i] |= fill[i]
+        } else if one {
+            ret.val[i] |= fill[i]
+        }
+    }
+    return ret.normalize()
+}
+
+// RotateLeft rotate v left within its width
+func (v BitVector) RotateLeft(shift uint) (BitVector) {
+    if v.width == 0 {
+        return v
+    }
+    shift %= v.width
+    if shift == 0 {
+        return v.resize(v.width)
+    }
+    return v.Lsh(shift).Or(v.Rsh(v.width - shift))
+}
+
+// RotateRight rotate v right within its width
+func (v BitVector) RotateRight(shift uint) (BitVector) {
+    if v.width == 0 {
+        return v
+    }
+    shift %= v.width
+    return v.RotateLeft(v.width - shift)
+}
+
+// make an all-x vector for arithmetic on unknown operands
+func bvUnknown(width uint) (BitVector) {
+    ret := newBitVector(width)
+    for i := range ret.xz {
+        ret.xz[i] = ^uint64(0)
+    }
+    return ret.normalize()
+}
+
+// Add return v + w modulo 2^width
+func (v BitVector) Add(w BitVector) (BitVector) {
+    v, w = bvExtend(v, w)
+    if v.IsUnknown() || w.IsUnknown() {
+        return bvUnknown(v.width)
+    }
+
+    ret := newBitVector(v.width)
+    var carry uint64
+    for i := range v.val {
+        ret.val[i], carry = bits.Add64(v.val[i], w.val[i], carry)
+    }
+    return ret.normalize()
+}
+
+// Sub return v - w modulo 2^width
+func (v BitVector) Sub(w BitVector) (BitVector) {
+    v, w = bvExtend(v, w)
+    if v.IsUnknown() || w.IsUnknown() {
+        return bvUnknown(v.width)
+    }
+
+    ret := newBitVector(v.width)
+    var borrow uint64
+    for i := range v.val {
+        ret.val[i], borrow = bits.Sub64(v.val[i], w.val[i], borrow)
+    }
+    return ret.normalize()
+}
+
+// Neg return -v modulo 2^width
+func (v BitVector) Neg() (BitVector) {
+    return newBitVector(v.width).Sub(v)
+}
+
+// Mul return v * w modulo 2^width
+func (v BitVector) Mul(w BitVector) (BitVector) {
+    v, w = bvExtend(v, w)
+    if v.IsUnknown() || w.IsUnknown() {
+        return bvUnknown(v.width)
+    }
+
+    n := len(v.val)
+    ret := newBitVector(v.width)
+    for i := 0; i < n; i++ {
+        var carry uint64
+        for j := 0; i + j < n; j++ {
+            hi, lo := bits.Mul64(v.val[i], w.val[j])
+            var c uint64
+            lo, c = bits.Add64(lo, carry, 0)
+            hi += c
+            ret.val[i + j], c = bits.Add64(ret.val[i + j], lo, 0)
+            carry = hi + c
+        }
+    }
+    return ret.normalize()
+}
+
+// multiply words by m and add a, return the carry out of the top word
+func mulAddWords(words []uint64, m uint64, a uint64) (uint64) {
+    carry := a
+    for i := range words {
+        hi, lo := bits.Mul64(words[i], m)
+        var c uint64
+        words[i], c = bits.Add64(lo, carry, 0)
+        carry = hi + c
+    }
+    return carry
+}
+
+// divide words by d in place and return the remainder
+func divWords(words []uint64, d uint64) (uint64) {
+    var rem uint64
+    for i := len(words) - 1; i >= 0; i-- {
+        words[i], rem = bits.Div64(rem, words[i], d)
+    }
+    return rem
+}
+
+// Text return the Verilog literal of v in the given base (2, 8, 10 or 16). Digits of
+// base 2/8/16 cover the full width, a digit is x if all its bits are x and X if only
+// some are. The decimal form is x if any bit is unknown
+func (v BitVector) Text(base int) (string) {
+    var sb strings.Builder
+    var step uint
+
+    switch base {
+    case 2:
+        sb.WriteString(fmt.Sprintf("%d'b", v.width))
+        step = 1
+    case 8:
+        sb.WriteString(fmt.Sprintf("%d'o", v.width))
+        step = 3
+    case 10:
+        sb.WriteString(fmt.Sprintf("%d'd", v.width))
+        if v.IsUnknown() {
+            sb.WriteString("x")
+            return sb.String()
+        }
+        words := make([]uint64, len(v.val))
+        copy(words, v.val)
+        var digits []byte
+        for {
+            digits = append(digits, byte('0' + divWords(words, 10)))
+            zero := true
+            for _, w := range words {
+                zero = zero && w == 0
+            }
+            if zero {
+                break
+            }
+        }
+        for i := len(digits) - 1; i >= 0; i-- {
+            sb.WriteByte(digits[i])
+        }
+        return sb.String()
+    default:
+        sb.WriteString(fmt.Sprintf("%d'h", v.width))
+        step = 4
+    }
+
+    for pos := int((v.width + step - 1) / step - 1) * int(step); pos >= 0; pos -= int(step) {
+        length := step
+        if uint(pos) + length > v.width {
+            length = v.width - uint(pos)
+        }
+        digit, _ := v.GetField(uint(pos) + length - 1, uint(pos))
+        switch {
+        case CountOne64(digit.xz[0]) == length:
+            sb.WriteByte('x')
+        case digit.xz[0] != 0:
+            sb.WriteByte('X')
+        default:
+            sb.WriteByte("0123456789abcdef"[digit.val[0]])
+        }
+    }
+    return sb.String()
+}
+
+// String return the Verilog literal of v, in hexadecimal if all bits are known or
+// in binary otherwise
+func (v BitVector) String() (string) {
+    if v.IsUnknown() {
+        return v.Text(2)
+    }
+    return v.Text(16)
+}
+
+// widest literal accepted by ParseBitVector, the minimum a Verilog tool must support
+const maxLiteralWidth = 1 << 16
+
+// ParseBitVector parse a sized Verilog literal such as 12'h3FF, 5'b1_0x01, 8'o17,
+// 16'd100 or 4'sb1010. Digits x/z/? mark unknown bits and a leading unknown digit
+// fills the upper bits like Verilog. The error is returned if the value does not fit
+// the width or the width is larger than 65536. Digits above the width are accepted
+// when they are a known 0, such as 4'h0F, and an unknown top digit wider than the
+// bits left, such as 1'hx, is truncated, but any other unknown bit above the width
+// is an error
+func ParseBitVector(s string) (BitVector, error) {
+    quote := strings.IndexByte(s, '\'')
+    if quote <= 0 || quote + 2 > len(s) {
+        return BitVector{}, fmt.Errorf("invalid literal(%q)", s)
+    }
+
+    var width uint = 0
+    for _, c := range s[:quote] {
+        if c < '0' || c > '9' {
+            return BitVector{}, fmt.Errorf("invalid width in literal(%q)", s)
+        }
+        width = width * 10 + uint(c - '0')
+        if width > maxLiteralWidth {
+            return BitVector{}, fmt.Errorf("invalid width in literal(%q)", s)
+        }
+    }
+    if width == 0 {
+        return BitVector{}, fmt.Errorf("invalid width in literal(%q)", s)
+    }
+
+    // the signed flag does not change the bit pattern
+    base := quote + 1
+    if s[base] == 's' || s[base] == 'S' {
+        base++
+    }
+    if base + 1 > len(s) {
+        return BitVector{}, fmt.Errorf("invalid literal(%q)", s)
+    }
+
+    var step uint
+    switch s[base] {
+    case 'b', 'B':
+        step = 1
+    case 'o', 'O':
+        step = 3
+    case 'd', 'D':
+        step = 0
+    case 'h', 'H':
+        step = 4
+    default:
+        return BitVector{}, fmt.Errorf("invalid base in literal(%q)", s)
+    }
+    digits := strings.ReplaceAll(s[base + 1:], "_", "")
+    if len(digits) == 0 {
+        return BitVector{}, fmt.Errorf("invalid literal(%q)", s)
+    }
+
+    ret := newBitVector(width)
+    if step == 0 {
+        // decimal is either all known or a single x/z
+        if len(digits) == 1 && strings.ContainsAny(digits, "xXzZ?") {
+            return bvUnknown(width), nil
+        }
+        for _, c := range digits {
+            if c < '0' || c > '9' {
+                return BitVector{}, fmt.Errorf("invalid digit in literal(%q)", s)
+            }
+            top := len(ret.val) - 1
+            if mulAddWords(ret.val, 10, uint64(c - '0')) != 0 || ret.val[top] &^ bvTopMask(width) != 0 {
+                return BitVector{}, fmt.Errorf("value out of range(%q)", s)
+            }
+        }
+    } else {
+        // accumulate in enough words for all digits then check the width
+        total := uint(len(digits)) * step
+        acc := newBitVector(total)
+        for i := 0; i < len(digits); i++ {
+            c := digits[i]
+            pos := total - uint(i + 1) * step
+            var d uint64
+            unknown := false
+            switch {
+            case c == 'x' || c == 'X' || c == 'z' || c == 'Z' || c == '?':
+                unknown = true
+            case '0' <= c && c <= '9':
+                d = uint64(c - '0')
+            case 'a' <= c && c <= 'f':
+                d = uint64(c - 'a' + 10)
+            case 'A' <= c && c <= 'F':
+                d = uint64(c - 'A' + 10)
+            default:
+                return BitVector{}, fmt.Errorf("invalid digit in literal(%q)", s)
+            }
+            if d >= uint64(1) << step {
+                return BitVector{}, fmt.Errorf("invalid digit in literal(%q)", s)
+            }
+            field := newBitVector(step)
+            if unknown {
+                field = bvUnknown(step)
+            } else {
+                field.val[0] = d
+            }
+            acc, _ = acc.SetField(pos + step - 1, pos, field)
+        }
+
+        if total > width {
+            //bits above the width must be a known 0, except the unknown top digit cut
+            //by the width as Text print 1'hx
+            upper, _ := acc.GetField(total - 1, width)
+            if upper.ReduceOr().val[0] != 0 || upper.IsUnknown() && total - width >= step {
+                return BitVector{}, fmt.Errorf("value out of range(%q)", s)
+            }
+        }
+        ret = acc.resize(width)
+        if _, unknown, _ := acc.TestBit(total - 1); unknown && total < width {
+            ret, _ = ret.SetField(width - 1, total, bvUnknown(width - total))
+        }
+    }
+
+    return ret.normalize(), nil
+}
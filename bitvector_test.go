@@ -0,0 +1,354 @@
+package bitops
+
+import "testing"
+
+func mustBitVector(t *testing.T, s string) (BitVector) {
+    v, err := ParseBitVector(s)
+    if err != nil {
+        t.Fatalf("parse %s: %v", s, err)
+    }
+    return v
+}
+
+func TestNewBitVector(t *testing.T) {
+    if _, err := NewBitVector(0, 0); err == nil {
+        t.Fail()
+        t.Log("invalid width")
+    }
+
+    v, err := NewBitVector(5, 0xFF)
+    if err != nil || v.Width() != 5 || v.String() != "5'h1f" {
+        t.Fail()
+        t.Logf("wrap at width get %v", v)
+    }
+
+    v, err = NewBitVectorWords(100, []uint64{0x1, 0xFFFFFFFFFFFFFFFF})
+    if err != nil || v.String() != "100'hfffffffff0000000000000001" {
+        t.Fail()
+        t.Logf("wrap at width get %v", v)
+    }
+}
+
+func TestParseBitVector(t *testing.T) {
+    vectors := []struct {
+        s      string
+        expect string
+    }{
+        {"12'h3FF", "12'h3ff"},
+        {"5'b1_0x01", "5'b10x01"},
+        {"8'o17", "8'h0f"},
+        {"16'd100", "16'h0064"},
+        {"8'hx", "8'bxxxxxxxx"},
+        {"8'bz1", "8'bxxxxxxx1"},
+        {"4'sb1010", "4'ha"},
+        {"1'ox", "1'bx"},
+        {"1'hx", "1'bx"},
+        {"4'h0F", "4'hf"},
+        {"8'b0000_0000_1", "8'h01"},
+        {"200'd1606938044258990275541962092341162602522202993782792835301375", "200'hffffffffffffffffffffffffffffffffffffffffffffffffff"},
+    }
+
+    for _, v := range vectors {
+        ret, err := ParseBitVector(v.s)
+        if err != nil || ret.String() != v.expect {
+            t.Fail()
+            t.Logf("%s expect %s but get %v (%v)", v.s, v.expect, ret, err)
+        }
+    }
+
+    //check error
+    for _, s := range []string{"", "12", "'h3", "0'h0", "4'h1F", "4'b2", "3'd8", "8'q1", "8'h", "4'bx0000", "4'b10000", "2'h0x", "65537'h0"} {
+        if _, err := ParseBitVector(s); err == nil {
+            t.Fail()
+            t.Logf("%q should fail", s)
+        }
+    }
+}
+
+func TestBitVectorText(t *testing.T) {
+    v := mustBitVector(t, "12'b1010_xxxx_x101")
+
+    if ret, expect := v.Text(16), "12'haxX"; ret != expect {
+        t.Fail()
+        t.Logf("expect %s but get %s", expect, ret)
+    }
+    if ret, expect := v.Text(10), "12'dx"; ret != expect {
+        t.Fail()
+        t.Logf("expect %s but get %s", expect, ret)
+    }
+
+    v = mustBitVector(t, "70'h3F_FFFF_FFFF_FFFF_FFFF")
+    if ret, expect := v.Text(10), "70'd1180591620717411303423"; ret != expect {
+        t.Fail()
+        t.Logf("expect %s but get %s", expect, ret)
+    }
+    if ret, expect := mustBitVector(t, "6'o77").Text(8), "6'o77"; ret != expect {
+        t.Fail()
+        t.Logf("expect %s but get %s", expect, ret)
+    }
+}
+
+func TestBitVectorUint64(t *testing.T) {
+    if ret, err := mustBitVector(t, "37'h1_0000_0001").Uint64(); err != nil || ret != 0x100000001 {
+        t.Fail()
+        t.Logf("get %x", ret)
+    }
+    if _, err := mustBitVector(t, "4'b10x1").Uint64(); err == nil {
+        t.Fail()
+        t.Log("unknown bit")
+    }
+    if _, err := mustBitVector(t, "65'h1_0000_0000_0000_0000").Uint64(); err == nil {
+        t.Fail()
+        t.Log("out of range")
+    }
+}
+
+func TestBitVectorGetField(t *testing.T) {
+    v := mustBitVector(t, "200'h1_2345_6789_ABCD_EF01_2345_6789_ABCD_EF01_2345_6789_ABCD_EF01")
+
+    if _, err := v.GetField(200, 0); err == nil {
+        t.Fail()
+        t.Log("invalid high")
+    }
+    if _, err := v.GetField(3, 4); err == nil {
+        t.Fail()
+        t.Log("high < low")
+    }
+
+    field, err := v.GetField(71, 60)
+    if err != nil || !field.Equal(mustBitVector(t, "12'h012")) {
+        t.Fail()
+        t.Logf("across words get %v", field)
+    }
+
+    field, err = v.GetField(199, 196)
+    if err != nil || !field.Equal(mustBitVector(t, "4'h0")) {
+        t.Fail()
+        t.Logf("top bits get %v", field)
+    }
+
+    bit, unknown, err := mustBitVector(t, "3'b1x0").TestBit(1)
+    if err != nil || bit || !unknown {
+        t.Fail()
+        t.Log("test unknown bit")
+    }
+}
+
+func TestBitVectorSetField(t *testing.T) {
+    v := mustBitVector(t, "37'h0")
+
+    ret, err := v.SetField(36, 33, mustBitVector(t, "4'hF"))
+    if err != nil || !ret.Equal(mustBitVector(t, "37'h1E_0000_0000")) {
+        t.Fail()
+        t.Logf("get %v", ret)
+    }
+
+    ret, err = ret.SetField(1, 0, mustBitVector(t, "8'b1111_1x11"))
+    if err != nil || !ret.Equal(mustBitVector(t, "37'b1_1110_0000_0000_0000_0000_0000_0000_0000_0011")) {
+        t.Fail()
+        t.Logf("truncate field get %v", ret)
+    }
+
+    if _, err = v.SetField(37, 0, v); err == nil {
+        t.Fail()
+        t.Log("invalid high")
+    }
+}
+
+func TestConcat(t *testing.T) {
+    ret, err := Concat(mustBitVector(t, "4'hA"), mustBitVector(t, "3'b1x0"), mustBitVector(t, "64'hFFFF_FFFF_FFFF_FFFF"))
+    if err != nil || ret.Width() != 71 {
+        t.Fail()
+        t.Logf("get %v", ret)
+    }
+    if field, _ := ret.GetField(70, 64); !field.Equal(mustBitVector(t, "7'b1010_1x0")) {
+        t.Fail()
+        t.Logf("get %v", field)
+    }
+
+    if _, err = Concat(); err == nil {
+        t.Fail()
+        t.Log("empty concatenation")
+    }
+}
+
+func TestReplicate(t *testing.T) {
+    ret, err := Replicate(3, mustBitVector(t, "2'b10"))
+    if err != nil || !ret.Equal(mustBitVector(t, "6'b101010")) {
+        t.Fail()
+        t.Logf("get %v", ret)
+    }
+
+    if _, err = Replicate(0, ret); err == nil {
+        t.Fail()
+        t.Log("zero count")
+    }
+}
+
+func TestBitVectorBitwise(t *testing.T) {
+    a := mustBitVector(t, "4'b01x1")
+    b := mustBitVector(t, "4'b0x11")
+
+    if ret, expect := a.And(b), mustBitVector(t, "4'b0xx1"); !ret.Equal(expect) {
+        t.Fail()
+        t.Logf("and expect %v but get %v", expect, ret)
+    }
+    if ret, expect := a.Or(b), mustBitVector(t, "4'b0111"); !ret.Equal(expect) {
+        t.Fail()
+        t.Logf("or expect %v but get %v", expect, ret)
+    }
+    if ret, expect := a.Xor(b), mustBitVector(t, "4'b0xx0"); !ret.Equal(expect) {
+        t.Fail()
+        t.Logf("xor expect %v but get %v", expect, ret)
+    }
+    if ret, expect := a.Not(), mustBitVector(t, "4'b10x0"); !ret.Equal(expect) {
+        t.Fail()
+        t.Logf("not expect %v but get %v", expect, ret)
+    }
+
+    //narrower operand is zero-extended
+    if ret, expect := mustBitVector(t, "8'hFF").And(mustBitVector(t, "4'hA")), mustBitVector(t, "8'h0A"); !ret.Equal(expect) {
+        t.Fail()
+        t.Logf("extend expect %v but get %v", expect, ret)
+    }
+}
+
+func TestBitVectorReduce(t *testing.T) {
+    vectors := []struct {
+        s               string
+        and, or, xor    string
+    }{
+        {"70'h3F_FFFF_FFFF_FFFF_FFFF", "1'b1", "1'b1", "1'b0"},
+        {"70'h0", "1'b0", "1'b0", "1'b0"},
+        {"4'b1x11", "1'bx", "1'b1", "1'bx"},
+        {"4'b0x00", "1'b0", "1'bx", "1'bx"},
+        {"3'b111", "1'b1", "1'b1", "1'b1"},
+    }
+
+    for _, v := range vectors {
+        value := mustBitVector(t, v.s)
+        if ret := value.ReduceAnd(); !ret.Equal(mustBitVector(t, v.and)) {
+            t.Fail()
+            t.Logf("&%s expect %s but get %v", v.s, v.and, ret)
+        }
+        if ret := value.ReduceOr(); !ret.Equal(mustBitVector(t, v.or)) {
+            t.Fail()
+            t.Logf("|%s expect %s but get %v", v.s, v.or, ret)
+        }
+        if ret := value.ReduceXor(); !ret.Equal(mustBitVector(t, v.xor)) {
+            t.Fail()
+            t.Logf("^%s expect %s but get %v", v.s, v.xor, ret)
+        }
+    }
+}
+
+func TestBitVectorShift(t *testing.T) {
+    v := mustBitVector(t, "70'h20_0000_0000_0000_0001")
+
+    if ret, expect := v.Lsh(4), mustBitVector(t, "70'h00_0000_0000_0000_0010"); !ret.Equal(expect) {
+        t.Fail()
+        t.Logf("lsh expect %v but get %v", expect, ret)
+    }
+    if ret, expect := v.Rsh(69), mustBitVector(t, "70'h1"); !ret.Equal(expect) {
+        t.Fail()
+        t.Logf("rsh expect %v but get %v", expect, ret)
+    }
+    if ret, expect := v.Ashr(66), mustBitVector(t, "70'h3F_FFFF_FFFF_FFFF_FFF8"); !ret.Equal(expect) {
+        t.Fail()
+        t.Logf("ashr expect %v but get %v", expect, ret)
+    }
+    if ret, expect := mustBitVector(t, "4'bx000").Ashr(2), mustBitVector(t, "4'bxxx0"); !ret.Equal(expect) {
+        t.Fail()
+        t.Logf("ashr unknown expect %v but get %v", expect, ret)
+    }
+    if ret, expect := v.RotateLeft(1), mustBitVector(t, "70'h3"); !ret.Equal(expect) {
+        t.Fail()
+        t.Logf("rotl expect %v but get %v", expect, ret)
+    }
+    if ret, expect := v.RotateRight(1), mustBitVector(t, "70'h30_0000_0000_0000_0000"); !ret.Equal(expect) {
+        t.Fail()
+        t.Logf("rotr expect %v but get %v", expect, ret)
+    }
+    if ret := v.RotateLeft(70); !ret.Equal(v) {
+        t.Fail()
+        t.Logf("full rotation get %v", ret)
+    }
+}
+
+func TestBitVectorArith(t *testing.T) {
+    max := mustBitVector(t, "37'h1F_FFFF_FFFF")
+    one := mustBitVector(t, "37'h1")
+
+    if ret, expect := max.Add(one), mustBitVector(t, "37'h0"); !ret.Equal(expect) {
+        t.Fail()
+        t.Logf("add expect %v but get %v", expect, ret)
+    }
+    if ret, expect := mustBitVector(t, "37'h0").Sub(one), max; !ret.Equal(expect) {
+        t.Fail()
+        t.Logf("sub expect %v but get %v", expect, ret)
+    }
+    if ret, expect := one.Neg(), max; !ret.Equal(expect) {
+        t.Fail()
+        t.Logf("neg expect %v but get %v", expect, ret)
+    }
+    if ret, expect := max.Mul(max), one; !ret.Equal(expect) {
+        t.Fail()
+        t.Logf("mul expect %v but get %v", expect, ret)
+    }
+
+    a := mustBitVector(t, "130'h3_0000_0000_0000_0001_FFFF_FFFF_FFFF_FFFF")
+    b := mustBitVector(t, "130'h0_0000_0000_0000_0000_0000_0000_0000_0010")
+    if ret, expect := a.Mul(b), mustBitVector(t, "130'h0_0000_0000_0000_001F_FFFF_FFFF_FFFF_FFF0"); !ret.Equal(expect) {
+        t.Fail()
+        t.Logf("wide mul expect %v but get %v", expect, ret)
+    }
+    if ret, expect := a.Add(a), mustBitVector(t, "130'h2_0000_0000_0000_0003_FFFF_FFFF_FFFF_FFFE"); !ret.Equal(expect) {
+        t.Fail()
+        t.Logf("wide add expect %v but get %v", expect, ret)
+    }
+
+    if ret := one.Add(mustBitVector(t, "37'bx")); !ret.Equal(mustBitVector(t, "37'hx")) {
+        t.Fail()
+        t.Logf("unknown operand get %v", ret)
+    }
+}
+
+func TestBitVectorZeroValue(t *testing.T) {
+    //the zero value is what constructors return on error and must not panic
+    var zero BitVector
+    one := mustBitVector(t, "4'h1")
+    results := []BitVector{
+        zero.Not(), zero.And(zero), zero.Or(zero), zero.Xor(zero), zero.Lsh(3), zero.Rsh(3), zero.Ashr(3),
+        zero.RotateLeft(3), zero.RotateRight(3), zero.Add(zero), zero.Sub(zero), zero.Neg(), zero.Mul(zero),
+    }
+    for i, ret := range results {
+        if ret.Width() != 0 || ret.IsUnknown() {
+            t.Fail()
+            t.Logf("operation %d get width %d", i, ret.Width())
+        }
+    }
+    if ret := zero.Or(one); !ret.Equal(one) {
+        t.Fail()
+        t.Logf("zero | 4'h1 get %v", ret)
+    }
+    if ret := zero.Add(one); !ret.Equal(one) {
+        t.Fail()
+        t.Logf("zero + 4'h1 get %v", ret)
+    }
+    if value, err := zero.Uint64(); value != 0 || err != nil {
+        t.Fail()
+        t.Logf("zero value get %d %v", value, err)
+    }
+    if _, _, err := zero.TestBit(0); err == nil {
+        t.Fail()
+        t.Log("bit 0 of zero value")
+    }
+    if _, err := zero.GetField(0, 0); err == nil {
+        t.Fail()
+        t.Log("field of zero value")
+    }
+    if !zero.Equal(BitVector{}) {
+        t.Fail()
+        t.Log("zero value not equal to itself")
+    }
+}
@@ -0,0 +1,237 @@
+package bitops
+
+import (
+    "encoding/binary"
+    "fmt"
+)
+
+// carry-save adder of three words, return the carry and the sum bits
+func csa(a uint64, b uint64, c uint64) (uint64, uint64) {
+    u := a ^ b
+    return (a & b) | (u & c), u ^ c
+}
+
+// harleySeal accumulate popcounts of 16-word blocks in carry-save form, so only
+// one CountOne64 is needed per block instead of sixteen
+type harleySeal struct {
+    ones   uint64
+    twos   uint64
+    fours  uint64
+    eights uint64
+    total  uint
+}
+
+// add the bits of a block of 16 words
+func (s *harleySeal) add16(w *[16]uint64) {
+    var twosA, twosB, foursA, foursB, eightsA, eightsB, sixteens uint64
+    twosA, s.ones = csa(s.ones, w[0], w[1])
+    twosB, s.ones = csa(s.ones, w[2], w[3])
+    foursA, s.twos = csa(s.twos, twosA, twosB)
+    twosA, s.ones = csa(s.ones, w[4], w[5])
+    twosB, s.ones = csa(s.ones, w[6], w[7])
+    foursB, s.twos = csa(s.twos, twosA, twosB)
+    eightsA, s.fours = csa(s.fours, foursA, foursB)
+    twosA, s.ones = csa(s.ones, w[8], w[9])
+    twosB, s.ones = csa(s.ones, w[10], w[11])
+    foursA, s.twos = csa(s.twos, twosA, twosB)
+    twosA, s.ones = csa(s.ones, w[12], w[13])
+    twosB, s.ones = csa(s.ones, w[14], w[15])
+    foursB, s.twos = csa(s.twos, twosA, twosB)
+    eightsB, s.fours = csa(s.fours, foursA, foursB)
+    sixteens, s.eights = csa(s.eights, eightsA, eightsB)
+    s.total += CountOne64(sixteens)
+}
+
+// return the number of bits added
+func (s *harleySeal) sum() (uint) {
+    return 16 * s.total + 8 * CountOne64(s.eights) + 4 * CountOne64(s.fours) +
+           2 * CountOne64(s.twos) + CountOne64(s.ones)
+}
+
+// check a and b have the same length and dst is long enough for the result
+func checkSlices(dstLen int, aLen int, bLen int) (error) {
+    if aLen != bLen {
+        return fmt.Errorf("invalid length(%v) and length(%v)", aLen, bLen)
+    }
+    if dstLen < aLen {
+        return fmt.Errorf("invalid dst length(%v), need %v", dstLen, aLen)
+    }
+    return nil
+}
+
+// PopCountSlice return the number of 1 in all words of src
+func PopCountSlice(src []uint64) (uint) {
+    var s harleySeal
+    i := 0
+    for ; i + 16 <= len(src); i += 16 {
+        s.add16((*[16]uint64)(src[i:i + 16]))
+    }
+    count := s.sum()
+    for _, w := range src[i:] {
+        count += CountOne64(w)
+    }
+    return count
+}
+
+// PopCountBytes return the number of 1 in all bytes of src
+func PopCountBytes(src []byte) (uint) {
+    var s harleySeal
+    var block [16]uint64
+    i := 0
+    for ; i + 128 <= len(src); i += 128 {
+        for j := range block {
+            block[j] = binary.LittleEndian.Uint64(src[i + 8 * j:])
+        }
+        s.add16(&block)
+    }
+    count := s.sum()
+    for ; i + 8 <= len(src); i += 8 {
+        count += CountOne64(binary.LittleEndian.Uint64(src[i:]))
+    }
+    for _, b := range src[i:] {
+        count += CountOne8(b)
+    }
+    return count
+}
+
+// PopCountAnd return the number of 1 in a & b without storing it, the error is
+// returned if the lengths differ. Carry-save blocks are used only without a
+// popcount instruction (purego), otherwise the fused loop is faster
+func PopCountAnd(a []uint64, b []uint64) (uint, error) {
+    if err := checkSlices(len(a), len(a), len(b)); err != nil {
+        return 0, err
+    }
+
+    var s harleySeal
+    var block [16]uint64
+    i := 0
+    for ; i + 16 <= len(a) && !fastCountOne; i += 16 {
+        x, y := (*[16]uint64)(a[i:i + 16]), (*[16]uint64)(b[i:i + 16])
+        for j := range block {
+            block[j] = x[j] & y[j]
+        }
+        s.add16(&block)
+    }
+    count := s.sum()
+    for ; i < len(a); i++ {
+        count += CountOne64(a[i] & b[i])
+    }
+    return count, nil
+}
+
+// PopCountXor return the number of 1 in a ^ b, the Hamming distance, without
+// storing it. The error is returned if the lengths differ, blocks are used as in
+// PopCountAnd
+func PopCountXor(a []uint64, b []uint64) (uint, error) {
+    if err := checkSlices(len(a), len(a), len(b)); err != nil {
+        return 0, err
+    }
+
+    var s harleySeal
+    var block [16]uint64
+    i := 0
+    for ; i + 16 <= len(a) && !fastCountOne; i += 16 {
+        x, y := (*[16]uint64)(a[i:i + 16]), (*[16]uint64)(b[i:i + 16])
+        for j := range block {
+            block[j] = x[j] ^ y[j]
+        }
+        s.add16(&block)
+    }
+    count := s.sum()
+    for ; i < len(a); i++ {
+        count += CountOne64(a[i] ^ b[i])
+    }
+    return count, nil
+}
+
+// AndSlice store a & b to dst, dst may be a or b. The error is returned and dst is
+// not modified if a and b lengths differ or dst is shorter
+func AndSlice(dst []uint64, a []uint64, b []uint64) (error) {
+    if err := checkSlices(len(dst), len(a), len(b)); err != nil {
+        return err
+    }
+    dst, b = dst[:len(a)], b[:len(a)]
+    for i := range a {
+        dst[i] = a[i] & b[i]
+    }
+    return nil
+}
+
+// OrSlice store a | b to dst, dst may be a or b. The error is returned and dst is
+// not modified if a and b lengths differ or dst is shorter
+func OrSlice(dst []uint64, a []uint64, b []uint64) (error) {
+    if err := checkSlices(len(dst), len(a), len(b)); err != nil {
+        return err
+    }
+    dst, b = dst[:len(a)], b[:len(a)]
+    for i := range a {
+        dst[i] = a[i] | b[i]
+    }
+    return nil
+}
+
+// XorSlice store a ^ b to dst, dst may be a or b. The error is returned and dst is
+// not modified if a and b lengths differ or dst is shorter
+func XorSlice(dst []uint64, a []uint64, b []uint64) (error) {
+    if err := checkSlices(len(dst), len(a), len(b)); err != nil {
+        return err
+    }
+    dst, b = dst[:len(a)], b[:len(a)]
+    for i := range a {
+        dst[i] = a[i] ^ b[i]
+    }
+    return nil
+}
+
+// AndNotSlice store a &^ b to dst, dst may be a or b. The error is returned and dst
+// is not modified if a and b lengths differ or dst is shorter
+func AndNotSlice(dst []uint64, a []uint64, b []uint64) (error) {
+    if err := checkSlices(len(dst), len(a), len(b)); err != nil {
+        return err
+    }
+    dst, b = dst[:len(a)], b[:len(a)]
+    for i := range a {
+        dst[i] = a[i] &^ b[i]
+    }
+    return nil
+}
+
+// real implementation for AndBytes/OrBytes/XorBytes/AndNotBytes, 8 bytes at a time
+func bytesOp(dst []byte, a []byte, b []byte, op func(uint64, uint64) uint64) (error) {
+    if err := checkSlices(len(dst), len(a), len(b)); err != nil {
+        return err
+    }
+
+    i := 0
+    for ; i + 8 <= len(a); i += 8 {
+        binary.LittleEndian.PutUint64(dst[i:], op(binary.LittleEndian.Uint64(a[i:]), binary.LittleEndian.Uint64(b[i:])))
+    }
+    for ; i < len(a); i++ {
+        dst[i] = byte(op(uint64(a[i]), uint64(b[i])))
+    }
+    return nil
+}
+
+// AndBytes store a & b to dst, dst may be a or b. The error is returned and dst is
+// not modified if a and b lengths differ or dst is shorter
+func AndBytes(dst []byte, a []byte, b []byte) (error) {
+    return bytesOp(dst, a, b, func(x, y uint64) (uint64) { return x & y })
+}
+
+// OrBytes store a | b to dst, dst may be a or b. The error is returned and dst is
+// not modified if a and b lengths differ or dst is shorter
+func OrBytes(dst []byte, a []byte, b []byte) (error) {
+    return bytesOp(dst, a, b, func(x, y uint64) (uint64) { return x | y })
+}
+
+// XorBytes store a ^ b to dst, dst may be a or b. The error is returned and dst is
+// not modified if a and b lengths differ or dst is shorter
+func XorBytes(dst []byte, a []byte, b []byte) (error) {
+    return bytesOp(dst, a, b, func(x, y uint64) (uint64) { return x ^ y })
+}
+
+// AndNotBytes store a &^ b to dst, dst may be a or b. The error is returned and dst
+// is not modified if a and b lengths differ or dst is shorter
+func AndNotBytes(dst []byte, a []byte, b []byte) (error) {
+    return bytesOp(dst, a, b, func(x, y uint64) (uint64) { return x &^ y })
+}
@@ -0,0 +1,148 @@
+package bitops
+
+import (
+    "math/rand"
+    "testing"
+)
+
+// popcount of words one at a time
+func naivePopCount(src []uint64) (uint) {
+    count := uint(0)
+    for _, w := range src {
+        count += CountOne64(w)
+    }
+    return count
+}
+
+func TestPopCountSlice(t *testing.T) {
+    rng := rand.New(rand.NewSource(1))
+    //lengths around the 16-word block and the 128-byte block
+    for _, n := range []int{0, 1, 15, 16, 17, 31, 32, 33, 100, 1000} {
+        a := make([]uint64, n)
+        b := make([]uint64, n)
+        for i := range a {
+            a[i], b[i] = rng.Uint64(), rng.Uint64()
+        }
+        if ret, expect := PopCountSlice(a), naivePopCount(a); ret != expect {
+            t.Fail()
+            t.Logf("length %d expect %d but get %d", n, expect, ret)
+        }
+
+        and := make([]uint64, n)
+        xor := make([]uint64, n)
+        AndSlice(and, a, b)
+        XorSlice(xor, a, b)
+        if ret, err := PopCountAnd(a, b); err != nil || ret != naivePopCount(and) {
+            t.Fail()
+            t.Logf("length %d and get %d %v", n, ret, err)
+        }
+        if ret, err := PopCountXor(a, b); err != nil || ret != naivePopCount(xor) {
+            t.Fail()
+            t.Logf("length %d xor get %d %v", n, ret, err)
+        }
+
+        bytes := make([]byte, 8 * n + n % 8)
+        rng.Read(bytes)
+        expect := uint(0)
+        for _, v := range bytes {
+            expect += CountOne8(v)
+        }
+        if ret := PopCountBytes(bytes); ret != expect {
+            t.Fail()
+            t.Logf("%d bytes expect %d but get %d", len(bytes), expect, ret)
+        }
+    }
+
+    //every word all ones overflow no counter
+    ones := make([]uint64, 4096)
+    for i := range ones {
+        ones[i] = ^uint64(0)
+    }
+    if ret := PopCountSlice(ones); ret != 4096 * 64 {
+        t.Fail()
+        t.Logf("all ones get %d", ret)
+    }
+    if _, err := PopCountAnd(ones, ones[1:]); err == nil {
+        t.Fail()
+        t.Log("length mismatch")
+    }
+}
+
+func TestBitwiseSlice(t *testing.T) {
+    a := []uint64{0xFF00FF00, 0x1234, 0xFFFFFFFFFFFFFFFF}
+    b := []uint64{0x0FF00FF0, 0x4321, 0x1}
+    dst := make([]uint64, 3)
+    ops := []struct {
+        name string
+        fn   func([]uint64, []uint64, []uint64) (error)
+        op   func(uint64, uint64) (uint64)
+    }{
+        {"and", AndSlice, func(x, y uint64) (uint64) { return x & y }},
+        {"or", OrSlice, func(x, y uint64) (uint64) { return x | y }},
+        {"xor", XorSlice, func(x, y uint64) (uint64) { return x ^ y }},
+        {"andnot", AndNotSlice, func(x, y uint64) (uint64) { return x &^ y }},
+    }
+    for _, o := range ops {
+        if err := o.fn(dst, a, b); err != nil {
+            t.Fatal(err)
+        }
+        for i := range a {
+            if dst[i] != o.op(a[i], b[i]) {
+                t.Fail()
+                t.Logf("%s word %d get %x", o.name, i, dst[i])
+            }
+        }
+        dst[0] = 7
+        if o.fn(dst[:2], a, b) == nil || o.fn(dst, a, b[:2]) == nil || dst[0] != 7 {
+            t.Fail()
+            t.Logf("%s length mismatch", o.name)
+        }
+    }
+
+    //in place
+    c := []uint64{0xF0, 0x0F}
+    AndNotSlice(c, c, []uint64{0x30, 0x01})
+    if c[0] != 0xC0 || c[1] != 0x0E {
+        t.Fail()
+        t.Logf("in place get %x", c)
+    }
+}
+
+func TestBitwiseBytes(t *testing.T) {
+    rng := rand.New(rand.NewSource(2))
+    a := make([]byte, 37)
+    b := make([]byte, 37)
+    rng.Read(a)
+    rng.Read(b)
+    dst := make([]byte, 40)
+    ops := []struct {
+        name string
+        fn   func([]byte, []byte, []byte) (error)
+        op   func(byte, byte) (byte)
+    }{
+        {"and", AndBytes, func(x, y byte) (byte) { return x & y }},
+        {"or", OrBytes, func(x, y byte) (byte) { return x | y }},
+        {"xor", XorBytes, func(x, y byte) (byte) { return x ^ y }},
+        {"andnot", AndNotBytes, func(x, y byte) (byte) { return x &^ y }},
+    }
+    for _, o := range ops {
+        dst[37] = 0x5A
+        if err := o.fn(dst, a, b); err != nil {
+            t.Fatal(err)
+        }
+        for i := range a {
+            if dst[i] != o.op(a[i], b[i]) {
+                t.Fail()
+                t.Logf("%s byte %d get %x", o.name, i, dst[i])
+            }
+        }
+        if dst[37] != 0x5A {
+            t.Fail()
+            t.Logf("%s write past the result", o.name)
+        }
+        if o.fn(dst[:36], a, b) == nil || o.fn(dst, a[:36], b) == nil {
+            t.Fail()
+            t.Logf("%s length mismatch", o.name)
+        }
+    }
+}
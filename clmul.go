@@ -0,0 +1,62 @@
+package bitops
+
+import "fmt"
+
+// CarrylessMul32 return the 64-bit carry-less product of a and b, the product of
+// the polynomials over GF(2) whose coefficients are the bits
+func CarrylessMul32(a uint32, b uint32) (uint64) {
+    product, x := uint64(0), uint64(a)
+    for i := uint(0); i < 32; i++ {
+        //the mask keep the time independent of b
+        product ^= (x << i) & -(uint64(b) >> i & 1)
+    }
+    return product
+}
+
+// CarrylessMul64 return the 128-bit carry-less product of a and b
+func CarrylessMul64(a uint64, b uint64) (Uint128) {
+    hi, lo := uint64(0), a & -(b & 1)
+    for i := uint(1); i < 64; i++ {
+        mask := -(b >> i & 1)
+        lo ^= (a << i) & mask
+        hi ^= (a >> (64 - i)) & mask
+    }
+    return Uint128{Hi: hi, Lo: lo}
+}
+
+// degree of the polynomial poly, the error is returned if poly is 0
+func polyDegree(poly uint64) (uint, error) {
+    if poly == 0 {
+        return 0, fmt.Errorf("invalid polynomial(%#x)", poly)
+    }
+    return 63 - CountLeadZero64(poly), nil
+}
+
+// PolyMod64 return value modulo the polynomial poly over GF(2), poly include its
+// leading term such as 0x11B for x^8+x^4+x^3+x+1
+func PolyMod64(value uint64, poly uint64) (uint64, error) {
+    return PolyMod128(Uint128{Lo: value}, poly)
+}
+
+// PolyMod128 return the 128-bit value modulo the polynomial poly over GF(2)
+func PolyMod128(value Uint128, poly uint64) (uint64, error) {
+    degree, err := polyDegree(poly)
+    if err != nil {
+        return 0, err
+    }
+
+    for i := uint(127); i >= degree; i-- {
+        if i >= 64 && value.Hi >> (i - 64) & 1 == 1 || i < 64 && value.Lo >> i & 1 == 1 {
+            value = value.Xor(Uint128{Lo: poly}.Lsh(i - degree))
+        }
+        if i == 0 {
+            break
+        }
+    }
+    return value.Lo, nil
+}
+
+// PolyMulMod64 return a * b modulo the polynomial poly over GF(2)
+func PolyMulMod64(a uint64, b uint64, poly uint64) (uint64, error) {
+    return PolyMod128(CarrylessMul64(a, b), poly)
+}
@@ -0,0 +1,79 @@
+package bitops
+
+import (
+    "math/rand"
+    "testing"
+)
+
+// carry-less product one bit at a time
+func naiveCarrylessMul(a uint64, b uint64) (Uint128) {
+    var p Uint128
+    for i := uint(0); i < 64; i++ {
+        if b >> i & 1 == 1 {
+            p = p.Xor(Uint128{Lo: a}.Lsh(i))
+        }
+    }
+    return p
+}
+
+func TestCarrylessMul(t *testing.T) {
+    //FIPS-197 4.2: {57} x {83} before reduction
+    if p := CarrylessMul32(0x57, 0x83); p != 0x2B79 {
+        t.Fail()
+        t.Logf("expect 2b79 but get %x", p)
+    }
+    if p := CarrylessMul64(1 << 63, 1 << 63); p != (Uint128{Hi: 1 << 62}) {
+        t.Fail()
+        t.Logf("x^63 squared get %v", p)
+    }
+    //squaring spread the bits to the even positions
+    if p := CarrylessMul64(^uint64(0), ^uint64(0)); p != (Uint128{Hi: 0x5555555555555555, Lo: 0x5555555555555555}) {
+        t.Fail()
+        t.Logf("all ones squared get %x %x", p.Hi, p.Lo)
+    }
+
+    rng := rand.New(rand.NewSource(1))
+    for i := 0; i < 1000; i++ {
+        a, b := rng.Uint64(), rng.Uint64()
+        if p, expect := CarrylessMul64(a, b), naiveCarrylessMul(a, b); p != expect {
+            t.Fatalf("%x x %x expect %v but get %v", a, b, expect, p)
+        }
+        if p := CarrylessMul64(a, b); p != CarrylessMul64(b, a) {
+            t.Fatalf("%x x %x not commutative", a, b)
+        }
+        if p := CarrylessMul32(uint32(a), uint32(b)); p != naiveCarrylessMul(uint64(uint32(a)), uint64(uint32(b))).Lo {
+            t.Fatalf("32-bit %x x %x get %x", uint32(a), uint32(b), p)
+        }
+    }
+}
+
+func TestPolyMod(t *testing.T) {
+    //FIPS-197 4.2: {57} x {83} = {c1} in the AES field
+    if r, err := PolyMulMod64(0x57, 0x83, 0x11B); err != nil || r != 0xC1 {
+        t.Fail()
+        t.Logf("AES product get %x %v", r, err)
+    }
+    if r, err := PolyMod64(0x2B79, 0x11B); err != nil || r != 0xC1 {
+        t.Fail()
+        t.Logf("AES reduction get %x %v", r, err)
+    }
+    //x^64 mod x^63+x^4+x^3+x+1 is x^5+x^4+x^2+x
+    poly := uint64(1) << 63 | 0x1B
+    r, err := PolyMod128(Uint128{Hi: 1}, poly)
+    if err != nil || r != 0x36 {
+        t.Fail()
+        t.Logf("x^64 mod get %x %v", r, err)
+    }
+    if r, _ = PolyMod64(0x17, 0x20); r != 0x17 {
+        t.Fail()
+        t.Logf("lower degree get %x", r)
+    }
+    if r, _ = PolyMod64(0xFF, 1); r != 0 {
+        t.Fail()
+        t.Logf("mod 1 get %x", r)
+    }
+    if _, err = PolyMod64(1, 0); err == nil {
+        t.Fail()
+        t.Log("zero polynomial")
+    }
+}
@@ -0,0 +1,151 @@
+package bitops
+
+import (
+    "fmt"
+    "runtime"
+    "sync"
+    "sync/atomic"
+)
+
+// minimum number of words handled by one goroutine in parallel operations,
+// smaller bitsets are processed by the caller goroutine
+const parallelChunkWords = 1 << 14
+
+// ConcurrentBitset is a fixed-size bitset which can be mutated by many goroutines
+// without lock. Every single-bit operation is atomic on the word holding the bit
+// and the bulk operations split the words across goroutines
+type ConcurrentBitset struct {
+    length uint
+    words  []uint64
+}
+
+// NewConcurrentBitset create a bitset holding length bits, all cleared
+func NewConcurrentBitset(length uint) (*ConcurrentBitset) {
+    return &ConcurrentBitset{length: length, words: make([]uint64, (length + 63) / 64)}
+}
+
+// Len return the number of bits in the bitset
+func (b *ConcurrentBitset) Len() (uint) {
+    return b.length
+}
+
+// Set atomically set bit pos to 1
+func (b *ConcurrentBitset) Set(pos uint) (error) {
+    if pos >= b.length {
+        return fmt.Errorf("invalid position(%v)", pos)
+    }
+
+    return AtomicSetBit64(&b.words[pos / 64], pos % 64)
+}
+
+// Clear atomically set bit pos to 0
+func (b *ConcurrentBitset) Clear(pos uint) (error) {
+    if pos >= b.length {
+        return fmt.Errorf("invalid position(%v)", pos)
+    }
+
+    return AtomicClearBit64(&b.words[pos / 64], pos % 64)
+}
+
+// Test atomically return whether bit pos is 1
+func (b *ConcurrentBitset) Test(pos uint) (bool, error) {
+    if pos >= b.length {
+        return false, fmt.Errorf("invalid position(%v)", pos)
+    }
+
+    return AtomicTestBit64(&b.words[pos / 64], pos % 64)
+}
+
+// TestAndSet atomically set bit pos to 1 and return its previous value. Exactly one
+// of the goroutines racing on the same clear bit observe false
+func (b *ConcurrentBitset) TestAndSet(pos uint) (bool, error) {
+    if pos >= b.length {
+        return false, fmt.Errorf("invalid position(%v)", pos)
+    }
+
+    return AtomicTestAndSet64(&b.words[pos / 64], pos % 64)
+}
+
+// TestAndClear atomically set bit pos to 0 and return its previous value
+func (b *ConcurrentBitset) TestAndClear(pos uint) (bool, error) {
+    if pos >= b.length {
+        return false, fmt.Errorf("invalid position(%v)", pos)
+    }
+
+    return AtomicTestAndClear64(&b.words[pos / 64], pos % 64)
+}
+
+// run fn over [0, n) words split in chunks across goroutines
+func parallelWords(n int, fn func(lo int, hi int)) {
+    workers := runtime.GOMAXPROCS(0)
+    if chunks := (n + parallelChunkWords - 1) / parallelChunkWords; chunks < workers {
+        workers = chunks
+    }
+    if workers <= 1 {
+        fn(0, n)
+        return
+    }
+
+    var wg sync.WaitGroup
+    step := (n + workers - 1) / workers
+    for lo := 0; lo < n; lo += step {
+        hi := lo + step
+        if hi > n {
+            hi = n
+        }
+        wg.Add(1)
+        go func(lo int, hi int) {
+            defer wg.Done()
+            fn(lo, hi)
+        }(lo, hi)
+    }
+    wg.Wait()
+}
+
+// Count return number of 1 in the bitset. Each word is loaded atomically so it is
+// safe to call during concurrent mutation, but the result is not a snapshot
+func (b *ConcurrentBitset) Count() (uint) {
+    var total atomic.Uint64
+
+    parallelWords(len(b.words), func(lo int, hi int) {
+        var count uint = 0
+        for i := lo; i < hi; i++ {
+            count += CountOne64(atomic.LoadUint64(&b.words[i]))
+        }
+        total.Add(uint64(count))
+    })
+
+    return uint(total.Load())
+}
+
+// Or atomically merge every 1 of other into the bitset word by word
+func (b *ConcurrentBitset) Or(other *ConcurrentBitset) (error) {
+    if other.length != b.length {
+        return fmt.Errorf("invalid length(%v), expect %v", other.length, b.length)
+    }
+
+    parallelWords(len(b.words), func(lo int, hi int) {
+        for i := lo; i < hi; i++ {
+            if w := atomic.LoadUint64(&other.words[i]); w != 0 {
+                atomic.OrUint64(&b.words[i], w)
+            }
+        }
+    })
+    return nil
+}
+
+// And atomically clear every bit of the bitset which is 0 in other word by word
+func (b *ConcurrentBitset) And(other *ConcurrentBitset) (error) {
+    if other.length != b.length {
+        return fmt.Errorf("invalid length(%v), expect %v", other.length, b.length)
+    }
+
+    parallelWords(len(b.words), func(lo int, hi int) {
+        for i := lo; i < hi; i++ {
+            if w := atomic.LoadUint64(&other.words[i]); w != ^uint64(0) {
+                atomic.AndUint64(&b.words[i], w)
+            }
+        }
+    })
+    return nil
+}
@@ -0,0 +1,183 @@
+package bitops
+
+import (
+    "sync"
+    "testing"
+)
+
+func TestConcurrentBitsetSet(t *testing.T) {
+    b := NewConcurrentBitset(100)
+
+    if err := b.Set(100); err == nil {
+        t.Fail()
+        t.Log("100th bit error")
+    }
+    if err := b.Clear(100); err == nil {
+        t.Fail()
+        t.Log("100th bit error")
+    }
+    if _, err := b.Test(100); err == nil {
+        t.Fail()
+        t.Log("100th bit error")
+    }
+
+    b.Set(99)
+    b.Set(0)
+    if set, err := b.Test(99); err != nil || !set || b.Count() != 2 || b.Len() != 100 {
+        t.Fail()
+        t.Log("set bit 0 and 99")
+    }
+    b.Clear(99)
+    if set, _ := b.Test(99); set || b.Count() != 1 {
+        t.Fail()
+        t.Log("clear bit 99")
+    }
+}
+
+func TestConcurrentBitsetNoLostUpdate(t *testing.T) {
+    const length = 1 << 20
+    b := NewConcurrentBitset(length)
+
+    //interleave the workers so neighbouring bits of a word are set by
+    //different goroutines
+    var wg sync.WaitGroup
+    for w := 0; w < stressWorkers; w++ {
+        wg.Add(1)
+        go func(w int) {
+            defer wg.Done()
+            for pos := w; pos < length; pos += stressWorkers {
+                b.Set(uint(pos))
+            }
+        }(w)
+    }
+    wg.Wait()
+
+    if count := b.Count(); count != length {
+        t.Fail()
+        t.Logf("expect %d but get %d", length, count)
+    }
+
+    for w := 0; w < stressWorkers; w++ {
+        wg.Add(1)
+        go func(w int) {
+            defer wg.Done()
+            for pos := w; pos < length; pos += 2 * stressWorkers {
+                b.Clear(uint(pos))
+            }
+        }(w)
+    }
+    wg.Wait()
+
+    if count := b.Count(); count != length / 2 {
+        t.Fail()
+        t.Logf("expect %d but get %d", length / 2, count)
+    }
+}
+
+func TestConcurrentBitsetTestAndSet(t *testing.T) {
+    const length = 1 << 16
+    b := NewConcurrentBitset(length)
+
+    //every worker try to claim every bit, each bit must be claimed once
+    var wg sync.WaitGroup
+    claims := make([]int, stressWorkers)
+    for w := 0; w < stressWorkers; w++ {
+        wg.Add(1)
+        go func(w int) {
+            defer wg.Done()
+            for pos := uint(0); pos < length; pos++ {
+                if old, _ := b.TestAndSet(pos); !old {
+                    claims[w]++
+                }
+            }
+        }(w)
+    }
+    wg.Wait()
+
+    total := 0
+    for _, c := range claims {
+        total += c
+    }
+    if total != length || b.Count() != length {
+        t.Fail()
+        t.Logf("expect %d claims but get %d", length, total)
+    }
+
+    if old, err := b.TestAndClear(0); err != nil || !old {
+        t.Fail()
+        t.Log("test and clear bit 0")
+    }
+    if _, err := b.TestAndSet(length); err == nil {
+        t.Fail()
+        t.Log("out of range bit error")
+    }
+}
+
+func TestConcurrentBitsetOrAnd(t *testing.T) {
+    //large enough to be split across goroutines
+    const length = 64 * parallelChunkWords * 4 + 3
+    a := NewConcurrentBitset(length)
+    b := NewConcurrentBitset(length)
+
+    for pos := uint(0); pos < length; pos += 3 {
+        a.Set(pos)
+    }
+    for pos := uint(0); pos < length; pos += 2 {
+        b.Set(pos)
+    }
+
+    for pos := uint(1); pos < length; pos += 6 {
+        a.Set(pos)
+        b.Set(pos)
+    }
+
+    if err := a.And(b); err != nil {
+        t.Fail()
+        t.Log(err)
+    }
+    //multiples of 6 and the 6k+1 bits set in both
+    expect := uint((length + 5) / 6 + (length + 4) / 6)
+    if count := a.Count(); count != expect {
+        t.Fail()
+        t.Logf("and expect %d but get %d", expect, count)
+    }
+
+    if err := a.Or(b); err != nil || a.Count() != b.Count() {
+        t.Fail()
+        t.Logf("or expect %d but get %d", b.Count(), a.Count())
+    }
+
+    if err := a.Or(NewConcurrentBitset(10)); err == nil {
+        t.Fail()
+        t.Log("length mismatch")
+    }
+    if err := a.And(NewConcurrentBitset(10)); err == nil {
+        t.Fail()
+        t.Log("length mismatch")
+    }
+}
+
+func TestConcurrentBitsetParallelOr(t *testing.T) {
+    const length = 64 * parallelChunkWords * 2
+    dst := NewConcurrentBitset(length)
+
+    //several sources merged into the same destination at the same time
+    var wg sync.WaitGroup
+    for w := 0; w < stressWorkers; w++ {
+        src := NewConcurrentBitset(length)
+        for pos := uint(w); pos < length; pos += stressWorkers {
+            src.Set(pos)
+        }
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            dst.Or(src)
+        }()
+    }
+    wg.Wait()
+
+    if count := dst.Count(); count != length {
+        t.Fail()
+        t.Logf("expect %d but get %d", length, count)
+    }
+}
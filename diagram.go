@@ -0,0 +1,241 @@
+package bitops
+
+import (
+    "bufio"
+    "encoding/json"
+    "encoding/xml"
+    "fmt"
+    "io"
+    "slices"
+    "strings"
+)
+
+// a field or a gap between fields of a diagram, Field.Name is empty for a gap
+type diagramSegment struct {
+    Field
+    gap bool
+}
+
+// the fields of r and the gaps between them from MSB to LSB
+func diagramSegments(r Register) ([]diagramSegment) {
+    fields := slices.Clone(r.Fields)
+    slices.SortFunc(fields, func(a, b Field) (int) {
+        return int(b.Low) - int(a.Low)
+    })
+
+    var segments []diagramSegment
+    next := r.Width
+    for _, f := range fields {
+        if f.High + 1 < next {
+            segments = append(segments, diagramSegment{Field{High: next - 1, Low: f.High + 1, Access: AccessReserved}, true})
+        }
+        segments = append(segments, diagramSegment{Field: f})
+        next = f.Low
+    }
+    if next > 0 {
+        segments = append(segments, diagramSegment{Field{High: next - 1, Low: 0, Access: AccessReserved}, true})
+    }
+    return segments
+}
+
+// WriteDiagramText write r as an ASCII table with a row per field from MSB to LSB,
+// bits not covered by a field are rows named -
+func WriteDiagramText(w io.Writer, r Register) (error) {
+    if err := r.Validate(); err != nil {
+        return err
+    }
+
+    rows := [][]string{{"Bits", "Field", "Access", "Reset", "Description"}}
+    for _, s := range diagramSegments(r) {
+        bits := fmt.Sprintf("%d", s.High)
+        if s.High != s.Low {
+            bits = fmt.Sprintf("%d:%d", s.High, s.Low)
+        }
+        if s.gap {
+            rows = append(rows, []string{bits, "-", "-", "-", ""})
+            continue
+        }
+        rows = append(rows, []string{bits, s.Name, s.Access.String(), fmt.Sprintf("%#x", s.Reset), s.Description})
+    }
+
+    widths := make([]int, len(rows[0]))
+    for _, row := range rows {
+        for i, cell := range row {
+            widths[i] = max(widths[i], len(cell))
+        }
+    }
+    out := bufio.NewWriter(w)
+    border := func() {
+        for _, width := range widths {
+            out.WriteString("+" + strings.Repeat("-", width + 2))
+        }
+        out.WriteString("+\n")
+    }
+
+    fmt.Fprintf(out, "%s @ %#x (%d bits, reset %#x)\n", r.Name, r.Offset, r.Width, r.ResetValue())
+    border()
+    for i, row := range rows {
+        for j, cell := range row {
+            fmt.Fprintf(out, "| %-*s ", widths[j], cell)
+        }
+        out.WriteString("|\n")
+        if i == 0 {
+            border()
+        }
+    }
+    border()
+    return out.Flush()
+}
+
+// geometry of the SVG diagram in pixels
+const (
+    svgBit    = 28
+    svgMargin = 10
+    svgLane   = 80
+    svgBox    = 34
+)
+
+// escape s for SVG text
+func svgText(s string) (string) {
+    var sb strings.Builder
+    xml.EscapeText(&sb, []byte(s))
+    return sb.String()
+}
+
+// WriteDiagramSVG write r as an SVG bit-field diagram in lanes of up to 32 bits
+// from MSB to LSB. Every field is a box holding its name with the bit positions
+// above and the access type below, reserved bits are shaded
+func WriteDiagramSVG(w io.Writer, r Register) (error) {
+    if err := r.Validate(); err != nil {
+        return err
+    }
+
+    laneBits := min(r.Width, 32)
+    lanes := r.Width / laneBits
+    width := int(laneBits) * svgBit + 2 * svgMargin
+    height := int(lanes) * svgLane + 2 * svgMargin + 20
+    out := bufio.NewWriter(w)
+    fmt.Fprintf(out, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`+"\n",
+                width, height, width, height)
+    fmt.Fprintf(out, `  <text x="%d" y="%d" font-weight="bold">%s</text>`+"\n", svgMargin, svgMargin + 12, svgText(r.Name))
+
+    for _, s := range diagramSegments(r) {
+        //split the segments crossing a lane boundary
+        for high := s.High; ; {
+            lane := (r.Width - 1 - high) / laneBits
+            laneLow := r.Width - (lane + 1) * laneBits
+            low := max(s.Low, laneLow)
+
+            x := svgMargin + int(laneLow + laneBits - 1 - high) * svgBit
+            y := svgMargin + 20 + int(lane) * svgLane
+            boxWidth := int(high - low + 1) * svgBit
+            fill := "#ffffff"
+            if s.Access == AccessReserved {
+                fill = "#d8d8d8"
+            }
+            mid := x + boxWidth / 2
+
+            fmt.Fprintf(out, `  <rect x="%d" y="%d" width="%d" height="%d" fill="%s" stroke="#000000"/>`+"\n",
+                        x, y + 16, boxWidth, svgBox, fill)
+            fmt.Fprintf(out, `  <text x="%d" y="%d" text-anchor="middle">%d</text>`+"\n", x + svgBit / 2, y + 12, high)
+            if high != low {
+                fmt.Fprintf(out, `  <text x="%d" y="%d" text-anchor="middle">%d</text>`+"\n",
+                            x + boxWidth - svgBit / 2, y + 12, low)
+            }
+            if !s.gap {
+                fmt.Fprintf(out, `  <text x="%d" y="%d" text-anchor="middle">%s</text>`+"\n",
+                            mid, y + 16 + svgBox / 2 + 4, svgText(s.Name))
+                fmt.Fprintf(out, `  <text x="%d" y="%d" text-anchor="middle" font-size="10">%s</text>`+"\n",
+                            mid, y + 16 + svgBox + 12, s.Access)
+            }
+
+            if low == s.Low {
+                break
+            }
+            high = low - 1
+        }
+    }
+
+    out.WriteString("</svg>\n")
+    return out.Flush()
+}
+
+// one entry of the WaveDrom bitfield format
+type waveDromField struct {
+    Name string          `json:"name,omitempty"`
+    Bits uint            `json:"bits"`
+    Attr json.RawMessage `json:"attr,omitempty"`
+    Type int             `json:"type,omitempty"`
+}
+
+// MarshalWaveDrom return r in the WaveDrom bitfield JSON format: an array from LSB
+// to MSB of {"name","bits","attr"} with the access type as attr, gaps as {"bits"}
+// and reserved fields with type 1
+func MarshalWaveDrom(r Register) ([]byte, error) {
+    if err := r.Validate(); err != nil {
+        return nil, err
+    }
+
+    segments := diagramSegments(r)
+    slices.Reverse(segments)
+    entries := make([]waveDromField, 0, len(segments))
+    for _, s := range segments {
+        entry := waveDromField{Bits: s.Width()}
+        if !s.gap {
+            entry.Name = s.Name
+            entry.Attr, _ = json.Marshal(s.Access.String())
+        }
+        if s.Access == AccessReserved && !s.gap {
+            entry.Type = 1
+        }
+        entries = append(entries, entry)
+    }
+    return json.Marshal(entries)
+}
+
+// ParseWaveDrom return the register of a WaveDrom bitfield JSON array. Unnamed
+// entries are gaps, attr (a string or the first string of an array) is the access
+// type if it is one and the width is the sum of bits
+func ParseWaveDrom(name string, data []byte) (Register, error) {
+    var entries []waveDromField
+    if err := json.Unmarshal(data, &entries); err != nil {
+        return Register{}, err
+    }
+
+    //bits are summed on 64 bits first, a huge entry can not wrap the total
+    total := uint64(0)
+    for _, e := range entries {
+        if e.Bits == 0 {
+            return Register{}, fmt.Errorf("invalid bits(%v) of field(%v)", e.Bits, e.Name)
+        }
+        total += min(uint64(e.Bits), 1 << 32)
+    }
+    if total > 64 {
+        return Register{}, fmt.Errorf("invalid register(%v), %v bits is wider than 64 bits", name, total)
+    }
+
+    r := Register{Name: name}
+    low := uint(0)
+    for _, e := range entries {
+        if e.Name != "" {
+            f := Field{Name: e.Name, High: low + e.Bits - 1, Low: low}
+            var attr string
+            var attrs []json.RawMessage
+            if json.Unmarshal(e.Attr, &attrs) == nil && len(attrs) > 0 {
+                json.Unmarshal(attrs[0], &attr)
+            } else {
+                json.Unmarshal(e.Attr, &attr)
+            }
+            if access, err := ParseAccess(attr); err == nil {
+                f.Access = access
+            } else if e.Type == 1 {
+                f.Access = AccessReserved
+            }
+            r.Fields = append(r.Fields, f)
+        }
+        low += e.Bits
+    }
+
+    r.Width = low
+    return r, r.Validate()
+}
@@ -0,0 +1,109 @@
+package bitops
+
+import (
+    "encoding/xml"
+    "io"
+    "strings"
+    "testing"
+)
+
+func TestWriteDiagramText(t *testing.T) {
+    var sb strings.Builder
+    if err := WriteDiagramText(&sb, testPeripheral().Registers[0]); err != nil {
+        t.Fatal(err)
+    }
+    expect := "CTRL @ 0x0 (32 bits, reset 0xc04)\n" +
+              "+-------+-------+--------+-------+-------------+\n" +
+              "| Bits  | Field | Access | Reset | Description |\n" +
+              "+-------+-------+--------+-------+-------------+\n" +
+              "| 31:24 | -     | -      | -     |             |\n" +
+              "| 23:8  | DIV   | RW     | 0xc   |             |\n" +
+              "| 7:4   | RSVD  | RSVD   | 0x0   |             |\n" +
+              "| 3     | START | W1S    | 0x0   |             |\n" +
+              "| 2:1   | MODE  | RW     | 0x2   |             |\n" +
+              "| 0     | EN    | RW     | 0x0   |             |\n" +
+              "+-------+-------+--------+-------+-------------+\n"
+    if sb.String() != expect {
+        t.Fail()
+        t.Logf("expect\n%s but get\n%s", expect, sb.String())
+    }
+}
+
+func TestWriteDiagramSVG(t *testing.T) {
+    r := Register{Name: "WIDE<64>", Width: 64, Fields: []Field{
+        {Name: "HI", High: 47, Low: 16, Access: AccessRO},
+        {Name: "LO", High: 7, Low: 0},
+    }}
+    var sb strings.Builder
+    if err := WriteDiagramSVG(&sb, r); err != nil {
+        t.Fatal(err)
+    }
+
+    //well-formed XML with the name escaped and HI split over the two lanes
+    d := xml.NewDecoder(strings.NewReader(sb.String()))
+    texts := []string{}
+    for {
+        tok, err := d.Token()
+        if err != nil {
+            if err != io.EOF {
+                t.Fatal(err)
+            }
+            break
+        }
+        if data, ok := tok.(xml.CharData); ok && strings.TrimSpace(string(data)) != "" {
+            texts = append(texts, string(data))
+        }
+    }
+    s := strings.Join(texts, " ")
+    if expect := "WIDE<64> 63 48 47 32 HI RO 31 16 HI RO 15 8 7 0 LO RW"; s != expect {
+        t.Fail()
+        t.Logf("expect %s but get %s", expect, s)
+    }
+    if strings.Count(sb.String(), "<rect") != 5 {
+        t.Fail()
+        t.Log(sb.String())
+    }
+}
+
+func TestWaveDrom(t *testing.T) {
+    ctrl := testRegisters()[0]
+    data, err := MarshalWaveDrom(ctrl)
+    expect := `[{"name":"EN","bits":1,"attr":"RW"},{"name":"MODE","bits":2,"attr":"RW"},` +
+              `{"name":"START","bits":1,"attr":"W1S"},{"name":"RSVD","bits":4,"attr":"RSVD","type":1},` +
+              `{"name":"DIV","bits":16,"attr":"RW"},{"bits":8}]`
+    if err != nil || string(data) != expect {
+        t.Fail()
+        t.Logf("expect %s but get %s %v", expect, data, err)
+    }
+
+    r, err := ParseWaveDrom("CTRL", data)
+    if err != nil || r.Width != 32 || len(r.Fields) != 5 {
+        t.Fatalf("parse get %+v %v", r, err)
+    }
+    for i, f := range r.Fields {
+        if g := ctrl.Fields[i]; f.Name != g.Name || f.High != g.High || f.Low != g.Low || f.Access != g.Access {
+            t.Fail()
+            t.Logf("field %d expect %+v but get %+v", i, g, f)
+        }
+    }
+
+    //attr array, free text attr and reserved type
+    r, err = ParseWaveDrom("S", []byte(`[{"name":"A","bits":4,"attr":["RO","x"]},{"name":"B","bits":2,"attr":"note"},{"name":"C","bits":2,"type":1}]`))
+    if err != nil || r.Width != 8 || r.Fields[0].Access != AccessRO || r.Fields[1].Access != AccessRW || r.Fields[2].Access != AccessReserved {
+        t.Fail()
+        t.Logf("parse get %+v %v", r, err)
+    }
+    for _, s := range []string{`{}`, `[{"name":"A","bits":0}]`, `[{"name":"A","bits":65}]`, `[{"name":"A","bits":12}]`} {
+        if _, err = ParseWaveDrom("X", []byte(s)); err == nil {
+            t.Fail()
+            t.Logf("parse %s should fail", s)
+        }
+    }
+
+    //a common 128-bit diagram is reported as too wide, not as a bad field
+    wide := `[{"name":"LO","bits":64},{"bits":32},{"name":"HI","bits":32}]`
+    if _, err = ParseWaveDrom("W", []byte(wide)); err == nil || !strings.Contains(err.Error(), "128 bits is wider than 64 bits") {
+        t.Fail()
+        t.Logf("128-bit diagram get %v", err)
+    }
+}
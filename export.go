@@ -0,0 +1,310 @@
+package bitops
+
+import (
+    "bufio"
+    "fmt"
+    "io"
+    "slices"
+    "strings"
+)
+
+// identifier return s with the characters invalid in C, SystemVerilog and Python
+// identifiers replaced by _
+func identifier(s string) (string) {
+    b := []byte(s)
+    for i, c := range b {
+        if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_') {
+            b[i] = '_'
+        }
+    }
+    if len(b) == 0 || b[0] >= '0' && b[0] <= '9' {
+        return "_" + string(b)
+    }
+    return string(b)
+}
+
+// reservedWords are the keywords of C, SystemVerilog and Python and the names
+// WritePython define itself, a generated identifier equal to one get a _ appended
+var reservedWords = func() (map[string]bool) {
+    words := map[string]bool{}
+    for _, list := range []string{
+        //C
+        "auto break case char const continue default do double else enum extern float for goto if inline int " +
+        "long register restrict return short signed sizeof static struct switch typedef union unsigned void " +
+        "volatile while _Bool _Complex _Imaginary bool true false",
+        //SystemVerilog
+        "always always_comb always_ff always_latch and assert assign assume automatic begin bind bins bit break " +
+        "buf byte case casex casez cell chandle class clocking cmos config const constraint context continue " +
+        "cover covergroup coverpoint cross deassign default defparam design disable dist do edge else end " +
+        "endcase endclass endclocking endconfig endfunction endgenerate endgroup endinterface endmodule " +
+        "endpackage endprimitive endprogram endproperty endsequence endspecify endtable endtask enum event " +
+        "expect export extends extern final first_match for force foreach forever fork forkjoin function " +
+        "generate genvar highz0 highz1 if iff ifnone ignore_bins illegal_bins import incdir include initial " +
+        "inout input inside instance int integer interface intersect join join_any join_none large liblist " +
+        "library local localparam logic longint macromodule matches medium modport module nand negedge new " +
+        "nmos nor noshowcancelled not notif0 notif1 null or output package packed parameter pmos posedge " +
+        "primitive priority program property protected pull0 pull1 pulldown pullup pulsestyle_ondetect " +
+        "pulsestyle_onevent pure rand randc randcase randsequence rcmos real realtime ref reg release repeat " +
+        "return rnmos rpmos rtran rtranif0 rtranif1 scalared sequence shortint shortreal showcancelled signed " +
+        "small solve specify specparam static string strong0 strong1 struct super supply0 supply1 table tagged " +
+        "task this throughout time timeprecision timeunit tran tranif0 tranif1 tri tri0 tri1 triand trior " +
+        "trireg type typedef union unique unsigned use uwire var vectored virtual void wait wait_order wand " +
+        "weak0 weak1 while wildcard wire with within wor xnor xor",
+        //Python and the names of the generated module
+        "False None True and as assert async await break class continue def del elif else except finally for " +
+        "from global if import in is lambda nonlocal not or pass raise return try while with yield " +
+        "Field Register BASE REGISTERS",
+    } {
+        for _, w := range strings.Fields(list) {
+            words[w] = true
+        }
+    }
+    return words
+}()
+
+// safeIdentifier return the identifier of s for a name standing alone in the
+// generated source, keywords are suffixed by _
+func safeIdentifier(s string) (string) {
+    id := identifier(s)
+    if reservedWords[id] {
+        return id + "_"
+    }
+    return id
+}
+
+// commentText fold s on one line and break */ so it can be written in a // # or
+// /* */ comment of any generated language
+func commentText(s string) (string) {
+    return strings.ReplaceAll(strings.Join(strings.Fields(s), " "), "*/", "* /")
+}
+
+// validate every register of p before generating code from it
+func validatePeripheral(p *Peripheral) (error) {
+    for _, r := range p.Registers {
+        if err := r.Validate(); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// enums of f with a single entry per name, the lowest value is kept
+func uniqueEnums(f Field) ([]EnumValue) {
+    seen := make(map[string]bool)
+    var enums []EnumValue
+    for _, e := range f.Enums {
+        if !seen[e.Name] {
+            seen[e.Name] = true
+            enums = append(enums, e)
+        }
+    }
+    return enums
+}
+
+// WriteCHeader write a C header with the base address, register offsets and reset
+// values, field shift/width/mask and enum macros and static inline get/set
+// accessors working on the register value. Macros are prefixed PERIPHERAL_REGISTER_
+// and accessors named peripheral_register_field_get/_set
+func WriteCHeader(w io.Writer, p *Peripheral) (error) {
+    if err := validatePeripheral(p); err != nil {
+        return err
+    }
+
+    out := bufio.NewWriter(w)
+    prefix := strings.ToUpper(identifier(p.Name))
+    guard := prefix + "_REGS_H"
+    fmt.Fprintf(out, "/* Register layout of %s, generated, do not edit */\n", commentText(p.Name))
+    fmt.Fprintf(out, "#ifndef %s\n#define %s\n\n#include <stdint.h>\n\n", guard, guard)
+    fmt.Fprintf(out, "#define %s_BASE UINT64_C(0x%X)\n", prefix, p.BaseAddress)
+
+    for _, r := range p.Registers {
+        name := prefix + "_" + strings.ToUpper(identifier(r.Name))
+        lower := strings.ToLower(name)
+        ctype := fmt.Sprintf("uint%d_t", r.Width)
+        literal := func(v uint64) (string) {
+            return fmt.Sprintf("UINT%d_C(0x%X)", r.Width, v)
+        }
+
+        fmt.Fprintln(out)
+        if r.Description != "" {
+            fmt.Fprintf(out, "/* %s: %s */\n", commentText(r.Name), commentText(r.Description))
+        }
+        fmt.Fprintf(out, "#define %s_OFFSET 0x%Xu\n", name, r.Offset)
+        fmt.Fprintf(out, "#define %s_RESET %s\n", name, literal(r.ResetValue()))
+        for _, f := range r.Fields {
+            if f.Access == AccessReserved {
+                continue
+            }
+            field := name + "_" + strings.ToUpper(identifier(f.Name))
+            fmt.Fprintf(out, "#define %s_SHIFT %du\n", field, f.Low)
+            fmt.Fprintf(out, "#define %s_WIDTH %du\n", field, f.Width())
+            fmt.Fprintf(out, "#define %s_MASK %s\n", field, literal(f.Mask()))
+            for _, e := range uniqueEnums(f) {
+                fmt.Fprintf(out, "#define %s_%s %s\n", field, strings.ToUpper(identifier(e.Name)), literal(e.Value))
+            }
+        }
+        for _, f := range r.Fields {
+            if f.Access == AccessReserved {
+                continue
+            }
+            field := name + "_" + strings.ToUpper(identifier(f.Name))
+            fn := lower + "_" + strings.ToLower(identifier(f.Name))
+            fmt.Fprintf(out, "static inline %s %s_get(%s reg) { return (%s)((reg & %s_MASK) >> %s_SHIFT); }\n",
+                        ctype, fn, ctype, ctype, field, field)
+            fmt.Fprintf(out, "static inline %s %s_set(%s reg, %s value) { return (%s)((reg & ~%s_MASK) | ((%s)(value << %s_SHIFT) & %s_MASK)); }\n",
+                        ctype, fn, ctype, ctype, ctype, field, ctype, field, field)
+        }
+    }
+
+    fmt.Fprintf(out, "\n#endif /* %s */\n", guard)
+    return out.Flush()
+}
+
+// WriteSystemVerilog write a SystemVerilog package peripheral_regs_pkg with offset
+// and reset localparams, an enum type per field with enumerated values and a
+// packed struct per register, most significant field first and gaps filled by
+// reserved members
+func WriteSystemVerilog(w io.Writer, p *Peripheral) (error) {
+    if err := validatePeripheral(p); err != nil {
+        return err
+    }
+
+    out := bufio.NewWriter(w)
+    pkg := strings.ToLower(identifier(p.Name)) + "_regs_pkg"
+    fmt.Fprintf(out, "// Register layout of %s, generated, do not edit\n", commentText(p.Name))
+    fmt.Fprintf(out, "package %s;\n\n", pkg)
+    fmt.Fprintf(out, "  localparam logic [63:0] BASE_ADDR = 64'h%X;\n", p.BaseAddress)
+
+    for _, r := range p.Registers {
+        name := strings.ToUpper(identifier(r.Name))
+        typ := strings.ToLower(identifier(r.Name))
+
+        fmt.Fprintln(out)
+        if r.Description != "" {
+            fmt.Fprintf(out, "  // %s: %s\n", commentText(r.Name), commentText(r.Description))
+        }
+        fmt.Fprintf(out, "  localparam logic [63:0] %s_OFFSET = 64'h%X;\n", name, r.Offset)
+
+        for _, f := range r.Fields {
+            enums := uniqueEnums(f)
+            if len(enums) == 0 || f.Access == AccessReserved {
+                continue
+            }
+            fmt.Fprintf(out, "\n  typedef enum logic [%d:0] {\n", f.Width() - 1)
+            for i, e := range enums {
+                sep := ","
+                if i == len(enums) - 1 {
+                    sep = ""
+                }
+                fmt.Fprintf(out, "    %s_%s_%s = %d'h%X%s\n", name, strings.ToUpper(identifier(f.Name)),
+                            strings.ToUpper(identifier(e.Name)), f.Width(), e.Value, sep)
+            }
+            fmt.Fprintf(out, "  } %s_e;\n", strings.ToLower(identifier(r.Name) + "_" + identifier(f.Name)))
+        }
+
+        fields := slices.Clone(r.Fields)
+        slices.SortFunc(fields, func(a, b Field) (int) {
+            return int(b.Low) - int(a.Low)
+        })
+        member := func(high uint, low uint, typ string, name string) {
+            if typ == "" {
+                typ = "logic"
+                if high > low {
+                    typ = fmt.Sprintf("logic [%d:0]", high - low)
+                }
+            }
+            fmt.Fprintf(out, "    %s %s; // [%d:%d]\n", typ, name, high, low)
+        }
+
+        fmt.Fprintf(out, "\n  typedef struct packed {\n")
+        next := r.Width
+        for _, f := range fields {
+            if f.High + 1 < next {
+                member(next - 1, f.High + 1, "", fmt.Sprintf("rsvd_%d", f.High + 1))
+            }
+            typ := ""
+            if len(uniqueEnums(f)) != 0 && f.Access != AccessReserved {
+                typ = strings.ToLower(identifier(r.Name) + "_" + identifier(f.Name)) + "_e"
+            }
+            member(f.High, f.Low, typ, safeIdentifier(f.Name))
+            next = f.Low
+        }
+        if next > 0 {
+            member(next - 1, 0, "", "rsvd_0")
+        }
+        fmt.Fprintf(out, "  } %s_t;\n\n", typ)
+        fmt.Fprintf(out, "  localparam %s_t %s_RESET = %d'h%X;\n", typ, name, r.Width, r.ResetValue())
+    }
+
+    fmt.Fprintf(out, "\nendpackage : %s\n", pkg)
+    return out.Flush()
+}
+
+// python source of the helper classes of WritePython
+const pythonPrelude = `class Field:
+    def __init__(self, name, high, low, access, reset, enums):
+        self.name, self.high, self.low = name, high, low
+        self.access, self.reset, self.enums = access, reset, enums
+        self.width = high - low + 1
+        self.mask = ((1 << self.width) - 1) << low
+
+    def get(self, value):
+        return (value & self.mask) >> self.low
+
+    def set(self, value, field):
+        if isinstance(field, str):
+            field = self.enums[field]
+        if field < 0 or field >> self.width:
+            raise ValueError("invalid value(%r) for field(%s)" % (field, self.name))
+        return (value & ~self.mask) | (field << self.low)
+
+
+class Register:
+    def __init__(self, name, offset, width, reset, fields):
+        self.name, self.offset, self.width, self.reset = name, offset, width, reset
+        self.fields = {f.name: f for f in fields}
+
+    def decode(self, value):
+        return {name: f.get(value) for name, f in self.fields.items() if f.access != "RSVD"}
+
+    def encode(self, **fields):
+        value = self.reset
+        for name, field in fields.items():
+            value = self.fields[name].set(value, field)
+        return value
+`
+
+// WritePython write a Python module with a Register object per register, their
+// Field objects with get/set and enum dictionaries, BASE and REGISTERS
+func WritePython(w io.Writer, p *Peripheral) (error) {
+    if err := validatePeripheral(p); err != nil {
+        return err
+    }
+
+    out := bufio.NewWriter(w)
+    fmt.Fprintf(out, "# Register layout of %s, generated, do not edit\n\n", commentText(p.Name))
+    fmt.Fprintf(out, "%s\n\nBASE = 0x%X\n", pythonPrelude, p.BaseAddress)
+
+    var names []string
+    for _, r := range p.Registers {
+        name := safeIdentifier(strings.ToUpper(identifier(r.Name)))
+        names = append(names, name)
+        fmt.Fprintln(out)
+        if r.Description != "" {
+            fmt.Fprintf(out, "# %s\n", commentText(r.Description))
+        }
+        fmt.Fprintf(out, "%s = Register(%q, 0x%X, %d, 0x%X, [\n", name, r.Name, r.Offset, r.Width, r.ResetValue())
+        for _, f := range r.Fields {
+            var enums []string
+            for _, e := range uniqueEnums(f) {
+                enums = append(enums, fmt.Sprintf("%q: 0x%X", e.Name, e.Value))
+            }
+            fmt.Fprintf(out, "    Field(%q, %d, %d, %q, 0x%X, {%s}),\n", f.Name, f.High, f.Low,
+                        f.Access.String(), f.Reset, strings.Join(enums, ", "))
+        }
+        fmt.Fprintf(out, "])\n")
+    }
+
+    fmt.Fprintf(out, "\nREGISTERS = [%s]\n", strings.Join(names, ", "))
+    return out.Flush()
+}
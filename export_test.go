@@ -0,0 +1,129 @@
+package bitops
+
+import (
+    "strings"
+    "testing"
+)
+
+// UART layout of testRegisters with enums and descriptions
+func testPeripheral() (*Peripheral) {
+    regs := testRegisters()
+    regs[0].Description = "Control"
+    regs[0].Fields[1].Enums = []EnumValue{{Name: "SLOW", Value: 0}, {Name: "NORMAL", Value: 1}, {Name: "FAST", Value: 2}}
+    return &Peripheral{Name: "UART0", BaseAddress: 0x40001000, Registers: regs}
+}
+
+// check every line of expect is in the generated source
+func checkGenerated(t *testing.T, kind string, src string, expect []string) {
+    for _, line := range expect {
+        if !strings.Contains(src, line) {
+            t.Fail()
+            t.Logf("%s miss %q", kind, line)
+        }
+    }
+    if t.Failed() {
+        t.Log(src)
+    }
+}
+
+func TestWriteCHeader(t *testing.T) {
+    var sb strings.Builder
+    if err := WriteCHeader(&sb, testPeripheral()); err != nil {
+        t.Fatal(err)
+    }
+    checkGenerated(t, "C", sb.String(), []string{
+        "#ifndef UART0_REGS_H",
+        "#define UART0_BASE UINT64_C(0x40001000)",
+        "/* CTRL: Control */",
+        "#define UART0_CTRL_RESET UINT32_C(0xC04)",
+        "#define UART0_CTRL_DIV_SHIFT 8u",
+        "#define UART0_CTRL_DIV_WIDTH 16u",
+        "#define UART0_CTRL_DIV_MASK UINT32_C(0xFFFF00)",
+        "#define UART0_CTRL_MODE_FAST UINT32_C(0x2)",
+        "#define UART0_DATA_OFFSET 0x8u",
+        "static inline uint8_t uart0_data_val_get(uint8_t reg)",
+        "static inline uint32_t uart0_ctrl_div_set(uint32_t reg, uint32_t value)",
+        "#endif /* UART0_REGS_H */",
+    })
+    if strings.Contains(sb.String(), "CTRL_RSVD") {
+        t.Fail()
+        t.Log("reserved field in C header")
+    }
+}
+
+func TestWriteSystemVerilog(t *testing.T) {
+    var sb strings.Builder
+    if err := WriteSystemVerilog(&sb, testPeripheral()); err != nil {
+        t.Fatal(err)
+    }
+    checkGenerated(t, "SystemVerilog", sb.String(), []string{
+        "package uart0_regs_pkg;",
+        "localparam logic [63:0] BASE_ADDR = 64'h40001000;",
+        "  typedef enum logic [1:0] {\n    CTRL_MODE_SLOW = 2'h0,\n    CTRL_MODE_NORMAL = 2'h1,\n    CTRL_MODE_FAST = 2'h2\n  } ctrl_mode_e;",
+        "  typedef struct packed {\n    logic [7:0] rsvd_24; // [31:24]\n    logic [15:0] DIV; // [23:8]\n" +
+        "    logic [3:0] RSVD; // [7:4]\n    logic START; // [3:3]\n    ctrl_mode_e MODE; // [2:1]\n    logic EN; // [0:0]\n  } ctrl_t;",
+        "localparam ctrl_t CTRL_RESET = 32'hC04;",
+        "    logic [28:0] rsvd_3; // [31:3]",
+        "endpackage : uart0_regs_pkg",
+    })
+}
+
+func TestWritePython(t *testing.T) {
+    var sb strings.Builder
+    if err := WritePython(&sb, testPeripheral()); err != nil {
+        t.Fatal(err)
+    }
+    checkGenerated(t, "Python", sb.String(), []string{
+        "class Register:",
+        "BASE = 0x40001000",
+        "# Control\nCTRL = Register(\"CTRL\", 0x0, 32, 0xC04, [",
+        "    Field(\"MODE\", 2, 1, \"RW\", 0x2, {\"SLOW\": 0x0, \"NORMAL\": 0x1, \"FAST\": 0x2}),",
+        "    Field(\"DONE\", 1, 1, \"W1C\", 0x0, {}),",
+        "REGISTERS = [CTRL, STATUS, DATA, KEY]",
+    })
+
+    p := testPeripheral()
+    p.Registers[0].Fields[0].High = 40
+    if err := WritePython(&sb, p); err == nil {
+        t.Fail()
+        t.Log("invalid layout")
+    }
+}
+
+func TestWriteEscaped(t *testing.T) {
+    //names that are keywords and descriptions that would end a comment or a line
+    p := &Peripheral{Name: "P */ 1", Registers: []Register{
+        {Name: "BASE", Width: 8, Description: "ends */ here\nnext line", Fields: []Field{
+            {Name: "logic", High: 3, Low: 0, Access: AccessRW},
+            {Name: "None", High: 7, Low: 4, Access: AccessRW},
+        }},
+        {Name: "reg", Offset: 1, Width: 8, Fields: []Field{{Name: "int", High: 7, Low: 0, Access: AccessRO}}},
+    }}
+
+    var c, sv, py strings.Builder
+    if err := WriteCHeader(&c, p); err != nil {
+        t.Fatal(err)
+    }
+    if err := WriteSystemVerilog(&sv, p); err != nil {
+        t.Fatal(err)
+    }
+    if err := WritePython(&py, p); err != nil {
+        t.Fatal(err)
+    }
+    checkGenerated(t, "C", c.String(), []string{
+        "/* Register layout of P * / 1, generated, do not edit */",
+        "/* BASE: ends * / here next line */",
+        "static inline uint8_t p____1_reg_int_get(uint8_t reg)",
+    })
+    checkGenerated(t, "SystemVerilog", sv.String(), []string{
+        "  // BASE: ends * / here next line\n",
+        "    logic [3:0] logic_; // [3:0]",
+        "    logic [7:0] int_; // [7:0]",
+    })
+    checkGenerated(t, "Python", py.String(), []string{
+        "# ends * / here next line\n",
+        "BASE_ = Register(\"BASE\", 0x0, 8",
+        "REG = Register(\"reg\"",
+        "REGISTERS = [BASE_, REG]",
+    })
+}
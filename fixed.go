@@ -0,0 +1,338 @@
+package bitops
+
+import (
+    "fmt"
+    "math"
+)
+
+// RoundMode select how fixed-point operations drop fraction bits
+type RoundMode uint
+
+const (
+    // RoundFloor round toward negative infinity, an arithmetic right shift
+    RoundFloor RoundMode = iota
+    // RoundTowardZero drop the fraction bits of the magnitude
+    RoundTowardZero
+    // RoundNearest round to nearest and ties away from zero
+    RoundNearest
+    // RoundNearestEven round to nearest and ties to even
+    RoundNearestEven
+)
+
+// QFormat describe a Qm.n fixed-point format with m integer bits and n fraction
+// bits. For signed formats the sign bit is counted in m, so Q1.15 is a 16-bit word.
+// The total width is limited to 32 bits
+type QFormat struct {
+    Int      uint
+    Frac     uint
+    Unsigned bool
+}
+
+// Fixed is a fixed-point value of a given QFormat kept as its raw integer
+type Fixed struct {
+    format QFormat
+    raw    int64
+}
+
+// NewQFormat create a Qm.n format and return error if the width is 0 or larger than
+// 32 bits, or a signed format has no integer bit for the sign
+func NewQFormat(intBits uint, fracBits uint, signed bool) (QFormat, error) {
+    q := QFormat{Int: intBits, Frac: fracBits, Unsigned: !signed}
+    if err := q.validate(); err != nil {
+        return QFormat{}, err
+    }
+    return q, nil
+}
+
+// check the format before any operation
+func (q QFormat) validate() (error) {
+    if w := q.Int + q.Frac; w == 0 || w > 32 || q.Int > 32 || q.Frac > 32 || (!q.Unsigned && q.Int == 0) {
+        return fmt.Errorf("invalid integer bits(%v) or fraction bits(%v)", q.Int, q.Frac)
+    }
+    return nil
+}
+
+// Width return the number of bits of the format
+func (q QFormat) Width() (uint) {
+    return q.Int + q.Frac
+}
+
+// String return the format as Qm.n or UQm.n
+func (q QFormat) String() (string) {
+    if q.Unsigned {
+        return fmt.Sprintf("UQ%d.%d", q.Int, q.Frac)
+    }
+    return fmt.Sprintf("Q%d.%d", q.Int, q.Frac)
+}
+
+// MinRaw return the smallest raw value of the format
+func (q QFormat) MinRaw() (int64) {
+    if q.Unsigned {
+        return 0
+    }
+    return -(int64(1) << (q.Width() - 1))
+}
+
+// MaxRaw return the largest raw value of the format
+func (q QFormat) MaxRaw() (int64) {
+    if q.Unsigned {
+        return (int64(1) << q.Width()) - 1
+    }
+    return (int64(1) << (q.Width() - 1)) - 1
+}
+
+// clamp raw to the range of the format
+func (q QFormat) saturate(raw int64) (int64) {
+    if raw > q.MaxRaw() {
+        return q.MaxRaw()
+    }
+    if raw < q.MinRaw() {
+        return q.MinRaw()
+    }
+    return raw
+}
+
+// keep the low width bits of raw and sign-extend them for signed formats
+func (q QFormat) wrap(raw int64) (int64) {
+    shift := 64 - q.Width()
+    if q.Unsigned {
+        return int64(uint64(raw) << shift >> shift)
+    }
+    return (raw << shift) >> shift
+}
+
+// FromRaw create a value from its raw integer and return error if it is out of range
+func (q QFormat) FromRaw(raw int64) (Fixed, error) {
+    if err := q.validate(); err != nil {
+        return Fixed{}, err
+    }
+    if raw < q.MinRaw() || raw > q.MaxRaw() {
+        return Fixed{}, fmt.Errorf("raw value(%v) out of %v range", raw, q)
+    }
+    return Fixed{format: q, raw: raw}, nil
+}
+
+// FromFloat convert f to the format with the given rounding and saturate if f is out
+// of range. It return error for NaN
+func (q QFormat) FromFloat(f float64, mode RoundMode) (Fixed, error) {
+    if err := q.validate(); err != nil {
+        return Fixed{}, err
+    }
+    if f != f {
+        return Fixed{}, fmt.Errorf("NaN can not be converted to %v", q)
+    }
+
+    scaled := math.Ldexp(f, int(q.Frac))
+    switch mode {
+    case RoundFloor:
+        scaled = math.Floor(scaled)
+    case RoundTowardZero:
+        scaled = math.Trunc(scaled)
+    case RoundNearest:
+        scaled = math.Round(scaled)
+    default:
+        scaled = math.RoundToEven(scaled)
+    }
+
+    // the range is at most 33 bits so the comparison in float64 is exact
+    if scaled >= float64(q.MaxRaw()) {
+        return Fixed{format: q, raw: q.MaxRaw()}, nil
+    }
+    if scaled <= float64(q.MinRaw()) {
+        return Fixed{format: q, raw: q.MinRaw()}, nil
+    }
+    return Fixed{format: q, raw: int64(scaled)}, nil
+}
+
+// GetField32 read a value of the format from bits high:low of a register word,
+// sign-extending signed formats. The field width must equal the format width
+func (q QFormat) GetField32(value uint32, high uint, low uint) (Fixed, error) {
+    if err := q.validate(); err != nil {
+        return Fixed{}, err
+    }
+    if high < low || high - low + 1 != q.Width() {
+        return Fixed{}, fmt.Errorf("invalid high(%v) or low(%v) for %v", high, low, q)
+    }
+
+    if q.Unsigned {
+        raw, err := GetField32(value, high, low)
+        return Fixed{format: q, raw: int64(raw)}, err
+    }
+    raw, err := GetSignedField32(value, high, low)
+    return Fixed{format: q, raw: int64(raw)}, err
+}
+
+// SetFixedField32 write x to bits high:low of a register word. The field width must
+// equal the width of the format of x
+func SetFixedField32(value uint32, high uint, low uint, x Fixed) (uint32, error) {
+    if high < low || high - low + 1 != x.format.Width() {
+        return value, fmt.Errorf("invalid high(%v) or low(%v) for %v", high, low, x.format)
+    }
+
+    return SetField32(value, high, low, uint32(x.raw))
+}
+
+// Format return the QFormat of x
+func (x Fixed) Format() (QFormat) {
+    return x.format
+}
+
+// Raw return the raw integer of x, x * 2^frac
+func (x Fixed) Raw() (int64) {
+    return x.raw
+}
+
+// Float64 return the value of x, exact for every format
+func (x Fixed) Float64() (float64) {
+    return math.Ldexp(float64(x.raw), -int(x.format.Frac))
+}
+
+// String return the decimal value followed by the format
+func (x Fixed) String() (string) {
+    return fmt.Sprintf("%v(%v)", x.Float64(), x.format)
+}
+
+// check both operands share a format
+func (x Fixed) sameFormat(y Fixed) (error) {
+    if x.format != y.format {
+        return fmt.Errorf("format mismatch(%v, %v)", x.format, y.format)
+    }
+    return nil
+}
+
+// AddSat return x + y clamped to the range of the format
+func (x Fixed) AddSat(y Fixed) (Fixed, error) {
+    if err := x.sameFormat(y); err != nil {
+        return x, err
+    }
+    return Fixed{format: x.format, raw: x.format.saturate(x.raw + y.raw)}, nil
+}
+
+// AddWrap return x + y wrapped around at the width of the format
+func (x Fixed) AddWrap(y Fixed) (Fixed, error) {
+    if err := x.sameFormat(y); err != nil {
+        return x, err
+    }
+    return Fixed{format: x.format, raw: x.format.wrap(x.raw + y.raw)}, nil
+}
+
+// SubSat return x - y clamped to the range of the format
+func (x Fixed) SubSat(y Fixed) (Fixed, error) {
+    if err := x.sameFormat(y); err != nil {
+        return x, err
+    }
+    return Fixed{format: x.format, raw: x.format.saturate(x.raw - y.raw)}, nil
+}
+
+// SubWrap return x - y wrapped around at the width of the format
+func (x Fixed) SubWrap(y Fixed) (Fixed, error) {
+    if err := x.sameFormat(y); err != nil {
+        return x, err
+    }
+    return Fixed{format: x.format, raw: x.format.wrap(x.raw - y.raw)}, nil
+}
+
+// shift the magnitude right with the given rounding, neg tell the sign of the value
+func roundMagnitude(mag uint64, neg bool, shift uint, mode RoundMode) (uint64) {
+    if shift == 0 {
+        return mag
+    }
+
+    quo := mag >> shift
+    rem := mag & ((uint64(1) << shift) - 1)
+    half := uint64(1) << (shift - 1)
+    switch mode {
+    case RoundFloor:
+        if neg && rem != 0 {
+            quo++
+        }
+    case RoundNearest:
+        if rem >= half {
+            quo++
+        }
+    case RoundNearestEven:
+        if rem > half || (rem == half && quo & 1 == 1) {
+            quo++
+        }
+    }
+    return quo
+}
+
+// real implementation for MulSat and MulWrap, the raw product keep 2*frac fraction
+// bits and its magnitude always fits in uint64 for 32-bit formats
+func (x Fixed) mul(y Fixed, mode RoundMode) (uint64, bool) {
+    neg := (x.raw < 0) != (y.raw < 0)
+    mx, my := x.raw, y.raw
+    if mx < 0 {
+        mx = -mx
+    }
+    if my < 0 {
+        my = -my
+    }
+    return roundMagnitude(uint64(mx) * uint64(my), neg, x.format.Frac, mode), neg
+}
+
+// MulSat return x * y rounded with mode and clamped to the range of the format
+func (x Fixed) MulSat(y Fixed, mode RoundMode) (Fixed, error) {
+    if err := x.sameFormat(y); err != nil {
+        return x, err
+    }
+
+    mag, neg := x.mul(y, mode)
+    q := x.format
+    if neg {
+        if mag > uint64(-q.MinRaw()) {
+            return Fixed{format: q, raw: q.MinRaw()}, nil
+        }
+        return Fixed{format: q, raw: -int64(mag)}, nil
+    }
+    if mag > uint64(q.MaxRaw()) {
+        return Fixed{format: q, raw: q.MaxRaw()}, nil
+    }
+    return Fixed{format: q, raw: int64(mag)}, nil
+}
+
+// MulWrap return x * y rounded with mode and wrapped around at the width of the format
+func (x Fixed) MulWrap(y Fixed, mode RoundMode) (Fixed, error) {
+    if err := x.sameFormat(y); err != nil {
+        return x, err
+    }
+
+    mag, neg := x.mul(y, mode)
+    raw := int64(mag)
+    if neg {
+        raw = -raw
+    }
+    return Fixed{format: x.format, raw: x.format.wrap(raw)}, nil
+}
+
+// Convert change x to format q with the given rounding and saturate if the value is
+// out of range
+func (x Fixed) Convert(q QFormat, mode RoundMode) (Fixed, error) {
+    if err := q.validate(); err != nil {
+        return Fixed{}, err
+    }
+
+    raw := x.raw
+    if q.Frac >= x.format.Frac {
+        // widening the fraction is exact, saturate before the shift can overflow
+        shift := q.Frac - x.format.Frac
+        if raw > q.MaxRaw() >> shift {
+            return Fixed{format: q, raw: q.MaxRaw()}, nil
+        }
+        if raw < q.MinRaw() >> shift {
+            return Fixed{format: q, raw: q.MinRaw()}, nil
+        }
+        return Fixed{format: q, raw: raw << shift}, nil
+    }
+
+    mag := raw
+    if mag < 0 {
+        mag = -mag
+    }
+    rounded := int64(roundMagnitude(uint64(mag), raw < 0, x.format.Frac - q.Frac, mode))
+    if raw < 0 {
+        rounded = -rounded
+    }
+    return Fixed{format: q, raw: q.saturate(rounded)}, nil
+}
@@ -0,0 +1,243 @@
+package bitops
+
+import (
+    "math"
+    "testing"
+)
+
+func TestNewQFormat(t *testing.T) {
+    q, err := NewQFormat(1, 15, true)
+    if err != nil || q.Width() != 16 || q.String() != "Q1.15" {
+        t.Fail()
+        t.Logf("Q1.15 get %v %v", q, err)
+    }
+    if q.MinRaw() != -0x8000 || q.MaxRaw() != 0x7FFF {
+        t.Fail()
+        t.Logf("Q1.15 range get %d %d", q.MinRaw(), q.MaxRaw())
+    }
+
+    q, err = NewQFormat(0, 32, false)
+    if err != nil || q.String() != "UQ0.32" || q.MaxRaw() != 0xFFFFFFFF {
+        t.Fail()
+        t.Logf("UQ0.32 get %v %v", q, err)
+    }
+
+    for _, v := range [][2]uint{{0, 0}, {20, 13}, {0, 16}} {
+        if _, err = NewQFormat(v[0], v[1], true); err == nil {
+            t.Fail()
+            t.Logf("Q%d.%d should be invalid", v[0], v[1])
+        }
+    }
+}
+
+func TestFixedFromFloat(t *testing.T) {
+    q, _ := NewQFormat(1, 15, true)
+    vectors := []struct {
+        f      float64
+        mode   RoundMode
+        expect int64
+    }{
+        {0.5, RoundNearestEven, 0x4000},
+        {-1, RoundNearestEven, -0x8000},
+        {1, RoundNearestEven, 0x7FFF},
+        {-2, RoundNearestEven, -0x8000},
+        {math.Inf(1), RoundFloor, 0x7FFF},
+        {2.5 / 32768, RoundNearestEven, 2},
+        {2.5 / 32768, RoundNearest, 3},
+        {-2.5 / 32768, RoundNearest, -3},
+        {-2.5 / 32768, RoundFloor, -3},
+        {-2.5 / 32768, RoundTowardZero, -2},
+        {3.5 / 32768, RoundNearestEven, 4},
+    }
+
+    for _, v := range vectors {
+        x, err := q.FromFloat(v.f, v.mode)
+        if err != nil || x.Raw() != v.expect {
+            t.Fail()
+            t.Logf("%v in mode %d expect %d but get %d", v.f, v.mode, v.expect, x.Raw())
+        }
+    }
+
+    if _, err := q.FromFloat(math.NaN(), RoundFloor); err == nil {
+        t.Fail()
+        t.Log("NaN should fail")
+    }
+
+    x, _ := q.FromFloat(-0.25, RoundFloor)
+    if x.Float64() != -0.25 || x.String() != "-0.25(Q1.15)" {
+        t.Fail()
+        t.Logf("get %v", x)
+    }
+}
+
+func TestFixedFromRaw(t *testing.T) {
+    q, _ := NewQFormat(4, 4, false)
+    if x, err := q.FromRaw(0xFF); err != nil || x.Float64() != 15.9375 {
+        t.Fail()
+        t.Logf("get %v %v", x, err)
+    }
+    if _, err := q.FromRaw(0x100); err == nil {
+        t.Fail()
+        t.Log("raw out of range")
+    }
+    if _, err := q.FromRaw(-1); err == nil {
+        t.Fail()
+        t.Log("negative raw for unsigned format")
+    }
+}
+
+func TestFixedAddSub(t *testing.T) {
+    q, _ := NewQFormat(1, 15, true)
+    a, _ := q.FromFloat(0.75, RoundFloor)
+    b, _ := q.FromFloat(0.5, RoundFloor)
+
+    if x, err := a.AddSat(b); err != nil || x.Raw() != 0x7FFF {
+        t.Fail()
+        t.Logf("saturating add get %v", x)
+    }
+    if x, err := a.AddWrap(b); err != nil || x.Float64() != -0.75 {
+        t.Fail()
+        t.Logf("wrapping add get %v", x)
+    }
+
+    n, _ := q.FromFloat(-1, RoundFloor)
+    if x, err := n.SubSat(b); err != nil || x.Raw() != -0x8000 {
+        t.Fail()
+        t.Logf("saturating sub get %v", x)
+    }
+    if x, err := n.SubWrap(b); err != nil || x.Float64() != 0.5 {
+        t.Fail()
+        t.Logf("wrapping sub get %v", x)
+    }
+
+    u, _ := NewQFormat(8, 0, false)
+    c, _ := u.FromRaw(3)
+    d, _ := u.FromRaw(5)
+    if x, err := c.SubSat(d); err != nil || x.Raw() != 0 {
+        t.Fail()
+        t.Logf("unsigned saturating sub get %v", x)
+    }
+    if x, err := c.SubWrap(d); err != nil || x.Raw() != 0xFE {
+        t.Fail()
+        t.Logf("unsigned wrapping sub get %v", x)
+    }
+
+    if _, err := a.AddSat(c); err == nil {
+        t.Fail()
+        t.Log("format mismatch")
+    }
+}
+
+func TestFixedMul(t *testing.T) {
+    q, _ := NewQFormat(1, 15, true)
+    a, _ := q.FromFloat(0.5, RoundFloor)
+    b, _ := q.FromFloat(-0.5, RoundFloor)
+    if x, err := a.MulSat(b, RoundNearestEven); err != nil || x.Float64() != -0.25 {
+        t.Fail()
+        t.Logf("0.5 * -0.5 get %v", x)
+    }
+
+    //-1 * -1 is the only product out of range in Q1.15
+    n, _ := q.FromRaw(-0x8000)
+    if x, err := n.MulSat(n, RoundFloor); err != nil || x.Raw() != 0x7FFF {
+        t.Fail()
+        t.Logf("saturating -1 * -1 get %v", x)
+    }
+    if x, err := n.MulWrap(n, RoundFloor); err != nil || x.Raw() != -0x8000 {
+        t.Fail()
+        t.Logf("wrapping -1 * -1 get %v", x)
+    }
+
+    //3 * -1 / 2^15 = -1.5 / 2^14, the product is a tie
+    c, _ := q.FromRaw(3)
+    d, _ := q.FromRaw(-0x4000)
+    for mode, expect := range []int64{-2, -1, -2, -2} {
+        if x, err := c.MulSat(d, RoundMode(mode)); err != nil || x.Raw() != expect {
+            t.Fail()
+            t.Logf("mode %d expect %d but get %d", mode, expect, x.Raw())
+        }
+    }
+
+    //the full unsigned 32-bit product must not overflow
+    u, _ := NewQFormat(16, 16, false)
+    m, _ := u.FromRaw(0xFFFFFFFF)
+    if x, err := m.MulSat(m, RoundFloor); err != nil || x.Raw() != 0xFFFFFFFF {
+        t.Fail()
+        t.Logf("saturating max * max get %x", x.Raw())
+    }
+    one, _ := u.FromFloat(1, RoundFloor)
+    if x, err := m.MulWrap(one, RoundFloor); err != nil || x.Raw() != 0xFFFFFFFF {
+        t.Fail()
+        t.Logf("max * 1 get %x", x.Raw())
+    }
+}
+
+func TestFixedConvert(t *testing.T) {
+    q15, _ := NewQFormat(1, 15, true)
+    q7, _ := NewQFormat(1, 7, true)
+    q31, _ := NewQFormat(1, 31, true)
+
+    x, _ := q15.FromRaw(0x0180)
+    if y, err := x.Convert(q7, RoundNearestEven); err != nil || y.Raw() != 2 {
+        t.Fail()
+        t.Logf("Q1.15 to Q1.7 get %d", y.Raw())
+    }
+    if y, err := x.Convert(q7, RoundTowardZero); err != nil || y.Raw() != 1 {
+        t.Fail()
+        t.Logf("Q1.15 to Q1.7 toward zero get %d", y.Raw())
+    }
+    if y, err := x.Convert(q31, RoundFloor); err != nil || y.Raw() != 0x01800000 || y.Float64() != x.Float64() {
+        t.Fail()
+        t.Logf("Q1.15 to Q1.31 get %x", y.Raw())
+    }
+
+    //0x7FFF rounds up to 1.0 which is out of Q1.7
+    x, _ = q15.FromRaw(0x7FFF)
+    if y, err := x.Convert(q7, RoundNearest); err != nil || y.Raw() != 0x7F {
+        t.Fail()
+        t.Logf("Q1.15 max to Q1.7 get %x", y.Raw())
+    }
+
+    q8, _ := NewQFormat(8, 8, true)
+    x, _ = q8.FromFloat(-100, RoundFloor)
+    if y, err := x.Convert(q15, RoundFloor); err != nil || y.Raw() != -0x8000 {
+        t.Fail()
+        t.Logf("Q8.8 -100 to Q1.15 get %x", y.Raw())
+    }
+}
+
+func TestFixedField32(t *testing.T) {
+    q, _ := NewQFormat(1, 15, true)
+    x, err := q.GetField32(0xC0001234, 31, 16)
+    if err != nil || x.Float64() != -0.5 {
+        t.Fail()
+        t.Logf("bits 31:16 get %v", x)
+    }
+
+    u, _ := NewQFormat(0, 16, false)
+    y, err := u.GetField32(0xC0001234, 31, 16)
+    if err != nil || y.Float64() != 0.75 {
+        t.Fail()
+        t.Logf("unsigned bits 31:16 get %v", y)
+    }
+
+    if _, err = q.GetField32(0xC0001234, 31, 17); err == nil {
+        t.Fail()
+        t.Log("field width mismatch")
+    }
+
+    z, _ := q.FromFloat(0.25, RoundFloor)
+    if reg, err := SetFixedField32(0xC0001234, 31, 16, z); err != nil || reg != 0x20001234 {
+        t.Fail()
+        t.Logf("set bits 31:16 get %x", reg)
+    }
+    x, _ = q.FromFloat(-0.25, RoundFloor)
+    if reg, err := SetFixedField32(0, 15, 0, x); err != nil || reg != 0xE000 {
+        t.Fail()
+        t.Logf("set bits 15:0 get %x", reg)
+    }
+    if _, err = SetFixedField32(0, 31, 0, x); err == nil {
+        t.Fail()
+        t.Log("field width mismatch")
+    }
+}
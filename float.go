@@ -0,0 +1,375 @@
+package bitops
+
+import (
+    "fmt"
+    "math"
+)
+
+// FloatClass is the IEEE-754 class of a floating-point value
+type FloatClass uint
+
+const (
+    FloatZero FloatClass = iota
+    FloatSubnormal
+    FloatNormal
+    FloatInfinite
+    FloatQuietNaN
+    FloatSignalingNaN
+)
+
+// String return the name of the class
+func (c FloatClass) String() (string) {
+    switch c {
+    case FloatZero:
+        return "zero"
+    case FloatSubnormal:
+        return "subnormal"
+    case FloatNormal:
+        return "normal"
+    case FloatInfinite:
+        return "infinite"
+    case FloatQuietNaN:
+        return "quiet NaN"
+    case FloatSignalingNaN:
+        return "signaling NaN"
+    }
+    return fmt.Sprintf("FloatClass(%d)", uint(c))
+}
+
+// DecomposeFloat32 split a float32 into sign (bit 31), biased exponent (bit 30:23)
+// and mantissa (bit 22:0) fields
+func DecomposeFloat32(f float32) (uint32, uint32, uint32) {
+    b := math.Float32bits(f)
+    sign, _ := GetField32(b, 31, 31)
+    exp, _ := GetField32(b, 30, 23)
+    mant, _ := GetField32(b, 22, 0)
+    return sign, exp, mant
+}
+
+// DecomposeFloat64 split a float64 into sign (bit 63), biased exponent (bit 62:52)
+// and mantissa (bit 51:0) fields
+func DecomposeFloat64(f float64) (uint64, uint64, uint64) {
+    b := math.Float64bits(f)
+    sign, _ := GetField64(b, 63, 63)
+    exp, _ := GetField64(b, 62, 52)
+    mant, _ := GetField64(b, 51, 0)
+    return sign, exp, mant
+}
+
+// ComposeFloat32 build a float32 from sign, biased exponent and mantissa fields
+// and return error if any field does not fit
+func ComposeFloat32(sign uint32, exp uint32, mant uint32) (float32, error) {
+    if sign > 1 || exp > 0xFF || mant > 0x7FFFFF {
+        return 0, fmt.Errorf("invalid sign(%v), exponent(%v) or mantissa(%v)", sign, exp, mant)
+    }
+
+    b, _ := SetField32(0, 31, 31, sign)
+    b, _ = SetField32(b, 30, 23, exp)
+    b, _ = SetField32(b, 22, 0, mant)
+    return math.Float32frombits(b), nil
+}
+
+// ComposeFloat64 build a float64 from sign, biased exponent and mantissa fields
+// and return error if any field does not fit
+func ComposeFloat64(sign uint64, exp uint64, mant uint64) (float64, error) {
+    if sign > 1 || exp > 0x7FF || mant > 0xFFFFFFFFFFFFF {
+        return 0, fmt.Errorf("invalid sign(%v), exponent(%v) or mantissa(%v)", sign, exp, mant)
+    }
+
+    b, _ := SetField64(0, 63, 63, sign)
+    b, _ = SetField64(b, 62, 52, exp)
+    b, _ = SetField64(b, 51, 0, mant)
+    return math.Float64frombits(b), nil
+}
+
+// real implementation for ClassifyFloat32/64 on the exponent and mantissa fields
+func classifyFloat(exp uint64, mant uint64, expBits uint, mantBits uint) (FloatClass) {
+    switch {
+    case exp == 0 && mant == 0:
+        return FloatZero
+    case exp == 0:
+        return FloatSubnormal
+    case exp != (uint64(1) << expBits) - 1:
+        return FloatNormal
+    case mant == 0:
+        return FloatInfinite
+    case mant >> (mantBits - 1) == 1:
+        return FloatQuietNaN
+    }
+    return FloatSignalingNaN
+}
+
+// ClassifyFloat32 return the IEEE-754 class of a float32
+func ClassifyFloat32(f float32) (FloatClass) {
+    _, exp, mant := DecomposeFloat32(f)
+    return classifyFloat(uint64(exp), uint64(mant), 8, 23)
+}
+
+// ClassifyFloat64 return the IEEE-754 class of a float64
+func ClassifyFloat64(f float64) (FloatClass) {
+    _, exp, mant := DecomposeFloat64(f)
+    return classifyFloat(exp, mant, 11, 52)
+}
+
+// NaNPayload32 return the payload of a float32 NaN, the mantissa without the quiet bit
+func NaNPayload32(f float32) (uint32, error) {
+    if c := ClassifyFloat32(f); c != FloatQuietNaN && c != FloatSignalingNaN {
+        return 0, fmt.Errorf("not a NaN(%v)", f)
+    }
+
+    _, _, mant := DecomposeFloat32(f)
+    return mant &^ (uint32(1) << 22), nil
+}
+
+// NaNPayload64 return the payload of a float64 NaN, the mantissa without the quiet bit
+func NaNPayload64(f float64) (uint64, error) {
+    if c := ClassifyFloat64(f); c != FloatQuietNaN && c != FloatSignalingNaN {
+        return 0, fmt.Errorf("not a NaN(%v)", f)
+    }
+
+    _, _, mant := DecomposeFloat64(f)
+    return mant &^ (uint64(1) << 51), nil
+}
+
+// MakeNaN32 build a positive float32 NaN with the given payload. A signaling NaN
+// needs a non-zero payload, otherwise it would be infinity
+func MakeNaN32(quiet bool, payload uint32) (float32, error) {
+    if payload >= uint32(1) << 22 || (!quiet && payload == 0) {
+        return 0, fmt.Errorf("invalid payload(%v)", payload)
+    }
+
+    if quiet {
+        payload |= uint32(1) << 22
+    }
+    return ComposeFloat32(0, 0xFF, payload)
+}
+
+// MakeNaN64 build a positive float64 NaN with the given payload. A signaling NaN
+// needs a non-zero payload, otherwise it would be infinity
+func MakeNaN64(quiet bool, payload uint64) (float64, error) {
+    if payload >= uint64(1) << 51 || (!quiet && payload == 0) {
+        return 0, fmt.Errorf("invalid payload(%v)", payload)
+    }
+
+    if quiet {
+        payload |= uint64(1) << 51
+    }
+    return ComposeFloat64(0, 0x7FF, payload)
+}
+
+// NextUp32 return the smallest float32 larger than f. NaN and +Inf are returned as is
+func NextUp32(f float32) (float32) {
+    b := math.Float32bits(f)
+    switch {
+    case f != f || b == 0x7F800000:
+        return f
+    case f == 0:
+        return math.Float32frombits(1)
+    case b >> 31 == 0:
+        return math.Float32frombits(b + 1)
+    }
+    return math.Float32frombits(b - 1)
+}
+
+// NextDown32 return the largest float32 smaller than f. NaN and -Inf are returned as is
+func NextDown32(f float32) (float32) {
+    return -NextUp32(-f)
+}
+
+// NextUp64 return the smallest float64 larger than f. NaN and +Inf are returned as is
+func NextUp64(f float64) (float64) {
+    b := math.Float64bits(f)
+    switch {
+    case f != f || b == 0x7FF0000000000000:
+        return f
+    case f == 0:
+        return math.Float64frombits(1)
+    case b >> 63 == 0:
+        return math.Float64frombits(b + 1)
+    }
+    return math.Float64frombits(b - 1)
+}
+
+// NextDown64 return the largest float64 smaller than f. NaN and -Inf are returned as is
+func NextDown64(f float64) (float64) {
+    return -NextUp64(-f)
+}
+
+// map the bits of a float to an integer which has the same order as the float
+func orderedFloat(b uint64, signBit uint) (int64) {
+    if b >> signBit != 0 {
+        return -int64(b &^ (uint64(1) << signBit))
+    }
+    return int64(b)
+}
+
+// ULPDistance32 return the number of float32 values between a and b, 0 and -0 are
+// the same value. It return error if either is NaN
+func ULPDistance32(a float32, b float32) (uint64, error) {
+    if a != a || b != b {
+        return 0, fmt.Errorf("NaN operand(%v, %v)", a, b)
+    }
+
+    ia := orderedFloat(uint64(math.Float32bits(a)), 31)
+    ib := orderedFloat(uint64(math.Float32bits(b)), 31)
+    if ia < ib {
+        ia, ib = ib, ia
+    }
+    return uint64(ia - ib), nil
+}
+
+// ULPDistance64 return the number of float64 values between a and b, 0 and -0 are
+// the same value. It return error if either is NaN
+func ULPDistance64(a float64, b float64) (uint64, error) {
+    if a != a || b != b {
+        return 0, fmt.Errorf("NaN operand(%v, %v)", a, b)
+    }
+
+    ia := orderedFloat(math.Float64bits(a), 63)
+    ib := orderedFloat(math.Float64bits(b), 63)
+    if ia < ib {
+        ia, ib = ib, ia
+    }
+    // the difference may not fit in int64 but it always fits in uint64
+    return uint64(ia) - uint64(ib), nil
+}
+
+// shift value right with round to nearest, ties to even
+func roundShiftRight(value uint64, shift uint) (uint64) {
+    if shift == 0 {
+        return value
+    }
+    if shift >= 64 {
+        return 0
+    }
+
+    ret := value >> shift
+    rem := value & ((uint64(1) << shift) - 1)
+    half := uint64(1) << (shift - 1)
+    if rem > half || (rem == half && ret & 1 == 1) {
+        ret++
+    }
+    return ret
+}
+
+// convert a binary floating-point encoding to a narrower one with round to nearest
+// even. Overflow become infinity and NaN keep the sign and the upper payload bits but
+// always become quiet
+func narrowFloat(b uint64, srcExp uint, srcMant uint, dstExp uint, dstMant uint) (uint64) {
+    sign := (b >> (srcExp + srcMant)) & 1
+    exp := (b >> srcMant) & ((uint64(1) << srcExp) - 1)
+    mant := b & ((uint64(1) << srcMant) - 1)
+    srcMax := (uint64(1) << srcExp) - 1
+    dstMax := (uint64(1) << dstExp) - 1
+    signed := sign << (dstExp + dstMant)
+
+    if exp == srcMax {
+        if mant == 0 {
+            return signed | dstMax << dstMant
+        }
+        payload := mant >> (srcMant - dstMant)
+        return signed | dstMax << dstMant | uint64(1) << (dstMant - 1) | payload
+    }
+    if exp == 0 && mant == 0 {
+        return signed
+    }
+
+    // value is mant * 2^(e - srcMant) with the implicit bit in mant
+    srcBias := int(uint64(1) << (srcExp - 1)) - 1
+    dstBias := int(uint64(1) << (dstExp - 1)) - 1
+    e := 1 - srcBias
+    if exp != 0 {
+        e = int(exp) - srcBias
+        mant |= uint64(1) << srcMant
+    } else {
+        // normalize the subnormal source so the target exponent is right
+        lz := CountLeadZero64(mant) - (64 - srcMant - 1)
+        mant <<= lz
+        e -= int(lz)
+    }
+
+    biased := e + dstBias
+    var ret uint64
+    if biased >= 1 {
+        // the implicit bit is added on top of biased-1 and a carry from rounding
+        // bump the exponent naturally
+        ret = uint64(biased - 1) << dstMant + roundShiftRight(mant, srcMant - dstMant)
+    } else {
+        ret = roundShiftRight(mant, srcMant - dstMant + uint(1 - biased))
+    }
+    if ret >= dstMax << dstMant {
+        return signed | dstMax << dstMant
+    }
+    return signed | ret
+}
+
+// convert a narrow binary floating-point encoding to float64 exactly
+func widenFloat(b uint64, srcExp uint, srcMant uint) (float64) {
+    sign := (b >> (srcExp + srcMant)) & 1
+    exp := (b >> srcMant) & ((uint64(1) << srcExp) - 1)
+    mant := b & ((uint64(1) << srcMant) - 1)
+    srcMax := (uint64(1) << srcExp) - 1
+    bias := int(uint64(1) << (srcExp - 1)) - 1
+
+    var ret float64
+    switch {
+    case exp == srcMax && mant == 0:
+        ret = math.Inf(1)
+    case exp == srcMax:
+        // keep the payload at the top of the float64 mantissa
+        ret = math.Float64frombits(uint64(0x7FF) << 52 | mant << (52 - srcMant))
+    case exp == 0:
+        ret = math.Ldexp(float64(mant), 1 - bias - int(srcMant))
+    default:
+        ret = math.Ldexp(float64(mant | uint64(1) << srcMant), int(exp) - bias - int(srcMant))
+    }
+    if sign == 1 {
+        return math.Float64frombits(math.Float64bits(ret) | uint64(1) << 63)
+    }
+    return ret
+}
+
+// Float32ToFloat16 convert f to IEEE-754 binary16 bits with round to nearest even
+func Float32ToFloat16(f float32) (uint16) {
+    return uint16(narrowFloat(uint64(math.Float32bits(f)), 8, 23, 5, 10))
+}
+
+// Float64ToFloat16 convert f to IEEE-754 binary16 bits with round to nearest even.
+// The conversion is done in one step so there is no double rounding through float32
+func Float64ToFloat16(f float64) (uint16) {
+    return uint16(narrowFloat(math.Float64bits(f), 11, 52, 5, 10))
+}
+
+// Float16ToFloat32 convert IEEE-754 binary16 bits to float32 exactly
+func Float16ToFloat32(h uint16) (float32) {
+    if b := uint64(h); (b >> 10) & 0x1F == 0x1F && b & 0x3FF != 0 {
+        // build the NaN directly so the payload survives
+        return math.Float32frombits(uint32(b >> 15) << 31 | 0x7F800000 | uint32(b & 0x3FF) << 13)
+    }
+    return float32(widenFloat(uint64(h), 5, 10))
+}
+
+// Float16ToFloat64 convert IEEE-754 binary16 bits to float64 exactly
+func Float16ToFloat64(h uint16) (float64) {
+    return widenFloat(uint64(h), 5, 10)
+}
+
+// Float32ToBFloat16 convert f to bfloat16 bits with round to nearest even
+func Float32ToBFloat16(f float32) (uint16) {
+    return uint16(narrowFloat(uint64(math.Float32bits(f)), 8, 23, 8, 7))
+}
+
+// Float64ToBFloat16 convert f to bfloat16 bits with round to nearest even in one step
+func Float64ToBFloat16(f float64) (uint16) {
+    return uint16(narrowFloat(math.Float64bits(f), 11, 52, 8, 7))
+}
+
+// BFloat16ToFloat32 convert bfloat16 bits to float32 exactly
+func BFloat16ToFloat32(h uint16) (float32) {
+    return math.Float32frombits(uint32(h) << 16)
+}
+
+// BFloat16ToFloat64 convert bfloat16 bits to float64 exactly
+func BFloat16ToFloat64(h uint16) (float64) {
+    return widenFloat(uint64(h), 8, 7)
+}
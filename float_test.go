@@ -0,0 +1,330 @@
+package bitops
+
+import (
+    "math"
+    "testing"
+)
+
+func TestDecomposeFloat32(t *testing.T) {
+    sign, exp, mant := DecomposeFloat32(-1.5)
+    if sign != 1 || exp != 127 || mant != 0x400000 {
+        t.Fail()
+        t.Logf("-1.5 get %d %d %x", sign, exp, mant)
+    }
+
+    f, err := ComposeFloat32(sign, exp, mant)
+    if err != nil || f != -1.5 {
+        t.Fail()
+        t.Logf("compose get %v", f)
+    }
+
+    if _, err = ComposeFloat32(0, 0x100, 0); err == nil {
+        t.Fail()
+        t.Log("invalid exponent")
+    }
+}
+
+func TestDecomposeFloat64(t *testing.T) {
+    sign, exp, mant := DecomposeFloat64(0.1)
+    if sign != 0 || exp != 1019 || mant != 0x999999999999A {
+        t.Fail()
+        t.Logf("0.1 get %d %d %x", sign, exp, mant)
+    }
+
+    f, err := ComposeFloat64(sign, exp, mant)
+    if err != nil || f != 0.1 {
+        t.Fail()
+        t.Logf("compose get %v", f)
+    }
+
+    if _, err = ComposeFloat64(2, 0, 0); err == nil {
+        t.Fail()
+        t.Log("invalid sign")
+    }
+    if _, err = ComposeFloat64(0, 0, 1 << 52); err == nil {
+        t.Fail()
+        t.Log("invalid mantissa")
+    }
+}
+
+func TestClassifyFloat32(t *testing.T) {
+    snan, _ := MakeNaN32(false, 1)
+    vectors := []struct {
+        f      float32
+        expect FloatClass
+    }{
+        {0, FloatZero},
+        {float32(math.Copysign(0, -1)), FloatZero},
+        {math.Float32frombits(1), FloatSubnormal},
+        {math.SmallestNonzeroFloat32, FloatSubnormal},
+        {1, FloatNormal},
+        {math.MaxFloat32, FloatNormal},
+        {float32(math.Inf(-1)), FloatInfinite},
+        {float32(math.NaN()), FloatQuietNaN},
+        {snan, FloatSignalingNaN},
+    }
+
+    for _, v := range vectors {
+        if c := ClassifyFloat32(v.f); c != v.expect {
+            t.Fail()
+            t.Logf("%x expect %v but get %v", math.Float32bits(v.f), v.expect, c)
+        }
+    }
+}
+
+func TestClassifyFloat64(t *testing.T) {
+    snan, _ := MakeNaN64(false, 1)
+    vectors := []struct {
+        f      float64
+        expect FloatClass
+    }{
+        {0, FloatZero},
+        {math.SmallestNonzeroFloat64, FloatSubnormal},
+        {math.Float64frombits(0x000FFFFFFFFFFFFF), FloatSubnormal},
+        {math.Float64frombits(0x0010000000000000), FloatNormal},
+        {math.Inf(1), FloatInfinite},
+        {math.NaN(), FloatQuietNaN},
+        {snan, FloatSignalingNaN},
+    }
+
+    for _, v := range vectors {
+        if c := ClassifyFloat64(v.f); c != v.expect {
+            t.Fail()
+            t.Logf("%x expect %v but get %v", math.Float64bits(v.f), v.expect, c)
+        }
+    }
+
+    if s := FloatSignalingNaN.String(); s != "signaling NaN" {
+        t.Fail()
+        t.Logf("get %s", s)
+    }
+}
+
+func TestNaNPayload32(t *testing.T) {
+    f, err := MakeNaN32(true, 0x1234)
+    if err != nil || ClassifyFloat32(f) != FloatQuietNaN {
+        t.Fail()
+        t.Log("make quiet NaN")
+    }
+    if payload, err := NaNPayload32(f); err != nil || payload != 0x1234 {
+        t.Fail()
+        t.Logf("expect payload 0x1234 but get %x", payload)
+    }
+
+    if _, err = MakeNaN32(false, 0); err == nil {
+        t.Fail()
+        t.Log("signaling NaN with zero payload")
+    }
+    if _, err = MakeNaN32(true, 1 << 22); err == nil {
+        t.Fail()
+        t.Log("payload too large")
+    }
+    if _, err = NaNPayload32(1); err == nil {
+        t.Fail()
+        t.Log("not a NaN")
+    }
+}
+
+func TestNaNPayload64(t *testing.T) {
+    f, err := MakeNaN64(false, 0xDEADBEEF)
+    if err != nil || ClassifyFloat64(f) != FloatSignalingNaN {
+        t.Fail()
+        t.Log("make signaling NaN")
+    }
+    if payload, err := NaNPayload64(f); err != nil || payload != 0xDEADBEEF {
+        t.Fail()
+        t.Logf("expect payload 0xDEADBEEF but get %x", payload)
+    }
+    if _, err = NaNPayload64(math.Inf(1)); err == nil {
+        t.Fail()
+        t.Log("not a NaN")
+    }
+}
+
+func TestNextUp32(t *testing.T) {
+    if f := NextUp32(1); math.Float32bits(f) != 0x3F800001 {
+        t.Fail()
+        t.Logf("next up of 1 get %x", math.Float32bits(f))
+    }
+    if f := NextDown32(1); math.Float32bits(f) != 0x3F7FFFFF {
+        t.Fail()
+        t.Logf("next down of 1 get %x", math.Float32bits(f))
+    }
+    if f := NextUp32(float32(math.Copysign(0, -1))); f != math.SmallestNonzeroFloat32 {
+        t.Fail()
+        t.Logf("next up of -0 get %v", f)
+    }
+    if f := NextUp32(-math.SmallestNonzeroFloat32); f != 0 {
+        t.Fail()
+        t.Logf("next up of -min get %v", f)
+    }
+    if f := NextUp32(math.MaxFloat32); !math.IsInf(float64(f), 1) {
+        t.Fail()
+        t.Logf("next up of max get %v", f)
+    }
+    if f := NextUp32(float32(math.Inf(1))); !math.IsInf(float64(f), 1) {
+        t.Fail()
+        t.Logf("next up of +Inf get %v", f)
+    }
+}
+
+func TestNextUp64(t *testing.T) {
+    for _, f := range []float64{1, -1, 0.1, -1e300, math.SmallestNonzeroFloat64, -math.MaxFloat64} {
+        if ret, expect := NextUp64(f), math.Nextafter(f, math.Inf(1)); ret != expect {
+            t.Fail()
+            t.Logf("next up of %v expect %v but get %v", f, expect, ret)
+        }
+        if ret, expect := NextDown64(f), math.Nextafter(f, math.Inf(-1)); ret != expect {
+            t.Fail()
+            t.Logf("next down of %v expect %v but get %v", f, expect, ret)
+        }
+    }
+    if f := NextDown64(math.Inf(-1)); !math.IsInf(f, -1) {
+        t.Fail()
+        t.Logf("next down of -Inf get %v", f)
+    }
+    if f := NextUp64(math.NaN()); f == f {
+        t.Fail()
+        t.Log("next up of NaN")
+    }
+}
+
+func TestULPDistance32(t *testing.T) {
+    if d, err := ULPDistance32(1, NextUp32(NextUp32(1))); err != nil || d != 2 {
+        t.Fail()
+        t.Logf("expect 2 but get %d", d)
+    }
+    if d, err := ULPDistance32(float32(math.Copysign(0, -1)), 0); err != nil || d != 0 {
+        t.Fail()
+        t.Logf("expect 0 between -0 and 0 but get %d", d)
+    }
+    if d, err := ULPDistance32(-math.SmallestNonzeroFloat32, math.SmallestNonzeroFloat32); err != nil || d != 2 {
+        t.Fail()
+        t.Logf("expect 2 across zero but get %d", d)
+    }
+    if _, err := ULPDistance32(float32(math.NaN()), 0); err == nil {
+        t.Fail()
+        t.Log("NaN operand")
+    }
+}
+
+func TestULPDistance64(t *testing.T) {
+    if d, err := ULPDistance64(math.MaxFloat64, math.Inf(1)); err != nil || d != 1 {
+        t.Fail()
+        t.Logf("expect 1 but get %d", d)
+    }
+    if d, err := ULPDistance64(math.Inf(-1), math.Inf(1)); err != nil || d != 0xFFE0000000000000 {
+        t.Fail()
+        t.Logf("expect %x from -Inf to +Inf but get %x", uint64(0xFFE0000000000000), d)
+    }
+    a, b := 0.1, 0.2
+    if d, err := ULPDistance64(a + b, 0.3); err != nil || d != 1 {
+        t.Fail()
+        t.Logf("expect 1 but get %d", d)
+    }
+    if _, err := ULPDistance64(0, math.NaN()); err == nil {
+        t.Fail()
+        t.Log("NaN operand")
+    }
+}
+
+func TestFloat16(t *testing.T) {
+    vectors := []struct {
+        f      float64
+        expect uint16
+    }{
+        {1, 0x3C00},
+        {-2, 0xC000},
+        {65504, 0x7BFF},
+        {65519.99, 0x7BFF},
+        {65520, 0x7C00},
+        {1e10, 0x7C00},
+        {1 + 1.0 / 2048, 0x3C00},
+        {1 + 3.0 / 2048, 0x3C02},
+        {math.Ldexp(1, -24), 0x0001},
+        {math.Ldexp(1, -25), 0x0000},
+        {math.Ldexp(1.5, -25), 0x0001},
+        {math.Ldexp(1023, -24), 0x03FF},
+        {math.Ldexp(2047, -25), 0x0400},
+        {math.Ldexp(1, -14), 0x0400},
+        {math.Copysign(0, -1), 0x8000},
+        {math.Inf(-1), 0xFC00},
+        {math.SmallestNonzeroFloat64, 0x0000},
+        //just above the tie, float32 would round it to the tie first
+        {1 + 1.0 / 2048 + math.Ldexp(1, -40), 0x3C01},
+    }
+
+    for _, v := range vectors {
+        if h := Float64ToFloat16(v.f); h != v.expect {
+            t.Fail()
+            t.Logf("Float64ToFloat16(%v) expect %x but get %x", v.f, v.expect, h)
+        }
+        if float64(float32(v.f)) == v.f {
+            if h := Float32ToFloat16(float32(v.f)); h != v.expect {
+                t.Fail()
+                t.Logf("Float32ToFloat16(%v) expect %x but get %x", v.f, v.expect, h)
+            }
+        }
+    }
+
+    if h := Float64ToFloat16(math.NaN()); ClassifyFloat32(Float16ToFloat32(h)) != FloatQuietNaN {
+        t.Fail()
+        t.Logf("NaN get %x", h)
+    }
+
+    //every binary16 value survive the round trip, signaling NaN become quiet
+    for i := 0; i < 1 << 16; i++ {
+        h := uint16(i)
+        expect := h
+        if h & 0x7C00 == 0x7C00 && h & 0x3FF != 0 {
+            expect |= 0x200
+        }
+        if ret := Float32ToFloat16(Float16ToFloat32(h)); ret != expect {
+            t.Fatalf("float32 round trip of %x get %x", h, ret)
+        }
+        if ret := Float64ToFloat16(Float16ToFloat64(h)); ret != expect {
+            t.Fatalf("float64 round trip of %x get %x", h, ret)
+        }
+    }
+}
+
+func TestBFloat16(t *testing.T) {
+    vectors := []struct {
+        bits   uint32
+        expect uint16
+    }{
+        {0x3F800000, 0x3F80},
+        {0x3F808000, 0x3F80},
+        {0x3F818000, 0x3F82},
+        {0x3F808001, 0x3F81},
+        {0x7F7FFFFF, 0x7F80},
+        {0x00010000, 0x0001},
+        {0x80008000, 0x8000},
+        {0xFF800000, 0xFF80},
+    }
+
+    for _, v := range vectors {
+        f := math.Float32frombits(v.bits)
+        if h := Float32ToBFloat16(f); h != v.expect {
+            t.Fail()
+            t.Logf("Float32ToBFloat16(%x) expect %x but get %x", v.bits, v.expect, h)
+        }
+        if h := Float64ToBFloat16(float64(f)); h != v.expect {
+            t.Fail()
+            t.Logf("Float64ToBFloat16(%x) expect %x but get %x", v.bits, v.expect, h)
+        }
+    }
+
+    for i := 0; i < 1 << 16; i++ {
+        h := uint16(i)
+        if h & 0x7F80 == 0x7F80 && h & 0x7F != 0 {
+            continue
+        }
+        if ret := Float32ToBFloat16(BFloat16ToFloat32(h)); ret != h {
+            t.Fatalf("float32 round trip of %x get %x", h, ret)
+        }
+        if ret := Float64ToBFloat16(BFloat16ToFloat64(h)); ret != h {
+            t.Fatalf("float64 round trip of %x get %x", h, ret)
+        }
+    }
+}
@@ -0,0 +1,889 @@
+package bitops
+
+import (
+    "bytes"
+    "iter"
+    "math/big"
+    "math/bits"
+    "strings"
+    "testing"
+)
+
+// naive bit-by-bit reference implementations used by the fuzz targets
+
+func naiveCountOne(value uint64, width uint) (uint) {
+    var count uint = 0
+    for i := uint(0); i < width; i++ {
+        count += uint(value >> i) & 1
+    }
+    return count
+}
+
+func naiveCountLeadZero(value uint64, width uint) (uint) {
+    var count uint = 0
+    for i := int(width) - 1; i >= 0 && (value >> uint(i)) & 1 == 0; i-- {
+        count++
+    }
+    return count
+}
+
+func naiveCountTrailZero(value uint64, width uint) (uint) {
+    var count uint = 0
+    for i := uint(0); i < width && (value >> i) & 1 == 0; i++ {
+        count++
+    }
+    return count
+}
+
+func naiveReverse(value uint64, width uint) (uint64) {
+    var ret uint64 = 0
+    for i := uint(0); i < width; i++ {
+        ret |= ((value >> i) & 1) << (width - 1 - i)
+    }
+    return ret
+}
+
+func naiveRotateLeft(value uint64, shift uint, width uint) (uint64) {
+    for i := uint(0); i < shift % width; i++ {
+        msb := (value >> (width - 1)) & 1
+        value = (value << 1) | msb
+        if width < 64 {
+            value &= (uint64(1) << width) - 1
+        }
+    }
+    return value
+}
+
+func naiveExtract(value uint64, start uint, length uint) (uint64) {
+    var ret uint64 = 0
+    for i := uint(0); i < length; i++ {
+        ret |= ((value >> (start + i)) & 1) << i
+    }
+    return ret
+}
+
+func naiveDeposit(value uint64, start uint, length uint, field uint64) (uint64) {
+    for i := uint(0); i < length; i++ {
+        pos := start + i
+        value = (value &^ (uint64(1) << pos)) | (((field >> i) & 1) << pos)
+    }
+    return value
+}
+
+func FuzzExtract32(f *testing.F) {
+    f.Add(uint32(0xF0F0F0F0), uint(4), uint(4))
+    f.Add(uint32(0xFFFFFFFF), uint(31), uint(2))
+    f.Add(uint32(0xFFFFFFFF), uint(1), ^uint(0))
+    f.Fuzz(func(t *testing.T, value uint32, start uint, length uint) {
+        ret, err := Extract32(value, start, length)
+        valid := start < 32 && length <= 32 - start
+        if valid != (err == nil) {
+            t.Fatalf("Extract32(%x, %d, %d) error %v", value, start, length, err)
+        }
+        if valid && uint64(ret) != naiveExtract(uint64(value), start, length) {
+            t.Fatalf("Extract32(%x, %d, %d) = %x", value, start, length, ret)
+        }
+        if !valid && ret != value {
+            t.Fatalf("Extract32(%x, %d, %d) modify value on error", value, start, length)
+        }
+    })
+}
+
+func FuzzExtract64(f *testing.F) {
+    f.Add(uint64(0xF0F0F0F0F0F0F0F0), uint(12), uint(4))
+    f.Add(uint64(0xFFFFFFFFFFFFFFFF), uint(63), uint(2))
+    f.Add(uint64(0xFFFFFFFFFFFFFFFF), uint(1), ^uint(0))
+    f.Fuzz(func(t *testing.T, value uint64, start uint, length uint) {
+        ret, err := Extract64(value, start, length)
+        valid := start < 64 && length <= 64 - start
+        if valid != (err == nil) {
+            t.Fatalf("Extract64(%x, %d, %d) error %v", value, start, length, err)
+        }
+        if valid && ret != naiveExtract(value, start, length) {
+            t.Fatalf("Extract64(%x, %d, %d) = %x", value, start, length, ret)
+        }
+    })
+}
+
+func FuzzGetField32(f *testing.F) {
+    f.Add(uint32(0xF0F0F0F0), uint(7), uint(4))
+    f.Fuzz(func(t *testing.T, value uint32, high uint, low uint) {
+        ret, err := GetField32(value, high, low)
+        valid := high < 32 && low <= high
+        if valid != (err == nil) {
+            t.Fatalf("GetField32(%x, %d, %d) error %v", value, high, low, err)
+        }
+        if valid && uint64(ret) != naiveExtract(uint64(value), low, high - low + 1) {
+            t.Fatalf("GetField32(%x, %d, %d) = %x", value, high, low, ret)
+        }
+    })
+}
+
+func FuzzGetField64(f *testing.F) {
+    f.Add(uint64(0xF0F0F0F0F0F0F0F0), uint(63), uint(0))
+    f.Fuzz(func(t *testing.T, value uint64, high uint, low uint) {
+        ret, err := GetField64(value, high, low)
+        valid := high < 64 && low <= high
+        if valid != (err == nil) {
+            t.Fatalf("GetField64(%x, %d, %d) error %v", value, high, low, err)
+        }
+        if valid && ret != naiveExtract(value, low, high - low + 1) {
+            t.Fatalf("GetField64(%x, %d, %d) = %x", value, high, low, ret)
+        }
+    })
+}
+
+func FuzzDeposit32(f *testing.F) {
+    f.Add(uint32(0), uint(4), uint(4), uint32(0xFF))
+    f.Add(uint32(0), uint(1), ^uint(0), uint32(0xFF))
+    f.Fuzz(func(t *testing.T, value uint32, start uint, length uint, field uint32) {
+        ret, err := Deposit32(value, start, length, field)
+        valid := start < 32 && length <= 32 - start
+        if valid != (err == nil) {
+            t.Fatalf("Deposit32(%x, %d, %d, %x) error %v", value, start, length, field, err)
+        }
+        if valid && uint64(ret) != naiveDeposit(uint64(value), start, length, uint64(field)) {
+            t.Fatalf("Deposit32(%x, %d, %d, %x) = %x", value, start, length, field, ret)
+        }
+        if !valid && ret != value {
+            t.Fatalf("Deposit32(%x, %d, %d, %x) modify value on error", value, start, length, field)
+        }
+    })
+}
+
+func FuzzDeposit64(f *testing.F) {
+    f.Add(uint64(0), uint(60), uint(4), uint64(0xFF))
+    f.Add(uint64(0), uint(1), ^uint(0), uint64(0xFF))
+    f.Fuzz(func(t *testing.T, value uint64, start uint, length uint, field uint64) {
+        ret, err := Deposit64(value, start, length, field)
+        valid := start < 64 && length <= 64 - start
+        if valid != (err == nil) {
+            t.Fatalf("Deposit64(%x, %d, %d, %x) error %v", value, start, length, field, err)
+        }
+        if valid && ret != naiveDeposit(value, start, length, field) {
+            t.Fatalf("Deposit64(%x, %d, %d, %x) = %x", value, start, length, field, ret)
+        }
+    })
+}
+
+func FuzzSetField32(f *testing.F) {
+    f.Add(uint32(0xFFFFFFFF), uint(31), uint(16), uint32(0))
+    f.Fuzz(func(t *testing.T, value uint32, high uint, low uint, field uint32) {
+        ret, err := SetField32(value, high, low, field)
+        valid := high < 32 && low <= high
+        if valid != (err == nil) {
+            t.Fatalf("SetField32(%x, %d, %d, %x) error %v", value, high, low, field, err)
+        }
+        if valid && uint64(ret) != naiveDeposit(uint64(value), low, high - low + 1, uint64(field)) {
+            t.Fatalf("SetField32(%x, %d, %d, %x) = %x", value, high, low, field, ret)
+        }
+    })
+}
+
+func FuzzSetField64(f *testing.F) {
+    f.Add(uint64(0xFFFFFFFFFFFFFFFF), uint(63), uint(0), uint64(0))
+    f.Fuzz(func(t *testing.T, value uint64, high uint, low uint, field uint64) {
+        ret, err := SetField64(value, high, low, field)
+        valid := high < 64 && low <= high
+        if valid != (err == nil) {
+            t.Fatalf("SetField64(%x, %d, %d, %x) error %v", value, high, low, field, err)
+        }
+        if valid && ret != naiveDeposit(value, low, high - low + 1, field) {
+            t.Fatalf("SetField64(%x, %d, %d, %x) = %x", value, high, low, field, ret)
+        }
+    })
+}
+
+func FuzzCountOne(f *testing.F) {
+    f.Add(uint64(0xF0F0F0F0F0F0F0F0))
+    f.Fuzz(func(t *testing.T, value uint64) {
+        if ret := CountOne8(uint8(value)); ret != uint(bits.OnesCount8(uint8(value))) || ret != naiveCountOne(value, 8) {
+            t.Fatalf("CountOne8(%x) = %d", uint8(value), ret)
+        }
+        if ret := CountOne16(uint16(value)); ret != uint(bits.OnesCount16(uint16(value))) || ret != naiveCountOne(value, 16) {
+            t.Fatalf("CountOne16(%x) = %d", uint16(value), ret)
+        }
+        if ret := CountOne32(uint32(value)); ret != uint(bits.OnesCount32(uint32(value))) || ret != naiveCountOne(value, 32) {
+            t.Fatalf("CountOne32(%x) = %d", uint32(value), ret)
+        }
+        if ret := CountOne64(value); ret != uint(bits.OnesCount64(value)) || ret != naiveCountOne(value, 64) {
+            t.Fatalf("CountOne64(%x) = %d", value, ret)
+        }
+    })
+}
+
+func FuzzCountZero(f *testing.F) {
+    f.Add(uint64(0xF0F0F0F0F0F0F0F0))
+    f.Fuzz(func(t *testing.T, value uint64) {
+        if ret := CountZero8(uint8(value)); ret != 8 - naiveCountOne(value, 8) {
+            t.Fatalf("CountZero8(%x) = %d", uint8(value), ret)
+        }
+        if ret := CountZero16(uint16(value)); ret != 16 - naiveCountOne(value, 16) {
+            t.Fatalf("CountZero16(%x) = %d", uint16(value), ret)
+        }
+        if ret := CountZero32(uint32(value)); ret != 32 - naiveCountOne(value, 32) {
+            t.Fatalf("CountZero32(%x) = %d", uint32(value), ret)
+        }
+        if ret := CountZero64(value); ret != 64 - naiveCountOne(value, 64) {
+            t.Fatalf("CountZero64(%x) = %d", value, ret)
+        }
+    })
+}
+
+func FuzzCountLeadZero32(f *testing.F) {
+    f.Add(uint32(0x0FFFFFFF))
+    f.Fuzz(func(t *testing.T, value uint32) {
+        if ret := CountLeadZero32(value); ret != uint(bits.LeadingZeros32(value)) || ret != naiveCountLeadZero(uint64(value), 32) {
+            t.Fatalf("CountLeadZero32(%x) = %d", value, ret)
+        }
+        if ret := CountLeadOne32(value); ret != uint(bits.LeadingZeros32(^value)) {
+            t.Fatalf("CountLeadOne32(%x) = %d", value, ret)
+        }
+    })
+}
+
+func FuzzCountLeadZero64(f *testing.F) {
+    f.Add(uint64(0x0FFFFFFFFFFFFFFF))
+    f.Fuzz(func(t *testing.T, value uint64) {
+        if ret := CountLeadZero64(value); ret != uint(bits.LeadingZeros64(value)) || ret != naiveCountLeadZero(value, 64) {
+            t.Fatalf("CountLeadZero64(%x) = %d", value, ret)
+        }
+        if ret := CountLeadOne64(value); ret != uint(bits.LeadingZeros64(^value)) {
+            t.Fatalf("CountLeadOne64(%x) = %d", value, ret)
+        }
+    })
+}
+
+func FuzzCountTrailZero32(f *testing.F) {
+    f.Add(uint32(0xFFFFFFF0))
+    f.Fuzz(func(t *testing.T, value uint32) {
+        if ret := CountTrailZero32(value); ret != uint(bits.TrailingZeros32(value)) || ret != naiveCountTrailZero(uint64(value), 32) {
+            t.Fatalf("CountTrailZero32(%x) = %d", value, ret)
+        }
+        if ret := CountTrailOne32(value); ret != uint(bits.TrailingZeros32(^value)) {
+            t.Fatalf("CountTrailOne32(%x) = %d", value, ret)
+        }
+    })
+}
+
+func FuzzCountTrailZero64(f *testing.F) {
+    f.Add(uint64(0xFFFFFFFFFFFFFFF0))
+    f.Fuzz(func(t *testing.T, value uint64) {
+        if ret := CountTrailZero64(value); ret != uint(bits.TrailingZeros64(value)) || ret != naiveCountTrailZero(value, 64) {
+            t.Fatalf("CountTrailZero64(%x) = %d", value, ret)
+        }
+        if ret := CountTrailOne64(value); ret != uint(bits.TrailingZeros64(^value)) {
+            t.Fatalf("CountTrailOne64(%x) = %d", value, ret)
+        }
+    })
+}
+
+func FuzzBit32(f *testing.F) {
+    f.Add(uint32(0), uint(31))
+    f.Fuzz(func(t *testing.T, value uint32, pos uint) {
+        set, errSet := SetBit32(value, pos)
+        clear, errClear := ClearBit32(value, pos)
+        toggle, errToggle := ToggleBit32(value, pos)
+        test, errTest := TestBit32(value, pos)
+
+        if pos >= 32 {
+            if errSet == nil || errClear == nil || errToggle == nil || errTest == nil {
+                t.Fatalf("position %d should fail", pos)
+            }
+            if set != value || clear != value || toggle != value {
+                t.Fatalf("value modified on error")
+            }
+            return
+        }
+        mask := uint32(1) << pos
+        if set != value | mask || clear != value &^ mask || toggle != value ^ mask || test != (value & mask != 0) {
+            t.Fatalf("bit %d of %x: set %x clear %x toggle %x test %v", pos, value, set, clear, toggle, test)
+        }
+    })
+}
+
+func FuzzBit64(f *testing.F) {
+    f.Add(uint64(0), uint(63))
+    f.Fuzz(func(t *testing.T, value uint64, pos uint) {
+        set, errSet := SetBit64(value, pos)
+        clear, errClear := ClearBit64(value, pos)
+        toggle, errToggle := ToggleBit64(value, pos)
+        test, errTest := TestBit64(value, pos)
+
+        if pos >= 64 {
+            if errSet == nil || errClear == nil || errToggle == nil || errTest == nil {
+                t.Fatalf("position %d should fail", pos)
+            }
+            return
+        }
+        mask := uint64(1) << pos
+        if set != value | mask || clear != value &^ mask || toggle != value ^ mask || test != (value & mask != 0) {
+            t.Fatalf("bit %d of %x: set %x clear %x toggle %x test %v", pos, value, set, clear, toggle, test)
+        }
+    })
+}
+
+func FuzzReverse(f *testing.F) {
+    f.Add(uint64(0x0123456789ABCDEF))
+    f.Fuzz(func(t *testing.T, value uint64) {
+        if ret := Reverse32(uint32(value)); ret != bits.Reverse32(uint32(value)) || uint64(ret) != naiveReverse(value, 32) {
+            t.Fatalf("Reverse32(%x) = %x", uint32(value), ret)
+        }
+        if ret := Reverse64(value); ret != bits.Reverse64(value) || ret != naiveReverse(value, 64) {
+            t.Fatalf("Reverse64(%x) = %x", value, ret)
+        }
+    })
+}
+
+func FuzzRotate32(f *testing.F) {
+    f.Add(uint32(0x01234567), uint(16))
+    f.Fuzz(func(t *testing.T, value uint32, shift uint) {
+        if ret := RotateLeft32(value, shift); ret != bits.RotateLeft32(value, int(shift % 32)) || uint64(ret) != naiveRotateLeft(uint64(value), shift, 32) {
+            t.Fatalf("RotateLeft32(%x, %d) = %x", value, shift, ret)
+        }
+        if ret := RotateRight32(value, shift); ret != bits.RotateLeft32(value, -int(shift % 32)) {
+            t.Fatalf("RotateRight32(%x, %d) = %x", value, shift, ret)
+        }
+    })
+}
+
+func FuzzRotate64(f *testing.F) {
+    f.Add(uint64(0x0123456789ABCDEF), uint(16))
+    f.Fuzz(func(t *testing.T, value uint64, shift uint) {
+        if ret := RotateLeft64(value, shift); ret != bits.RotateLeft64(value, int(shift % 64)) || ret != naiveRotateLeft(value, shift, 64) {
+            t.Fatalf("RotateLeft64(%x, %d) = %x", value, shift, ret)
+        }
+        if ret := RotateRight64(value, shift); ret != bits.RotateLeft64(value, -int(shift % 64)) {
+            t.Fatalf("RotateRight64(%x, %d) = %x", value, shift, ret)
+        }
+    })
+}
+
+func FuzzIterOne64(f *testing.F) {
+    f.Add(uint64(0x8000000100000001))
+    f.Fuzz(func(t *testing.T, value uint64) {
+        var forward, reverse uint64
+        var prev uint = 64
+        for pos := range IterOne64(value) {
+            if prev != 64 && pos <= prev {
+                t.Fatalf("IterOne64(%x) not ascending", value)
+            }
+            forward |= uint64(1) << pos
+            prev = pos
+        }
+        for pos := range IterOneReverse64(value) {
+            reverse |= uint64(1) << pos
+        }
+
+        var runs uint64
+        for start, length := range IterRun64(value) {
+            field, _ := Deposit64(0, start, length, ^uint64(0))
+            runs |= field
+        }
+
+        dst := make([]uint8, 64)
+        count, err := SetPositions64(value, dst)
+        if forward != value || reverse != value || runs != value || err != nil || count != naiveCountOne(value, 64) {
+            t.Fatalf("iterate %x: forward %x reverse %x runs %x count %d", value, forward, reverse, runs, count)
+        }
+    })
+}
+
+func FuzzIterOneNarrow(f *testing.F) {
+    f.Add(uint64(0x80018001))
+    f.Fuzz(func(t *testing.T, value uint64) {
+        seqs := []struct {
+            width   uint
+            forward iter.Seq[uint]
+            reverse iter.Seq[uint]
+            set     func(dst []uint8) (uint, error)
+        }{
+            {8, IterOne8(uint8(value)), IterOneReverse8(uint8(value)), func(dst []uint8) (uint, error) { return SetPositions8(uint8(value), dst) }},
+            {16, IterOne16(uint16(value)), IterOneReverse16(uint16(value)), func(dst []uint8) (uint, error) { return SetPositions16(uint16(value), dst) }},
+            {32, IterOne32(uint32(value)), IterOneReverse32(uint32(value)), func(dst []uint8) (uint, error) { return SetPositions32(uint32(value), dst) }},
+        }
+        for _, s := range seqs {
+            expect := value & (uint64(1) << s.width - 1)
+            var positions []uint
+            for pos := range IterOne64(expect) {
+                positions = append(positions, pos)
+            }
+
+            //a sequence must give the same positions every time it is ranged
+            for pass := 0; pass < 2; pass++ {
+                var forward []uint
+                for pos := range s.forward {
+                    forward = append(forward, pos)
+                }
+                var reverse uint64
+                for pos := range s.reverse {
+                    reverse |= uint64(1) << pos
+                }
+                if len(forward) != len(positions) || reverse != expect {
+                    t.Fatalf("IterOne%d(%x) pass %d get %v reverse %x", s.width, expect, pass, forward, reverse)
+                }
+                for i := range forward {
+                    if forward[i] != positions[i] {
+                        t.Fatalf("IterOne%d(%x) pass %d get %v", s.width, expect, pass, forward)
+                    }
+                }
+            }
+
+            dst := make([]uint8, s.width)
+            count, err := s.set(dst)
+            if err != nil || count != naiveCountOne(expect, 64) {
+                t.Fatalf("SetPositions%d(%x) get %d %v", s.width, expect, count, err)
+            }
+            for i := uint(0); i < count; i++ {
+                if uint(dst[i]) != positions[i] {
+                    t.Fatalf("SetPositions%d(%x) get %v", s.width, expect, dst[:count])
+                }
+            }
+        }
+    })
+}
+
+func FuzzUint128(f *testing.F) {
+    f.Add(uint64(0x0123456789ABCDEF), uint64(0xFEDCBA9876543210), uint64(0), uint64(10), uint(3))
+    f.Add(uint64(1), uint64(1), uint64(0), uint64(1), uint(1 << 40))
+    f.Fuzz(func(t *testing.T, ahi, alo, bhi, blo uint64, shift uint) {
+        //shifts past 128 are still covered, a huge one would only blow up the big.Int
+        shift %= 256
+        a, b := NewUint128(ahi, alo), NewUint128(bhi, blo)
+        ba, bb := new(big.Int), new(big.Int)
+        ba.SetString(a.Text(16), 16)
+        bb.SetString(b.Text(16), 16)
+        mod := new(big.Int).Lsh(big.NewInt(1), 128)
+
+        check := func(name string, ret Uint128, expect *big.Int) {
+            expect.Mod(expect, mod)
+            if ret.Text(10) != expect.String() {
+                t.Fatalf("%s(%v, %v) = %v expect %s", name, a, b, ret, expect.Text(16))
+            }
+        }
+        check("Add", a.Add(b), new(big.Int).Add(ba, bb))
+        check("Sub", a.Sub(b), new(big.Int).Sub(ba, bb))
+        check("Mul", a.Mul(b), new(big.Int).Mul(ba, bb))
+        check("Lsh", a.Lsh(shift), new(big.Int).Lsh(ba, shift))
+        check("Rsh", a.Rsh(shift), new(big.Int).Rsh(ba, shift))
+        if !b.IsZero() {
+            quo, rem, err := a.QuoRem(b)
+            if err != nil {
+                t.Fatal(err)
+            }
+            bq, br := new(big.Int).QuoRem(ba, bb, new(big.Int))
+            check("Quo", quo, bq)
+            check("Rem", rem, br)
+        }
+        if a.Cmp(b) != ba.Cmp(bb) {
+            t.Fatalf("Cmp(%v, %v) = %d", a, b, a.Cmp(b))
+        }
+
+        if ret, err := ParseUint128(a.Text(2), 2); err != nil || ret != a {
+            t.Fatalf("ParseUint128 binary round trip of %v", a)
+        }
+        if ret := Reverse128(Reverse128(a)); ret != a {
+            t.Fatalf("Reverse128 twice of %v", a)
+        }
+        if ret := RotateRight128(RotateLeft128(a, shift), shift); ret != a {
+            t.Fatalf("Rotate128 of %v by %d", a, shift)
+        }
+        if CountOne128(a) != naiveCountOne(ahi, 64) + naiveCountOne(alo, 64) {
+            t.Fatalf("CountOne128(%v)", a)
+        }
+        if CountLeadZero128(a) != uint(128 - ba.BitLen()) {
+            t.Fatalf("CountLeadZero128(%v) = %d", a, CountLeadZero128(a))
+        }
+    })
+}
+
+func FuzzUint128Fields(f *testing.F) {
+    f.Add(uint64(0x0123456789ABCDEF), uint64(0xFEDCBA9876543210), uint64(0), uint64(0x55), uint(60), uint(8))
+    f.Add(uint64(0), uint64(0), ^uint64(0), ^uint64(0), uint(0), uint(128))
+    f.Fuzz(func(t *testing.T, ahi, alo, bhi, blo uint64, p uint, q uint) {
+        a, b := NewUint128(ahi, alo), NewUint128(bhi, blo)
+        ba, bb := new(big.Int), new(big.Int)
+        ba.SetString(a.Text(16), 16)
+        bb.SetString(b.Text(16), 16)
+        ones := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+        mask := func(start uint, length uint) (*big.Int) {
+            m := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), length), big.NewInt(1))
+            return m.Lsh(m, start)
+        }
+
+        check := func(name string, ret Uint128, expect *big.Int) {
+            if ret.Text(10) != expect.String() {
+                t.Fatalf("%s(%v, %v, %d, %d) = %v expect %s", name, a, b, p, q, ret, expect.Text(16))
+            }
+        }
+        check("And", a.And(b), new(big.Int).And(ba, bb))
+        check("Or", a.Or(b), new(big.Int).Or(ba, bb))
+        check("Xor", a.Xor(b), new(big.Int).Xor(ba, bb))
+        check("AndNot", a.AndNot(b), new(big.Int).AndNot(ba, bb))
+        check("Not", a.Not(), new(big.Int).Xor(ba, ones))
+        check("RotateLeft128", RotateLeft128(a, p), new(big.Int).Or(
+            new(big.Int).And(new(big.Int).Lsh(ba, p % 128), ones), new(big.Int).Rsh(ba, 128 - p % 128)))
+        if ret := Reverse128(a); ret != NewUint128(naiveReverse(alo, 64), naiveReverse(ahi, 64)) {
+            t.Fatalf("Reverse128(%v) = %v", a, ret)
+        }
+
+        //Extract128/Deposit128 take start and length, GetField128/SetField128 high and low
+        ret, err := Extract128(a, p, q)
+        if valid := p < 128 && q <= 128 - p; valid != (err == nil) {
+            t.Fatalf("Extract128(%v, %d, %d) error %v", a, p, q, err)
+        } else if valid {
+            check("Extract128", ret, new(big.Int).Rsh(new(big.Int).And(ba, mask(p, q)), p))
+        }
+        ret, err = Deposit128(a, p, q, b)
+        if valid := p < 128 && q <= 128 - p; valid != (err == nil) {
+            t.Fatalf("Deposit128(%v, %d, %d) error %v", a, p, q, err)
+        } else if valid {
+            field := new(big.Int).And(new(big.Int).Lsh(bb, p), mask(p, q))
+            check("Deposit128", ret, new(big.Int).Or(new(big.Int).AndNot(ba, mask(p, q)), field))
+        }
+        ret, err = GetField128(a, p, q)
+        if valid := p < 128 && q <= p; valid != (err == nil) {
+            t.Fatalf("GetField128(%v, %d, %d) error %v", a, p, q, err)
+        } else if valid {
+            check("GetField128", ret, new(big.Int).Rsh(new(big.Int).And(ba, mask(q, p - q + 1)), q))
+        }
+        ret, err = SetField128(a, p, q, b)
+        if valid := p < 128 && q <= p; valid != (err == nil) {
+            t.Fatalf("SetField128(%v, %d, %d) error %v", a, p, q, err)
+        } else if valid {
+            field := new(big.Int).And(new(big.Int).Lsh(bb, q), mask(q, p - q + 1))
+            check("SetField128", ret, new(big.Int).Or(new(big.Int).AndNot(ba, mask(q, p - q + 1)), field))
+        }
+
+        set, errSet := SetBit128(a, p)
+        clear, errClear := ClearBit128(a, p)
+        toggle, errToggle := ToggleBit128(a, p)
+        bit, errTest := TestBit128(a, p)
+        if p >= 128 {
+            if errSet == nil || errClear == nil || errToggle == nil || errTest == nil || set != a || clear != a || toggle != a {
+                t.Fatalf("bit %d of %v accepted", p, a)
+            }
+        } else {
+            check("SetBit128", set, new(big.Int).SetBit(ba, int(p), 1))
+            check("ClearBit128", clear, new(big.Int).SetBit(ba, int(p), 0))
+            check("ToggleBit128", toggle, new(big.Int).SetBit(ba, int(p), ba.Bit(int(p)) ^ 1))
+            if bit != (ba.Bit(int(p)) == 1) {
+                t.Fatalf("TestBit128(%v, %d) = %v", a, p, bit)
+            }
+        }
+
+        trail := uint(128)
+        if ba.Sign() != 0 {
+            trail = ba.TrailingZeroBits()
+        }
+        notA := new(big.Int).Xor(ba, ones)
+        trailOne := uint(128)
+        if notA.Sign() != 0 {
+            trailOne = notA.TrailingZeroBits()
+        }
+        if CountTrailZero128(a) != trail || CountTrailOne128(a) != trailOne ||
+            CountLeadOne128(a) != uint(128 - notA.BitLen()) || CountZero128(a) != 128 - CountOne128(a) {
+            t.Fatalf("counts of %v get %d %d %d %d", a, CountTrailZero128(a), CountTrailOne128(a), CountLeadOne128(a), CountZero128(a))
+        }
+    })
+}
+
+func FuzzParseBitVector(f *testing.F) {
+    f.Add("12'h3FF")
+    f.Add("5'b1_0x01")
+    f.Add("70'd1180591620717411303423")
+    f.Fuzz(func(t *testing.T, s string) {
+        v, err := ParseBitVector(s)
+        if err != nil {
+            return
+        }
+        if v.Width() > 4096 {
+            return
+        }
+        for _, base := range []int{2, 8, 16} {
+            //a partially unknown digit is printed as X and read back as all x
+            if strings.ContainsRune(v.Text(base), 'X') {
+                continue
+            }
+            back, err := ParseBitVector(v.Text(base))
+            if err != nil || !back.Equal(v) {
+                t.Fatalf("%q does not round trip through %s (%v)", s, v.Text(base), err)
+            }
+        }
+        if !v.IsUnknown() {
+            back, err := ParseBitVector(v.Text(10))
+            if err != nil || !back.Equal(v) {
+                t.Fatalf("%q does not round trip through %s (%v)", s, v.Text(10), err)
+            }
+        }
+    })
+}
+
+// value of a known BitVector as a big.Int, nil if any bit is x
+func bitVectorBig(v BitVector) (*big.Int) {
+    ret := new(big.Int)
+    for i := len(v.val) - 1; i >= 0; i-- {
+        if v.xz[i] != 0 {
+            return nil
+        }
+        ret.Lsh(ret, 64).Or(ret, new(big.Int).SetUint64(v.val[i]))
+    }
+    return ret
+}
+
+func FuzzBitVector(f *testing.F) {
+    f.Add(uint64(0xFFFFFFFFFFFFFFFF), uint64(0x8000000000000001), uint64(3), uint64(0), uint8(65), uint8(7), uint8(1))
+    f.Add(uint64(1), uint64(0), uint64(0), uint64(0), uint8(0), uint8(0), uint8(5))
+    f.Fuzz(func(t *testing.T, a0, a1, b0, b1 uint64, wa uint8, wb uint8, shift uint8) {
+        //width 0 is the zero value which every operation must accept
+        vector := func(width uint, words []uint64) (BitVector) {
+            if width == 0 {
+                return BitVector{}
+            }
+            v, _ := NewBitVectorWords(width, words)
+            return v
+        }
+        widthA, widthB, s := uint(wa) % 193, uint(wb) % 193, uint(shift)
+        a := vector(widthA, []uint64{a0, a1, a0 ^ a1})
+        b := vector(widthB, []uint64{b0, b1, b0 ^ b1})
+        ba, bb := bitVectorBig(a), bitVectorBig(b)
+        width := max(widthA, widthB)
+        mask := func(width uint) (*big.Int) {
+            return new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), width), big.NewInt(1))
+        }
+
+        check := func(name string, ret BitVector, width uint, expect *big.Int) {
+            expect.And(expect, mask(width))
+            if got := bitVectorBig(ret); ret.Width() != width || got == nil || got.Cmp(expect) != 0 {
+                t.Fatalf("%s(%v, %v, %d) = %v expect %d'h%s", name, a, b, s, ret, width, expect.Text(16))
+            }
+        }
+        check("Add", a.Add(b), width, new(big.Int).Add(ba, bb))
+        check("Sub", a.Sub(b), width, new(big.Int).Sub(ba, bb))
+        check("Mul", a.Mul(b), width, new(big.Int).Mul(ba, bb))
+        check("Neg", a.Neg(), widthA, new(big.Int).Neg(ba))
+        check("And", a.And(b), width, new(big.Int).And(ba, bb))
+        check("Or", a.Or(b), width, new(big.Int).Or(ba, bb))
+        check("Xor", a.Xor(b), width, new(big.Int).Xor(ba, bb))
+        check("Not", a.Not(), widthA, new(big.Int).Xor(ba, mask(widthA)))
+        check("Lsh", a.Lsh(s), widthA, new(big.Int).Lsh(ba, s))
+        check("Rsh", a.Rsh(s), widthA, new(big.Int).Rsh(ba, s))
+
+        signed := new(big.Int).Set(ba)
+        if widthA > 0 && ba.Bit(int(widthA) - 1) == 1 {
+            signed.Sub(signed, new(big.Int).Lsh(big.NewInt(1), widthA))
+        }
+        check("Ashr", a.Ashr(s), widthA, signed.Rsh(signed, s))
+
+        left, right := new(big.Int), new(big.Int)
+        if widthA > 0 {
+            r := s % widthA
+            left.Or(new(big.Int).Lsh(ba, r), new(big.Int).Rsh(ba, widthA - r))
+            right.Or(new(big.Int).Rsh(ba, r), new(big.Int).Lsh(ba, widthA - r))
+        }
+        check("RotateLeft", a.RotateLeft(s), widthA, left)
+        check("RotateRight", a.RotateRight(s), widthA, right)
+    })
+}
+
+func FuzzPortable(f *testing.F) {
+    f.Add(uint64(0x0123456789ABCDEF), uint(7))
+    f.Fuzz(func(t *testing.T, value uint64, shift uint) {
+        if portableCountOne8(uint8(value)) != CountOne8(uint8(value)) ||
+            portableCountOne16(uint16(value)) != CountOne16(uint16(value)) ||
+            portableCountOne32(uint32(value)) != CountOne32(uint32(value)) ||
+            portableCountOne64(value) != CountOne64(value) {
+            t.Fatalf("portable CountOne of %x", value)
+        }
+        if portableCountTrailZero32(uint32(value)) != CountTrailZero32(uint32(value)) ||
+            portableCountTrailZero64(value) != CountTrailZero64(value) ||
+            portableCountLeadZero32(uint32(value)) != CountLeadZero32(uint32(value)) ||
+            portableCountLeadZero64(value) != CountLeadZero64(value) {
+            t.Fatalf("portable leading/trailing zero of %x", value)
+        }
+        if portableReverse32(uint32(value)) != Reverse32(uint32(value)) || portableReverse64(value) != Reverse64(value) {
+            t.Fatalf("portable Reverse of %x", value)
+        }
+        if portableRotateLeft32(uint32(value), shift) != RotateLeft32(uint32(value), shift) ||
+            portableRotateRight32(uint32(value), shift) != RotateRight32(uint32(value), shift) ||
+            portableRotateLeft64(value, shift) != RotateLeft64(value, shift) ||
+            portableRotateRight64(value, shift) != RotateRight64(value, shift) {
+            t.Fatalf("portable Rotate of %x by %d", value, shift)
+        }
+    })
+}
+
+func FuzzVarint(f *testing.F) {
+    f.Add(uint64(624485), []byte{0xE5, 0x8E, 0x26})
+    f.Add(uint64(0x7FFFFFFF), []byte{0xC0, 0x80})
+    f.Fuzz(func(t *testing.T, value uint64, src []byte) {
+        if ret, n, err := DecodeULEB128(AppendULEB128(nil, value), 64); err != nil || ret != value || n == 0 {
+            t.Fatalf("ULEB128 round trip of %x get %x %v", value, ret, err)
+        }
+        if ret, _, err := DecodeSLEB128(AppendSLEB128(nil, int64(value)), 64); err != nil || ret != int64(value) {
+            t.Fatalf("SLEB128 round trip of %x get %x %v", value, ret, err)
+        }
+        if enc, err := AppendQUICVarint(nil, value >> 2); err != nil {
+            t.Fatal(err)
+        } else if ret, _, err := DecodeQUICVarint(enc); err != nil || ret != value >> 2 {
+            t.Fatalf("QUIC round trip of %x get %x %v", value >> 2, ret, err)
+        }
+
+        //whatever decode successfully must encode back to the same value
+        if ret, n, err := DecodeULEB128(src, 32); err == nil && (ret > 0xFFFFFFFF || n > 5) {
+            t.Fatalf("ULEB128 u32 of % x get %x in %d bytes", src, ret, n)
+        }
+        if ret, n, err := DecodeSLEB128(src, 32); err == nil && (ret != int64(int32(ret)) || n > 5) {
+            t.Fatalf("SLEB128 s32 of % x get %x in %d bytes", src, ret, n)
+        }
+        if ret, n, err := DecodeUTF8Varint(src); err == nil {
+            enc, _ := AppendUTF8Varint(nil, ret)
+            if !bytes.Equal(enc, src[:n]) {
+                t.Fatalf("UTF-8 of % x get %x", src[:n], ret)
+            }
+        }
+    })
+}
+
+func FuzzAtomic(f *testing.F) {
+    f.Add(uint64(0x0123456789ABCDEF), uint64(0xFF), uint(63), uint(8), uint16(130))
+    f.Add(uint64(0), ^uint64(0), uint(64), uint(0), uint16(0))
+    f.Fuzz(func(t *testing.T, value uint64, field uint64, p uint, q uint, length uint16) {
+        //every helper must act as the plain operation on a private word
+        bit64 := uint64(1) << (p & 63)
+        w64 := value
+        err := AtomicSetBit64(&w64, p)
+        if (err == nil) != (p < 64) || (err == nil && w64 != value | bit64) || (err != nil && w64 != value) {
+            t.Fatalf("AtomicSetBit64(%x, %d) get %x %v", value, p, w64, err)
+        }
+        w64 = value
+        err = AtomicClearBit64(&w64, p)
+        if (err == nil) != (p < 64) || (err == nil && w64 != value &^ bit64) || (err != nil && w64 != value) {
+            t.Fatalf("AtomicClearBit64(%x, %d) get %x %v", value, p, w64, err)
+        }
+        w64 = value
+        err = AtomicToggleBit64(&w64, p)
+        if (err == nil) != (p < 64) || (err == nil && w64 != value ^ bit64) || (err != nil && w64 != value) {
+            t.Fatalf("AtomicToggleBit64(%x, %d) get %x %v", value, p, w64, err)
+        }
+        w64 = value
+        if ret, err := AtomicTestBit64(&w64, p); (err == nil) != (p < 64) || (err == nil && ret != (value & bit64 != 0)) {
+            t.Fatalf("AtomicTestBit64(%x, %d) get %v %v", value, p, ret, err)
+        }
+        if ret, err := AtomicTestAndSet64(&w64, p); err == nil && (ret != (value & bit64 != 0) || w64 != value | bit64) {
+            t.Fatalf("AtomicTestAndSet64(%x, %d) get %v %x", value, p, ret, w64)
+        }
+        w64 = value
+        if ret, err := AtomicTestAndClear64(&w64, p); err == nil && (ret != (value & bit64 != 0) || w64 != value &^ bit64) {
+            t.Fatalf("AtomicTestAndClear64(%x, %d) get %v %x", value, p, ret, w64)
+        }
+
+        bit32 := uint32(1) << (p & 31)
+        v32 := uint32(value)
+        w32 := v32
+        err = AtomicSetBit32(&w32, p)
+        if (err == nil) != (p < 32) || (err == nil && w32 != v32 | bit32) || (err != nil && w32 != v32) {
+            t.Fatalf("AtomicSetBit32(%x, %d) get %x %v", v32, p, w32, err)
+        }
+        w32 = v32
+        err = AtomicClearBit32(&w32, p)
+        if (err == nil) != (p < 32) || (err == nil && w32 != v32 &^ bit32) || (err != nil && w32 != v32) {
+            t.Fatalf("AtomicClearBit32(%x, %d) get %x %v", v32, p, w32, err)
+        }
+        w32 = v32
+        err = AtomicToggleBit32(&w32, p)
+        if (err == nil) != (p < 32) || (err == nil && w32 != v32 ^ bit32) || (err != nil && w32 != v32) {
+            t.Fatalf("AtomicToggleBit32(%x, %d) get %x %v", v32, p, w32, err)
+        }
+        w32 = v32
+        if ret, err := AtomicTestBit32(&w32, p); (err == nil) != (p < 32) || (err == nil && ret != (v32 & bit32 != 0)) {
+            t.Fatalf("AtomicTestBit32(%x, %d) get %v %v", v32, p, ret, err)
+        }
+        if ret, err := AtomicTestAndSet32(&w32, p); err == nil && (ret != (v32 & bit32 != 0) || w32 != v32 | bit32) {
+            t.Fatalf("AtomicTestAndSet32(%x, %d) get %v %x", v32, p, ret, w32)
+        }
+        w32 = v32
+        if ret, err := AtomicTestAndClear32(&w32, p); err == nil && (ret != (v32 & bit32 != 0) || w32 != v32 &^ bit32) {
+            t.Fatalf("AtomicTestAndClear32(%x, %d) get %v %x", v32, p, ret, w32)
+        }
+
+        //the field helpers return the previous word and match their plain version
+        w64 = value
+        expect64, expectErr := Deposit64(value, p, q, field)
+        if old, err := AtomicDeposit64(&w64, p, q, field); old != value || w64 != expect64 || (err == nil) != (expectErr == nil) {
+            t.Fatalf("AtomicDeposit64(%x, %d, %d, %x) get %x %x %v", value, p, q, field, old, w64, err)
+        }
+        w64 = value
+        expect64, expectErr = SetField64(value, p, q, field)
+        if old, err := AtomicSetField64(&w64, p, q, field); old != value || w64 != expect64 || (err == nil) != (expectErr == nil) {
+            t.Fatalf("AtomicSetField64(%x, %d, %d, %x) get %x %x %v", value, p, q, field, old, w64, err)
+        }
+        w32 = v32
+        expect32, expectErr := Deposit32(v32, p, q, uint32(field))
+        if old, err := AtomicDeposit32(&w32, p, q, uint32(field)); old != v32 || w32 != expect32 || (err == nil) != (expectErr == nil) {
+            t.Fatalf("AtomicDeposit32(%x, %d, %d, %x) get %x %x %v", v32, p, q, uint32(field), old, w32, err)
+        }
+        w32 = v32
+        expect32, expectErr = SetField32(v32, p, q, uint32(field))
+        if old, err := AtomicSetField32(&w32, p, q, uint32(field)); old != v32 || w32 != expect32 || (err == nil) != (expectErr == nil) {
+            t.Fatalf("AtomicSetField32(%x, %d, %d, %x) get %x %x %v", v32, p, q, uint32(field), old, w32, err)
+        }
+
+        //ConcurrentBitset against a bool slice, bit i taken from bit i%64 of the words
+        n := uint(length) % 300
+        a, b := NewConcurrentBitset(n), NewConcurrentBitset(n)
+        ref := make([]bool, n)
+        var count uint
+        for i := uint(0); i < n; i++ {
+            ref[i] = value >> (i % 64) & 1 == 1
+            if ref[i] {
+                a.Set(i)
+                count++
+            }
+            if field >> (i % 64) & 1 == 1 {
+                b.Set(i)
+            }
+        }
+        if a.Len() != n || a.Count() != count {
+            t.Fatalf("bitset of %d bits from %x get length %d count %d", n, value, a.Len(), a.Count())
+        }
+        if err := a.Set(n); err == nil {
+            t.Fatalf("bitset of %d bits accept bit %d", n, n)
+        }
+        if n > 0 {
+            pos := p % n
+            if old, err := a.TestAndClear(pos); err != nil || old != ref[pos] {
+                t.Fatalf("TestAndClear(%d) of %x get %v %v", pos, value, old, err)
+            }
+            if old, err := a.TestAndSet(pos); err != nil || old {
+                t.Fatalf("TestAndSet(%d) of %x get %v %v", pos, value, old, err)
+            }
+            if err := a.Clear(pos); err != nil {
+                t.Fatal(err)
+            }
+            ref[pos] = false
+        }
+
+        or, and := NewConcurrentBitset(n), NewConcurrentBitset(n)
+        or.Or(a)
+        and.Or(a)
+        if err := or.Or(b); err != nil {
+            t.Fatal(err)
+        }
+        if err := and.And(b); err != nil {
+            t.Fatal(err)
+        }
+        for i := uint(0); i < n; i++ {
+            other := field >> (i % 64) & 1 == 1
+            got, _ := a.Test(i)
+            gotOr, _ := or.Test(i)
+            gotAnd, _ := and.Test(i)
+            if got != ref[i] || gotOr != (ref[i] || other) || gotAnd != (ref[i] && other) {
+                t.Fatalf("bit %d of %x and %x get %v or %v and %v", i, value, field, got, gotOr, gotAnd)
+            }
+        }
+        if err := a.Or(NewConcurrentBitset(n + 1)); err == nil {
+            t.Fatalf("Or of %d and %d bits accepted", n, n + 1)
+        }
+    })
+}
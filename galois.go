@@ -0,0 +1,131 @@
+package bitops
+
+import "fmt"
+
+const (
+    // PolyGF256 is x^8+x^4+x^3+x^2+1, the primitive polynomial of QR codes and most
+    // Reed-Solomon codes
+    PolyGF256 = 0x11D
+    // PolyGF65536 is x^16+x^12+x^3+x+1, a primitive polynomial of GF(2^16)
+    PolyGF65536 = 0x1100B
+)
+
+// GaloisField is the finite field GF(2^m) for m from 2 to 16 built from a
+// primitive polynomial. Elements are uint16 below Size, addition is XOR and
+// multiplication go through exp/log tables of the generator x. Methods taking
+// elements expect them below Size
+type GaloisField struct {
+    bits uint
+    poly uint32
+    //exp is two periods long so exp[log a + log b] need no modulo
+    exp  []uint16
+    log  []uint16
+}
+
+// NewGaloisField create GF(2^bits) with the primitive polynomial poly including its
+// x^bits term, such as PolyGF256 for bits 8. The error is returned if bits is out
+// of range or poly is not primitive
+func NewGaloisField(bits uint, poly uint32) (*GaloisField, error) {
+    if bits < 2 || bits > 16 || poly >> bits != 1 {
+        return nil, fmt.Errorf("invalid bits(%v) or polynomial(%#x)", bits, poly)
+    }
+
+    order := (1 << bits) - 1
+    f := &GaloisField{bits: bits, poly: poly, exp: make([]uint16, 2 * order), log: make([]uint16, order + 1)}
+    x := uint32(1)
+    for i := 0; i < order; i++ {
+        //x must visit every non-zero element once before coming back to 1
+        if x == 0 || (i > 0 && x == 1) {
+            return nil, fmt.Errorf("invalid polynomial(%#x), not primitive", poly)
+        }
+        f.exp[i] = uint16(x)
+        f.log[x] = uint16(i)
+        x <<= 1
+        if x >> bits != 0 {
+            x ^= poly
+        }
+    }
+    if x != 1 {
+        return nil, fmt.Errorf("invalid polynomial(%#x), not primitive", poly)
+    }
+    copy(f.exp[order:], f.exp[:order])
+    return f, nil
+}
+
+// Bits return m of GF(2^m)
+func (f *GaloisField) Bits() (uint) {
+    return f.bits
+}
+
+// Size return the number of elements 2^m
+func (f *GaloisField) Size() (uint) {
+    return 1 << f.bits
+}
+
+// Poly return the primitive polynomial of the field
+func (f *GaloisField) Poly() (uint32) {
+    return f.poly
+}
+
+// Add return a + b which is also a - b
+func (f *GaloisField) Add(a uint16, b uint16) (uint16) {
+    return a ^ b
+}
+
+// Mul return a * b
+func (f *GaloisField) Mul(a uint16, b uint16) (uint16) {
+    if a == 0 || b == 0 {
+        return 0
+    }
+    return f.exp[uint(f.log[a]) + uint(f.log[b])]
+}
+
+// Div return a / b, the error is returned if b is 0
+func (f *GaloisField) Div(a uint16, b uint16) (uint16, error) {
+    if b == 0 {
+        return 0, fmt.Errorf("division by zero")
+    }
+    if a == 0 {
+        return 0, nil
+    }
+    order := uint(len(f.log)) - 1
+    return f.exp[uint(f.log[a]) + order - uint(f.log[b])], nil
+}
+
+// Inverse return 1 / a, the error is returned if a is 0
+func (f *GaloisField) Inverse(a uint16) (uint16, error) {
+    return f.Div(1, a)
+}
+
+// Exp return the generator x to the power n, n may be negative
+func (f *GaloisField) Exp(n int) (uint16) {
+    order := len(f.log) - 1
+    n %= order
+    if n < 0 {
+        n += order
+    }
+    return f.exp[n]
+}
+
+// Log return n with Exp(n) equal to a, from 0 to Size-2. The error is returned if
+// a is 0
+func (f *GaloisField) Log(a uint16) (uint, error) {
+    if a == 0 {
+        return 0, fmt.Errorf("invalid log(0)")
+    }
+    return uint(f.log[a]), nil
+}
+
+// Pow return a to the power n, the error is returned for 0 to a negative power
+func (f *GaloisField) Pow(a uint16, n int) (uint16, error) {
+    switch {
+    case n == 0:
+        return 1, nil
+    case a == 0 && n < 0:
+        return 0, fmt.Errorf("invalid power(%v) of 0", n)
+    case a == 0:
+        return 0, nil
+    }
+    order := len(f.log) - 1
+    return f.Exp(int(f.log[a]) * (n % order)), nil
+}
@@ -0,0 +1,108 @@
+package bitops
+
+import "testing"
+
+func TestGaloisField(t *testing.T) {
+    f, err := NewGaloisField(8, PolyGF256)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if f.Size() != 256 || f.Bits() != 8 || f.Poly() != PolyGF256 {
+        t.Fatalf("field get %d %d %x", f.Size(), f.Bits(), f.Poly())
+    }
+
+    //antilog table of QR codes (ISO/IEC 18004)
+    vectors := []struct {
+        n     int
+        value uint16
+    }{{0, 1}, {1, 2}, {7, 128}, {8, 29}, {25, 3}, {100, 17}, {254, 142}, {255, 1}, {-1, 142}}
+    for _, v := range vectors {
+        if ret := f.Exp(v.n); ret != v.value {
+            t.Fail()
+            t.Logf("exp %d expect %d but get %d", v.n, v.value, ret)
+        }
+    }
+
+    //table multiplication agree with the carry-less product reduced
+    for a := uint16(0); a < 256; a++ {
+        for b := uint16(0); b < 256; b++ {
+            expect, _ := PolyMulMod64(uint64(a), uint64(b), PolyGF256)
+            if p := f.Mul(a, b); uint64(p) != expect {
+                t.Fatalf("%d x %d expect %d but get %d", a, b, expect, p)
+            }
+        }
+        if a == 0 {
+            continue
+        }
+        inv, err := f.Inverse(a)
+        if err != nil || f.Mul(a, inv) != 1 {
+            t.Fatalf("inverse of %d get %d %v", a, inv, err)
+        }
+        if n, _ := f.Log(a); f.Exp(int(n)) != a {
+            t.Fatalf("log of %d get %d", a, n)
+        }
+        if q, _ := f.Div(f.Mul(a, 77), a); q != 77 {
+            t.Fatalf("77 x %d / %d get %d", a, a, q)
+        }
+    }
+
+    if p, _ := f.Pow(3, 5); p != f.Mul(f.Mul(f.Mul(3, 3), f.Mul(3, 3)), 3) {
+        t.Fail()
+        t.Logf("3^5 get %d", p)
+    }
+    if p, _ := f.Pow(3, -1); p != 244 || f.Mul(p, 3) != 1 {
+        t.Fail()
+        t.Logf("3^-1 get %d", p)
+    }
+    if _, err = f.Inverse(0); err == nil {
+        t.Fail()
+        t.Log("inverse of 0")
+    }
+    if _, err = f.Log(0); err == nil {
+        t.Fail()
+        t.Log("log of 0")
+    }
+    if _, err = f.Pow(0, -2); err == nil {
+        t.Fail()
+        t.Log("0 to a negative power")
+    }
+}
+
+func TestGaloisField16(t *testing.T) {
+    f, err := NewGaloisField(16, PolyGF65536)
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, a := range []uint16{1, 2, 0x1234, 0x8000, 0xFFFF} {
+        for _, b := range []uint16{3, 0x100, 0xABCD, 0xFFFF} {
+            expect, _ := PolyMulMod64(uint64(a), uint64(b), PolyGF65536)
+            if p := f.Mul(a, b); uint64(p) != expect {
+                t.Fatalf("%x x %x expect %x but get %x", a, b, expect, p)
+            }
+        }
+        if inv, _ := f.Inverse(a); f.Mul(a, inv) != 1 {
+            t.Fatalf("inverse of %x", a)
+        }
+    }
+    if f.Exp(65535) != 1 || f.Exp(16) != uint16(PolyGF65536 & 0xFFFF) {
+        t.Fail()
+        t.Log("exp of GF(2^16)")
+    }
+
+    //the AES polynomial is irreducible but x is not a generator, x^8+1 is reducible
+    for _, poly := range []uint32{0x11B, 0x101, 0x100, 0x1D} {
+        if _, err = NewGaloisField(8, poly); err == nil {
+            t.Fail()
+            t.Logf("polynomial %x should be rejected", poly)
+        }
+    }
+    if _, err = NewGaloisField(17, 0x20009); err == nil {
+        t.Fail()
+        t.Log("17 bits")
+    }
+    //GF(4) with x^2+x+1
+    if g, err := NewGaloisField(2, 7); err != nil || g.Mul(2, 3) != 1 {
+        t.Fail()
+        t.Logf("GF(4) get %v", err)
+    }
+}
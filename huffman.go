@@ -0,0 +1,316 @@
+package bitops
+
+import (
+    "cmp"
+    "fmt"
+    "slices"
+)
+
+const (
+    // longest code supported, the decoding table hold 2^length entries
+    maxHuffmanLength = 16
+    // largest alphabet supported
+    maxHuffmanSymbols = 1 << 16
+)
+
+// HuffmanCode is a canonical prefix code, shorter codes sort before longer ones
+// and codes of the same length follow the symbol order, so the code lengths alone
+// describe the code as in DEFLATE and JPEG
+type HuffmanCode struct {
+    lengths []uint8
+    codes   []uint32
+    maxLen  uint
+    // entry for every maxLen-bit prefix, symbol << 5 | length and 0 if unused
+    table []uint32
+}
+
+// optimal code lengths no longer than limit by the package-merge algorithm
+func huffmanLengths(freqs []uint64, limit uint) ([]uint8, error) {
+    type item struct {
+        weight uint64
+        symbol int
+        left   int
+        right  int
+    }
+
+    var items []item
+    var total uint64
+    for symbol, freq := range freqs {
+        if freq == 0 {
+            continue
+        }
+        if total + freq < total {
+            return nil, fmt.Errorf("frequency sum overflow")
+        }
+        total += freq
+        items = append(items, item{weight: freq, symbol: symbol, left: -1, right: -1})
+    }
+
+    lengths := make([]uint8, len(freqs))
+    n := len(items)
+    switch {
+    case n == 0:
+        return nil, fmt.Errorf("no symbol with frequency")
+    case n == 1:
+        lengths[items[0].symbol] = 1
+        return lengths, nil
+    case n > 1 << limit:
+        return nil, fmt.Errorf("invalid length limit(%v) for %v symbols", limit, n)
+    }
+
+    slices.SortStableFunc(items, func(a, b item) (int) {
+        return cmp.Compare(a.weight, b.weight)
+    })
+    leaves := make([]int, n)
+    for i := range leaves {
+        leaves[i] = i
+    }
+
+    // each round package adjacent pairs of the list and merge the packages
+    // with the leaves, packages are nodes of the arena pointing to their pair
+    list := leaves
+    for round := uint(1); round < limit; round++ {
+        merged := make([]int, 0, n + len(list) / 2)
+        next := 0
+        for j := 0; j + 1 < len(list); j += 2 {
+            pkg := item{weight: items[list[j]].weight + items[list[j + 1]].weight,
+                        symbol: -1, left: list[j], right: list[j + 1]}
+            for next < n && items[leaves[next]].weight <= pkg.weight {
+                merged = append(merged, leaves[next])
+                next++
+            }
+            items = append(items, pkg)
+            merged = append(merged, len(items) - 1)
+        }
+        list = append(merged, leaves[next:]...)
+    }
+
+    // every appearance of a leaf in the first 2n - 2 items add 1 to its length
+    stack := slices.Clone(list[:2 * n - 2])
+    for len(stack) > 0 {
+        it := items[stack[len(stack) - 1]]
+        stack = stack[:len(stack) - 1]
+        if it.symbol >= 0 {
+            lengths[it.symbol]++
+        } else {
+            stack = append(stack, it.left, it.right)
+        }
+    }
+    return lengths, nil
+}
+
+// NewHuffmanCode build the optimal canonical code for symbol frequencies with no
+// code longer than maxLength bits, symbols of frequency 0 get no code
+func NewHuffmanCode(freqs []uint64, maxLength uint) (*HuffmanCode, error) {
+    if maxLength == 0 || maxLength > maxHuffmanLength {
+        return nil, fmt.Errorf("invalid max length(%v)", maxLength)
+    }
+    if len(freqs) > maxHuffmanSymbols {
+        return nil, fmt.Errorf("invalid number of symbols(%v)", len(freqs))
+    }
+
+    lengths, err := huffmanLengths(freqs, maxLength)
+    if err != nil {
+        return nil, err
+    }
+    return NewHuffmanCodeFromLengths(lengths)
+}
+
+// NewHuffmanCodeFromLengths build the canonical code of the given code lengths, 0
+// means the symbol has no code. It return error if the lengths are oversubscribed
+func NewHuffmanCodeFromLengths(lengths []uint8) (*HuffmanCode, error) {
+    if len(lengths) > maxHuffmanSymbols {
+        return nil, fmt.Errorf("invalid number of symbols(%v)", len(lengths))
+    }
+
+    var count [maxHuffmanLength + 1]uint32
+    maxLen := uint(0)
+    for symbol, length := range lengths {
+        if length > maxHuffmanLength {
+            return nil, fmt.Errorf("invalid length(%v) of symbol(%v)", length, symbol)
+        }
+        count[length]++
+        maxLen = max(maxLen, uint(length))
+    }
+    if maxLen == 0 {
+        return nil, fmt.Errorf("no symbol with code")
+    }
+
+    // first code of every length, as in RFC 1951 3.2.2
+    var next [maxHuffmanLength + 1]uint32
+    code := uint32(0)
+    count[0] = 0
+    for length := uint(1); length <= maxLen; length++ {
+        code = (code + count[length - 1]) << 1
+        next[length] = code
+        if code + count[length] > uint32(1) << length {
+            return nil, fmt.Errorf("oversubscribed code lengths")
+        }
+    }
+
+    c := &HuffmanCode{lengths: slices.Clone(lengths), codes: make([]uint32, len(lengths)),
+                      maxLen: maxLen, table: make([]uint32, 1 << maxLen)}
+    for symbol, length := range lengths {
+        if length == 0 {
+            continue
+        }
+        c.codes[symbol] = next[length]
+        next[length]++
+
+        first := c.codes[symbol] << (maxLen - uint(length))
+        last := first + uint32(1) << (maxLen - uint(length))
+        for i := first; i < last; i++ {
+            c.table[i] = uint32(symbol) << 5 | uint32(length)
+        }
+    }
+    return c, nil
+}
+
+// Lengths return the code length of every symbol
+func (c *HuffmanCode) Lengths() ([]uint8) {
+    return slices.Clone(c.lengths)
+}
+
+// MaxLength return the length of the longest code
+func (c *HuffmanCode) MaxLength() (uint) {
+    return c.maxLen
+}
+
+// Code return the code of symbol, MSB first, and its length
+func (c *HuffmanCode) Code(symbol uint) (uint32, uint, error) {
+    if symbol >= uint(len(c.lengths)) || c.lengths[symbol] == 0 {
+        return 0, 0, fmt.Errorf("invalid symbol(%v)", symbol)
+    }
+
+    return c.codes[symbol], uint(c.lengths[symbol]), nil
+}
+
+// ReversedCode return the code of symbol with its bits reversed, for LSB-first
+// bitstreams such as DEFLATE where the first code bit is the lowest bit
+func (c *HuffmanCode) ReversedCode(symbol uint) (uint32, uint, error) {
+    code, length, err := c.Code(symbol)
+    if err != nil {
+        return 0, 0, err
+    }
+
+    return Reverse32(code) >> (32 - length), length, nil
+}
+
+// LookupReversed decode the symbol at the low bits of an LSB-first bit buffer and
+// return it with the number of bits to consume. It return ErrMalformed if the bits
+// do not start with a code
+func (c *HuffmanCode) LookupReversed(bits uint32) (uint, uint, error) {
+    entry := c.table[Reverse32(bits) >> (32 - c.maxLen)]
+    if entry == 0 {
+        return 0, 0, ErrMalformed
+    }
+
+    return uint(entry >> 5), uint(entry & 0x1F), nil
+}
+
+// WriteSymbol write the code of symbol
+func (c *HuffmanCode) WriteSymbol(w *BitWriter, symbol uint) (error) {
+    code, length, err := c.Code(symbol)
+    if err != nil {
+        return err
+    }
+
+    return w.WriteBits(uint64(code), length)
+}
+
+// ReadSymbol decode one symbol with a single table lookup. It return ErrMalformed
+// if the bits do not start with a code and consume nothing if error occurs
+func (c *HuffmanCode) ReadSymbol(r *BitReader) (uint, error) {
+    avail := uint(min(r.Remaining(), uint64(c.maxLen)))
+    bits, _ := r.PeekBits(avail)
+    entry := c.table[bits << (c.maxLen - avail)]
+    if entry == 0 {
+        return 0, ErrMalformed
+    }
+    if entry & 0x1F > uint32(avail) {
+        return 0, ErrTruncated
+    }
+
+    r.pos += uint64(entry & 0x1F)
+    return uint(entry >> 5), nil
+}
+
+// Encode write the codes of symbols, nothing is written if a symbol has no code
+func (c *HuffmanCode) Encode(w *BitWriter, symbols []uint) (error) {
+    for _, symbol := range symbols {
+        if _, _, err := c.Code(symbol); err != nil {
+            return err
+        }
+    }
+
+    for _, symbol := range symbols {
+        c.WriteSymbol(w, symbol)
+    }
+    return nil
+}
+
+// Decode read count symbols, nothing is consumed if error occurs. The error is
+// returned for a negative count
+func (c *HuffmanCode) Decode(r *BitReader, count int) ([]uint, error) {
+    if count < 0 {
+        return nil, fmt.Errorf("invalid count(%v)", count)
+    }
+    pos := r.pos
+    //every code is at least 1 bit, do not trust count beyond the input
+    symbols := make([]uint, 0, min(uint64(count), r.Remaining()))
+    for range count {
+        symbol, err := c.ReadSymbol(r)
+        if err != nil {
+            r.pos = pos
+            return nil, err
+        }
+        symbols = append(symbols, symbol)
+    }
+    return symbols, nil
+}
+
+// WriteTable write the code lengths, the number of symbols as exp-Golomb followed
+// by the difference of every length to the previous one as signed exp-Golomb
+func (c *HuffmanCode) WriteTable(w *BitWriter) {
+    w.WriteExpGolomb(uint64(len(c.lengths)), 0)
+    prev := int64(0)
+    for _, length := range c.lengths {
+        w.WriteSignedExpGolomb(int64(length) - prev, 0)
+        prev = int64(length)
+    }
+}
+
+// ReadHuffmanTable read code lengths written by WriteTable and build the code.
+// Nothing is consumed if error occurs
+func ReadHuffmanTable(r *BitReader) (*HuffmanCode, error) {
+    pos := r.pos
+    c, err := readHuffmanTable(r)
+    r.restore(pos, err)
+    return c, err
+}
+
+// real implementation for ReadHuffmanTable, the position is restored by the caller
+func readHuffmanTable(r *BitReader) (*HuffmanCode, error) {
+    count, err := r.ReadExpGolomb(0)
+    if err != nil {
+        return nil, err
+    }
+    if count > maxHuffmanSymbols {
+        return nil, fmt.Errorf("invalid number of symbols(%v)", count)
+    }
+
+    lengths := make([]uint8, count)
+    prev := int64(0)
+    for i := range lengths {
+        delta, err := r.ReadSignedExpGolomb(0)
+        if err != nil {
+            return nil, err
+        }
+        if delta < -prev || delta > maxHuffmanLength - prev {
+            return nil, fmt.Errorf("invalid length(%v) of symbol(%v)", prev + delta, i)
+        }
+        prev += delta
+        lengths[i] = uint8(prev)
+    }
+    return NewHuffmanCodeFromLengths(lengths)
+}
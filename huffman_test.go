@@ -0,0 +1,226 @@
+package bitops
+
+import (
+    "errors"
+    "math"
+    "slices"
+    "testing"
+)
+
+// total encoded bits of freqs with lengths
+func huffmanCost(freqs []uint64, lengths []uint8) (uint64) {
+    cost := uint64(0)
+    for i, freq := range freqs {
+        cost += freq * uint64(lengths[i])
+    }
+    return cost
+}
+
+func TestHuffmanFixedDeflate(t *testing.T) {
+    //fixed literal/length code of RFC 1951 3.2.6
+    lengths := make([]uint8, 288)
+    for i := range lengths {
+        switch {
+        case i < 144:
+            lengths[i] = 8
+        case i < 256:
+            lengths[i] = 9
+        case i < 280:
+            lengths[i] = 7
+        default:
+            lengths[i] = 8
+        }
+    }
+
+    c, err := NewHuffmanCodeFromLengths(lengths)
+    if err != nil {
+        t.Fatal(err)
+    }
+    vectors := []struct {
+        symbol uint
+        code   uint32
+        length uint
+    }{
+        {0, 0x30, 8}, {143, 0xBF, 8}, {144, 0x190, 9}, {255, 0x1FF, 9},
+        {256, 0x00, 7}, {279, 0x17, 7}, {280, 0xC0, 8}, {287, 0xC7, 8},
+    }
+    for _, v := range vectors {
+        if code, length, err := c.Code(v.symbol); err != nil || code != v.code || length != v.length {
+            t.Fail()
+            t.Logf("symbol %d expect %x/%d but get %x/%d", v.symbol, v.code, v.length, code, length)
+        }
+        rev, length, _ := c.ReversedCode(v.symbol)
+        if rev != Reverse32(v.code) >> (32 - v.length) {
+            t.Fail()
+            t.Logf("reversed code of %d get %x", v.symbol, rev)
+        }
+        //the reversed code followed by unrelated higher bits
+        if symbol, n, err := c.LookupReversed(rev | 0xFFFFFE00); err != nil || symbol != v.symbol || n != length {
+            t.Fail()
+            t.Logf("lookup reversed %x get %d/%d %v", rev, symbol, n, err)
+        }
+    }
+}
+
+func TestHuffmanLengths(t *testing.T) {
+    //unlimited optimal lengths are 1, 2, 3, 4, 5, 5
+    freqs := []uint64{1, 1, 2, 3, 5, 8}
+    c, err := NewHuffmanCode(freqs, 16)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if ret := c.Lengths(); !slices.Equal(ret, []uint8{5, 5, 4, 3, 2, 1}) {
+        t.Fail()
+        t.Logf("unlimited get %v", ret)
+    }
+
+    //limited to 3 bits the best cost is 1*3 + 1*3 + 2*3 + 3*3 + 5*2 + 8*2 = 47
+    c, err = NewHuffmanCode(freqs, 3)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if ret := c.Lengths(); c.MaxLength() != 3 || huffmanCost(freqs, ret) != 47 {
+        t.Fail()
+        t.Logf("limited get %v", ret)
+    }
+
+    if _, err = NewHuffmanCode(freqs, 2); err == nil {
+        t.Fail()
+        t.Log("6 symbols do not fit 2 bits")
+    }
+
+    //a single symbol still need one bit, unused symbols get no code
+    c, err = NewHuffmanCode([]uint64{0, 7, 0}, 8)
+    if err != nil || !slices.Equal(c.Lengths(), []uint8{0, 1, 0}) {
+        t.Fail()
+        t.Logf("single symbol get %v %v", c, err)
+    }
+    if _, _, err = c.Code(0); err == nil {
+        t.Fail()
+        t.Log("symbol without code")
+    }
+
+    if _, err = NewHuffmanCode([]uint64{0, 0}, 8); err == nil {
+        t.Fail()
+        t.Log("no frequency")
+    }
+    if _, err = NewHuffmanCode(freqs, 17); err == nil {
+        t.Fail()
+        t.Log("max length 17")
+    }
+    if _, err = NewHuffmanCodeFromLengths([]uint8{1, 1, 1}); err == nil {
+        t.Fail()
+        t.Log("oversubscribed")
+    }
+}
+
+func TestHuffmanOptimal(t *testing.T) {
+    //the limited lengths never cost more than any other complete code within the
+    //limit, compare with the Kraft-valid flat code and the unlimited code
+    freqs := make([]uint64, 200)
+    seed := uint64(1)
+    for i := range freqs {
+        seed = seed * 6364136223846793005 + 1442695040888963407
+        freqs[i] = (seed >> 33) % 1000
+        if i % 17 == 0 {
+            freqs[i] = uint64(1) << (i / 17)
+        }
+    }
+
+    unlimited, _ := NewHuffmanCode(freqs, 16)
+    prev := huffmanCost(freqs, unlimited.Lengths())
+    for limit := uint(16); limit >= 8; limit-- {
+        c, err := NewHuffmanCode(freqs, limit)
+        if err != nil {
+            t.Fatal(err)
+        }
+        cost := huffmanCost(freqs, c.Lengths())
+        if c.MaxLength() > limit || cost < prev {
+            t.Fatalf("limit %d get max length %d and cost %d", limit, c.MaxLength(), cost)
+        }
+        flat := make([]uint8, len(freqs))
+        for i := range flat {
+            if freqs[i] != 0 {
+                flat[i] = 8
+            }
+        }
+        if cost > huffmanCost(freqs, flat) {
+            t.Fatalf("limit %d cost %d more than a flat code", limit, cost)
+        }
+        prev = cost
+    }
+}
+
+func TestHuffmanEncode(t *testing.T) {
+    text := []byte("abracadabra, a canonical huffman code")
+    freqs := make([]uint64, 256)
+    symbols := make([]uint, len(text))
+    for i, b := range text {
+        freqs[b]++
+        symbols[i] = uint(b)
+    }
+
+    c, err := NewHuffmanCode(freqs, 7)
+    if err != nil {
+        t.Fatal(err)
+    }
+    w := NewBitWriter()
+    c.WriteTable(w)
+    if err = c.Encode(w, symbols); err != nil {
+        t.Fatal(err)
+    }
+    if err = c.Encode(w, []uint{'a', 'z'}); err == nil {
+        t.Fail()
+        t.Log("symbol without code")
+    }
+
+    r := NewBitReader(w.Bytes())
+    d, err := ReadHuffmanTable(r)
+    if err != nil || !slices.Equal(d.Lengths(), c.Lengths()) {
+        t.Fatalf("table get %v", err)
+    }
+    ret, err := d.Decode(r, len(symbols))
+    if err != nil || !slices.Equal(ret, symbols) {
+        t.Fail()
+        t.Logf("decode get %v %v", ret, err)
+    }
+
+    pos := r.Pos()
+    if _, err = d.Decode(r, 8); (!errors.Is(err, ErrTruncated) && !errors.Is(err, ErrMalformed)) || r.Pos() != pos {
+        t.Fail()
+        t.Logf("decode past end get %v", err)
+    }
+    if _, err = d.Decode(r, -1); err == nil || r.Pos() != pos {
+        t.Fail()
+        t.Log("negative count accepted")
+    }
+    if _, err = d.Decode(r, math.MaxInt); !errors.Is(err, ErrTruncated) && !errors.Is(err, ErrMalformed) {
+        t.Fail()
+        t.Logf("huge count get %v", err)
+    }
+}
+
+func TestHuffmanMalformed(t *testing.T) {
+    //incomplete code, 11 is not assigned
+    c, err := NewHuffmanCodeFromLengths([]uint8{1, 2})
+    if err != nil {
+        t.Fatal(err)
+    }
+    r := NewBitReader([]byte{0xC0})
+    if _, err = c.ReadSymbol(r); err != ErrMalformed || r.Pos() != 0 {
+        t.Fail()
+        t.Logf("unassigned code get %v", err)
+    }
+    if _, _, err = c.LookupReversed(0x3); err != ErrMalformed {
+        t.Fail()
+        t.Logf("unassigned reversed code get %v", err)
+    }
+
+    w := NewBitWriter()
+    w.WriteExpGolomb(2, 0)
+    w.WriteSignedExpGolomb(17, 0)
+    if _, err = ReadHuffmanTable(NewBitReader(w.Bytes())); err == nil {
+        t.Fail()
+        t.Log("length 17 in table")
+    }
+}
@@ -0,0 +1,66 @@
+//go:build !purego
+
+package bitops
+
+import "math/bits"
+
+// math/bits versions selected by default, see portable.go
+
+// a popcount instruction beat the carry-save blocks of PopCountAnd/PopCountXor
+const fastCountOne = true
+
+func countOne8(value uint8) (uint) {
+    return uint(bits.OnesCount8(value))
+}
+
+func countOne16(value uint16) (uint) {
+    return uint(bits.OnesCount16(value))
+}
+
+func countOne32(value uint32) (uint) {
+    return uint(bits.OnesCount32(value))
+}
+
+func countOne64(value uint64) (uint) {
+    return uint(bits.OnesCount64(value))
+}
+
+func countTrailZero32(value uint32) (uint) {
+    return uint(bits.TrailingZeros32(value))
+}
+
+func countTrailZero64(value uint64) (uint) {
+    return uint(bits.TrailingZeros64(value))
+}
+
+func countLeadZero32(value uint32) (uint) {
+    return uint(bits.LeadingZeros32(value))
+}
+
+func countLeadZero64(value uint64) (uint) {
+    return uint(bits.LeadingZeros64(value))
+}
+
+func reverse32(value uint32) (uint32) {
+    return bits.Reverse32(value)
+}
+
+func reverse64(value uint64) (uint64) {
+    return bits.Reverse64(value)
+}
+
+func rotateRight32(value uint32, shift uint) (uint32) {
+    return bits.RotateLeft32(value, -int(shift & 0x1F))
+}
+
+func rotateLeft32(value uint32, shift uint) (uint32) {
+    return bits.RotateLeft32(value, int(shift & 0x1F))
+}
+
+func rotateRight64(value uint64, shift uint) (uint64) {
+    return bits.RotateLeft64(value, -int(shift & 0x3F))
+}
+
+func rotateLeft64(value uint64, shift uint) (uint64) {
+    return bits.RotateLeft64(value, int(shift & 0x3F))
+}
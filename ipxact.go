@@ -0,0 +1,268 @@
+package bitops
+
+import (
+    "encoding/xml"
+    "fmt"
+    "io"
+    "slices"
+    "strconv"
+)
+
+// value and mask of an IP-XACT reset, 1685-2009 put it on the register and
+// 1685-2014 on the fields
+type ipxactReset struct {
+    Value string `xml:"value"`
+    Mask  string `xml:"mask"`
+}
+
+type ipxactField struct {
+    Name               string `xml:"name"`
+    Description        string `xml:"description"`
+    BitOffset          string `xml:"bitOffset"`
+    BitWidth           string `xml:"bitWidth"`
+    Access             string `xml:"access"`
+    ModifiedWriteValue string `xml:"modifiedWriteValue"`
+    ReadAction         string `xml:"readAction"`
+    Resets             []ipxactReset `xml:"resets>reset"`
+    Enums              []struct {
+        Name        string `xml:"name"`
+        Description string `xml:"description"`
+        Value       string `xml:"value"`
+    } `xml:"enumeratedValues>enumeratedValue"`
+}
+
+type ipxactRegister struct {
+    Name          string `xml:"name"`
+    Description   string `xml:"description"`
+    AddressOffset string `xml:"addressOffset"`
+    Dim           string `xml:"dim"`
+    Size          string `xml:"size"`
+    Access        string `xml:"access"`
+    Reset         ipxactReset `xml:"reset"`
+    Fields        []ipxactField `xml:"field"`
+}
+
+type ipxactRegisterFile struct {
+    Name          string `xml:"name"`
+    AddressOffset string `xml:"addressOffset"`
+    Dim           string `xml:"dim"`
+    Range         string `xml:"range"`
+    Registers     []ipxactRegister `xml:"register"`
+    RegisterFiles []ipxactRegisterFile `xml:"registerFile"`
+}
+
+type ipxactAddressBlock struct {
+    Name          string `xml:"name"`
+    Description   string `xml:"description"`
+    BaseAddress   string `xml:"baseAddress"`
+    Width         string `xml:"width"`
+    Access        string `xml:"access"`
+    Registers     []ipxactRegister `xml:"register"`
+    RegisterFiles []ipxactRegisterFile `xml:"registerFile"`
+}
+
+type ipxactComponent struct {
+    Name        string `xml:"name"`
+    Description string `xml:"description"`
+    Blocks      []ipxactAddressBlock `xml:"memoryMaps>memoryMap>addressBlock"`
+}
+
+// register layout of r without name and offset, a register without fields get a
+// single VALUE field covering its width
+func (r ipxactRegister) layout(size string, access string) (Register, error) {
+    if r.Size != "" {
+        size = r.Size
+    }
+    if r.Access != "" {
+        access = r.Access
+    }
+    width, err := parseRegisterNumber(size)
+    if err != nil || width == 0 || width > 64 {
+        return Register{}, fmt.Errorf("invalid size(%v) of register(%v)", size, r.Name)
+    }
+    widthMask := ^uint64(0) >> (64 - width)
+
+    reset, resetMask := uint64(0), widthMask
+    if r.Reset.Value != "" {
+        if reset, err = parseRegisterNumber(r.Reset.Value); err != nil {
+            return Register{}, err
+        }
+    }
+    if r.Reset.Mask != "" {
+        if resetMask, err = parseRegisterNumber(r.Reset.Mask); err != nil {
+            return Register{}, err
+        }
+    }
+
+    reg := Register{Width: uint(width), Description: cleanDescription(r.Description)}
+    if len(r.Fields) == 0 {
+        acc, err := xmlAccess(access, "", "")
+        if err != nil {
+            return Register{}, err
+        }
+        reg.Fields = []Field{{Name: "VALUE", High: uint(width) - 1, Low: 0, Access: acc, Reset: reset & widthMask}}
+        reg.UndefinedReset = widthMask &^ resetMask
+        return reg, nil
+    }
+
+    for _, f := range r.Fields {
+        low, err1 := parseRegisterNumber(f.BitOffset)
+        bits, err2 := parseRegisterNumber(f.BitWidth)
+        if err1 != nil || err2 != nil || bits == 0 || low + bits > 64 {
+            return Register{}, fmt.Errorf("invalid bit position of field(%v)", f.Name)
+        }
+        fieldAccess := access
+        if f.Access != "" {
+            fieldAccess = f.Access
+        }
+        acc, err := xmlAccess(fieldAccess, f.ModifiedWriteValue, f.ReadAction)
+        if err != nil {
+            return Register{}, err
+        }
+
+        field := Field{Name: f.Name, High: uint(low + bits - 1), Low: uint(low), Access: acc,
+                       Description: cleanDescription(f.Description)}
+        field.Reset = (reset & field.Mask()) >> field.Low
+        reg.UndefinedReset |= field.Mask() &^ resetMask
+        if len(f.Resets) > 0 {
+            value, err := parseRegisterNumber(f.Resets[0].Value)
+            if err != nil {
+                return Register{}, err
+            }
+            field.Reset = value
+            reg.UndefinedReset &^= field.Mask()
+            if f.Resets[0].Mask != "" {
+                mask, err := parseRegisterNumber(f.Resets[0].Mask)
+                if err != nil {
+                    return Register{}, err
+                }
+                reg.UndefinedReset |= (^mask << field.Low) & field.Mask()
+            }
+        } else if r.Reset.Value == "" {
+            // 1685-2014 field without reset
+            reg.UndefinedReset |= field.Mask()
+        }
+
+        for _, e := range f.Enums {
+            value, err := parseRegisterNumber(e.Value)
+            if err != nil {
+                return Register{}, err
+            }
+            field.Enums = append(field.Enums, EnumValue{Name: e.Name, Value: value,
+                                                        Description: cleanDescription(e.Description)})
+        }
+        reg.Fields = append(reg.Fields, field)
+    }
+    return reg, nil
+}
+
+// number of elements of an IP-XACT dim, 1 if absent
+func ipxactDim(dim string, name string) (uint64, error) {
+    if dim == "" {
+        return 1, nil
+    }
+    count, err := parseRegisterNumber(dim)
+    if err != nil || count == 0 || count > 1 << 16 {
+        return 0, fmt.Errorf("invalid dim(%v) of %v", dim, name)
+    }
+    return count, nil
+}
+
+// flatten the registers and register files of a scope, register file registers
+// are named FILE_REGISTER and arrays get the index appended
+func ipxactRegisters(regs []ipxactRegister, files []ipxactRegisterFile, size string, access string,
+                     prefix string, offset uint64) ([]Register, error) {
+    var out []Register
+    for _, r := range regs {
+        off, err := parseRegisterNumber(r.AddressOffset)
+        if err != nil {
+            return nil, fmt.Errorf("invalid addressOffset(%v) of register(%v)", r.AddressOffset, r.Name)
+        }
+        count, err := ipxactDim(r.Dim, r.Name)
+        if err != nil {
+            return nil, err
+        }
+        reg, err := r.layout(size, access)
+        if err != nil {
+            return nil, err
+        }
+
+        for i := uint64(0); i < count; i++ {
+            //every dim element get its own fields
+            elem := reg.Clone()
+            elem.Name = prefix + r.Name
+            if r.Dim != "" {
+                elem.Name += strconv.FormatUint(i, 10)
+            }
+            elem.Offset = offset + off + i * uint64(reg.Width / 8)
+            if err = elem.Validate(); err != nil {
+                return nil, err
+            }
+            out = append(out, elem)
+        }
+    }
+
+    for _, f := range files {
+        off, err := parseRegisterNumber(f.AddressOffset)
+        if err != nil {
+            return nil, fmt.Errorf("invalid addressOffset(%v) of registerFile(%v)", f.AddressOffset, f.Name)
+        }
+        count, err := ipxactDim(f.Dim, f.Name)
+        if err != nil {
+            return nil, err
+        }
+        step := uint64(0)
+        if count > 1 {
+            if step, err = parseRegisterNumber(f.Range); err != nil {
+                return nil, fmt.Errorf("invalid range(%v) of registerFile(%v)", f.Range, f.Name)
+            }
+        }
+
+        for i := uint64(0); i < count; i++ {
+            name := f.Name
+            if f.Dim != "" {
+                name += strconv.FormatUint(i, 10)
+            }
+            sub, err := ipxactRegisters(f.Registers, f.RegisterFiles, size, access,
+                                        prefix + name + "_", offset + off + step * i)
+            if err != nil {
+                return nil, err
+            }
+            out = append(out, sub...)
+        }
+    }
+
+    slices.SortStableFunc(out, func(a, b Register) (int) {
+        return cmpUint64(a.Offset, b.Offset)
+    })
+    return out, nil
+}
+
+// ParseIPXACT read the memory maps of an IP-XACT 1685-2009 or 1685-2014 component,
+// every address block become a peripheral. Register arrays and register files are
+// flattened and resets, reset masks and enumerated values are kept
+func ParseIPXACT(r io.Reader) (*Device, error) {
+    var c ipxactComponent
+    if err := xml.NewDecoder(r).Decode(&c); err != nil {
+        return nil, fmt.Errorf("invalid IP-XACT: %w", err)
+    }
+
+    device := &Device{Name: c.Name, Description: cleanDescription(c.Description)}
+    for _, b := range c.Blocks {
+        base, err := parseRegisterNumber(b.BaseAddress)
+        if err != nil {
+            return nil, fmt.Errorf("invalid baseAddress(%v) of addressBlock(%v)", b.BaseAddress, b.Name)
+        }
+        size := b.Width
+        if size == "" {
+            size = "32"
+        }
+        regs, err := ipxactRegisters(b.Registers, b.RegisterFiles, size, b.Access, "", 0)
+        if err != nil {
+            return nil, fmt.Errorf("addressBlock(%v): %w", b.Name, err)
+        }
+        device.Peripherals = append(device.Peripherals, Peripheral{Name: b.Name, BaseAddress: base,
+                                    Description: cleanDescription(b.Description), Registers: regs})
+    }
+    return device, nil
+}
@@ -0,0 +1,119 @@
+package bitops
+
+import (
+    "os"
+    "strings"
+    "testing"
+)
+
+func TestParseIPXACT(t *testing.T) {
+    f, err := os.Open("testdata/sample.ipxact.xml")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer f.Close()
+    d, err := ParseIPXACT(f)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    timer, ok := d.Peripheral("TIMER")
+    if d.Name != "timer" || !ok || timer.BaseAddress != 0x40003000 || len(timer.Registers) != 4 {
+        t.Fatalf("device get %+v", d)
+    }
+
+    ctrl, _ := timer.Register("CTRL")
+    if ctrl.ResetValue() != 0x4 || ctrl.UndefinedReset != 0xFF00 {
+        t.Fail()
+        t.Logf("CTRL reset get %x undefined %x", ctrl.ResetValue(), ctrl.UndefinedReset)
+    }
+    mode, _ := ctrl.Field("MODE")
+    if len(mode.Enums) != 2 || mode.Enums[1] != (EnumValue{Name: "PERIODIC", Value: 2}) {
+        t.Fail()
+        t.Logf("MODE get %+v", mode)
+    }
+    if capture, _ := ctrl.Field("CAPTURE"); capture.Access != AccessRO || capture.High != 15 {
+        t.Fail()
+        t.Logf("CAPTURE get %+v", capture)
+    }
+
+    if r, _ := timer.Register("INT"); r.Offset != 4 || r.Fields[0].Access != AccessW1C {
+        t.Fail()
+        t.Logf("INT get %+v", r)
+    }
+    cmp, ok := timer.Register("CMP1")
+    if !ok || cmp.Offset != 0x14 || cmp.ResetValue() != 0xFFFF {
+        t.Fail()
+        t.Logf("CMP1 get %+v", cmp)
+    }
+    if _, err = NewRegisterBank(timer.Registers); err != nil {
+        t.Fail()
+        t.Logf("bank get %v", err)
+    }
+}
+
+func TestParseIPXACTIndependentCopies(t *testing.T) {
+    f, err := os.Open("testdata/sample.ipxact.xml")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer f.Close()
+    d, err := ParseIPXACT(f)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    //dim elements must not share fields or enums
+    timer, _ := d.Peripheral("TIMER")
+    cmp0, _ := timer.Register("CMP0")
+    cmp1, _ := timer.Register("CMP1")
+    if len(cmp0.Fields) == 0 || len(cmp0.Fields[0].Enums) == 0 {
+        t.Fatalf("CMP0 get %+v", cmp0)
+    }
+    cmp0.Fields[0].Name = "CHANGED"
+    cmp0.Fields[0].Reset = 0
+    cmp0.Fields[0].Enums[0].Name = "CHANGED"
+    if value, _ := cmp1.Field("VALUE"); value.Reset != 0xFFFF || value.Enums[0].Name != "OFF" {
+        t.Fail()
+        t.Logf("CMP1 share fields with CMP0, get %+v", cmp1.Fields)
+    }
+}
+
+func TestParseIPXACT2009(t *testing.T) {
+    s := `<spirit:component xmlns:spirit="http://www.spiritconsortium.org/XMLSchema/SPIRIT/1.5">
+      <spirit:name>gpio</spirit:name>
+      <spirit:memoryMaps><spirit:memoryMap><spirit:name>m</spirit:name>
+        <spirit:addressBlock><spirit:name>GPIO</spirit:name><spirit:baseAddress>0x100</spirit:baseAddress>
+          <spirit:width>16</spirit:width><spirit:access>read-only</spirit:access>
+          <spirit:register><spirit:name>IN</spirit:name><spirit:addressOffset>0x2</spirit:addressOffset>
+            <spirit:reset><spirit:value>0x00A5</spirit:value><spirit:mask>0x00FF</spirit:mask></spirit:reset>
+          </spirit:register>
+          <spirit:registerFile><spirit:name>PORT</spirit:name><spirit:dim>2</spirit:dim>
+            <spirit:addressOffset>0x10</spirit:addressOffset><spirit:range>8</spirit:range>
+            <spirit:register><spirit:name>OUT</spirit:name><spirit:addressOffset>0x4</spirit:addressOffset>
+              <spirit:access>read-write</spirit:access>
+            </spirit:register>
+          </spirit:registerFile>
+        </spirit:addressBlock>
+      </spirit:memoryMap></spirit:memoryMaps>
+    </spirit:component>`
+    d, err := ParseIPXACT(strings.NewReader(s))
+    if err != nil {
+        t.Fatal(err)
+    }
+    p := d.Peripherals[0]
+    in, _ := p.Register("IN")
+    if p.BaseAddress != 0x100 || in.Width != 16 || in.Fields[0].Access != AccessRO || in.ResetValue() != 0xA5 || in.UndefinedReset != 0xFF00 {
+        t.Fail()
+        t.Logf("IN get %+v", in)
+    }
+    if out, ok := p.Register("PORT1_OUT"); !ok || out.Offset != 0x1C || out.Fields[0].Access != AccessRW {
+        t.Fail()
+        t.Logf("PORT1_OUT get %+v", out)
+    }
+
+    if _, err = ParseIPXACT(strings.NewReader(strings.Replace(s, "0x2<", "two<", 1))); err == nil {
+        t.Fail()
+        t.Log("invalid offset")
+    }
+}
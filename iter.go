@@ -0,0 +1,156 @@
+package bitops
+
+import (
+    "fmt"
+    "iter"
+)
+
+// real implementation for IterOne8/16/32/64
+func iterOne(value uint64) iter.Seq[uint] {
+    return func(yield func(uint) bool) {
+        //the sequence may be ranged again, work on a copy
+        value := value
+        for value != 0 {
+            if !yield(CountTrailZero64(value)) {
+                return
+            }
+            value &= value - 1
+        }
+    }
+}
+
+// real implementation for IterOneReverse8/16/32/64
+func iterOneReverse(value uint64) iter.Seq[uint] {
+    return func(yield func(uint) bool) {
+        //the highest 1 of value is the lowest 1 of the reversed value
+        reversed := Reverse64(value)
+        for reversed != 0 {
+            if !yield(63 - CountTrailZero64(reversed)) {
+                return
+            }
+            reversed &= reversed - 1
+        }
+    }
+}
+
+// real implementation for IterRun8/16/32/64
+func iterRun(value uint64) iter.Seq2[uint, uint] {
+    return func(yield func(uint, uint) bool) {
+        value := value
+        for value != 0 {
+            start := CountTrailZero64(value)
+            length := CountTrailOne64(value >> start)
+            if !yield(start, length) {
+                return
+            }
+            if length == 64 {
+                return
+            }
+            value &^= ((uint64(1) << length) - 1) << start
+        }
+    }
+}
+
+// real implementation for SetPositions8/16/32/64
+func setPositions(value uint64, dst []uint8) (uint, error) {
+    if need := CountOne64(value); uint(len(dst)) < need {
+        return 0, fmt.Errorf("invalid dst length(%v), need %v", len(dst), need)
+    }
+
+    var count uint = 0
+    for value != 0 {
+        dst[count] = uint8(CountTrailZero64(value))
+        count++
+        value &= value - 1
+    }
+
+    return count, nil
+}
+
+// IterOne8 iterate the position of every 1 in uint8 variable from LSB to MSB
+func IterOne8(value uint8) (iter.Seq[uint]) {
+    return iterOne(uint64(value))
+}
+
+// IterOne16 iterate the position of every 1 in uint16 variable from LSB to MSB
+func IterOne16(value uint16) (iter.Seq[uint]) {
+    return iterOne(uint64(value))
+}
+
+// IterOne32 iterate the position of every 1 in uint32 variable from LSB to MSB
+func IterOne32(value uint32) (iter.Seq[uint]) {
+    return iterOne(uint64(value))
+}
+
+// IterOne64 iterate the position of every 1 in uint64 variable from LSB to MSB
+func IterOne64(value uint64) (iter.Seq[uint]) {
+    return iterOne(value)
+}
+
+// IterOneReverse8 iterate the position of every 1 in uint8 variable from MSB to LSB
+func IterOneReverse8(value uint8) (iter.Seq[uint]) {
+    return iterOneReverse(uint64(value))
+}
+
+// IterOneReverse16 iterate the position of every 1 in uint16 variable from MSB to LSB
+func IterOneReverse16(value uint16) (iter.Seq[uint]) {
+    return iterOneReverse(uint64(value))
+}
+
+// IterOneReverse32 iterate the position of every 1 in uint32 variable from MSB to LSB
+func IterOneReverse32(value uint32) (iter.Seq[uint]) {
+    return iterOneReverse(uint64(value))
+}
+
+// IterOneReverse64 iterate the position of every 1 in uint64 variable from MSB to LSB
+func IterOneReverse64(value uint64) (iter.Seq[uint]) {
+    return iterOneReverse(value)
+}
+
+// IterRun8 iterate (start, length) of every run of consecutive 1 in uint8 variable
+// from LSB to MSB
+func IterRun8(value uint8) (iter.Seq2[uint, uint]) {
+    return iterRun(uint64(value))
+}
+
+// IterRun16 iterate (start, length) of every run of consecutive 1 in uint16 variable
+// from LSB to MSB
+func IterRun16(value uint16) (iter.Seq2[uint, uint]) {
+    return iterRun(uint64(value))
+}
+
+// IterRun32 iterate (start, length) of every run of consecutive 1 in uint32 variable
+// from LSB to MSB
+func IterRun32(value uint32) (iter.Seq2[uint, uint]) {
+    return iterRun(uint64(value))
+}
+
+// IterRun64 iterate (start, length) of every run of consecutive 1 in uint64 variable
+// from LSB to MSB
+func IterRun64(value uint64) (iter.Seq2[uint, uint]) {
+    return iterRun(value)
+}
+
+// SetPositions8 store the position of every 1 in uint8 variable to dst in ascending
+// order and return the number of positions. dst is not modified if it is too short
+func SetPositions8(value uint8, dst []uint8) (uint, error) {
+    return setPositions(uint64(value), dst)
+}
+
+// SetPositions16 store the position of every 1 in uint16 variable to dst in ascending
+// order and return the number of positions. dst is not modified if it is too short
+func SetPositions16(value uint16, dst []uint8) (uint, error) {
+    return setPositions(uint64(value), dst)
+}
+
+// SetPositions32 store the position of every 1 in uint32 variable to dst in ascending
+// order and return the number of positions. dst is not modified if it is too short
+func SetPositions32(value uint32, dst []uint8) (uint, error) {
+    return setPositions(uint64(value), dst)
+}
+
+// SetPositions64 store the position of every 1 in uint64 variable to dst in ascending
+// order and return the number of positions. dst is not modified if it is too short
+func SetPositions64(value uint64, dst []uint8) (uint, error) {
+    return setPositions(value, dst)
+}
@@ -0,0 +1,240 @@
+package bitops
+
+import "testing"
+
+func collectOne(seq func(func(uint) bool)) ([]uint) {
+    var ret []uint
+    for pos := range seq {
+        ret = append(ret, pos)
+    }
+    return ret
+}
+
+func collectRun(seq func(func(uint, uint) bool)) ([][2]uint) {
+    var ret [][2]uint
+    for start, length := range seq {
+        ret = append(ret, [2]uint{start, length})
+    }
+    return ret
+}
+
+func equalUint(a, b []uint) (bool) {
+    if len(a) != len(b) {
+        return false
+    }
+    for i := range a {
+        if a[i] != b[i] {
+            return false
+        }
+    }
+    return true
+}
+
+func equalRun(a, b [][2]uint) (bool) {
+    if len(a) != len(b) {
+        return false
+    }
+    for i := range a {
+        if a[i] != b[i] {
+            return false
+        }
+    }
+    return true
+}
+
+func TestIterOne8(t *testing.T) {
+    ret := collectOne(IterOne8(0xA5))
+    if expect := []uint{0, 2, 5, 7}; !equalUint(ret, expect) {
+        t.Fail()
+        t.Logf("expect %v but get %v", expect, ret)
+    }
+
+    if ret = collectOne(IterOne8(0)); len(ret) != 0 {
+        t.Fail()
+        t.Logf("expect nothing for 0 but get %v", ret)
+    }
+}
+
+func TestIterOne16(t *testing.T) {
+    ret := collectOne(IterOne16(0x8001))
+    if expect := []uint{0, 15}; !equalUint(ret, expect) {
+        t.Fail()
+        t.Logf("expect %v but get %v", expect, ret)
+    }
+}
+
+func TestIterOne32(t *testing.T) {
+    ret := collectOne(IterOne32(0x80010010))
+    if expect := []uint{4, 16, 31}; !equalUint(ret, expect) {
+        t.Fail()
+        t.Logf("expect %v but get %v", expect, ret)
+    }
+}
+
+func TestIterOne64(t *testing.T) {
+    ret := collectOne(IterOne64(0x8000000100000001))
+    if expect := []uint{0, 32, 63}; !equalUint(ret, expect) {
+        t.Fail()
+        t.Logf("expect %v but get %v", expect, ret)
+    }
+
+    //stop early
+    var count uint = 0
+    for pos := range IterOne64(0xFFFFFFFFFFFFFFFF) {
+        if pos == 3 {
+            break
+        }
+        count++
+    }
+    if count != 3 {
+        t.Fail()
+        t.Logf("expect to stop after 3 positions but get %d", count)
+    }
+}
+
+func TestIterOneReverse8(t *testing.T) {
+    ret := collectOne(IterOneReverse8(0xA5))
+    if expect := []uint{7, 5, 2, 0}; !equalUint(ret, expect) {
+        t.Fail()
+        t.Logf("expect %v but get %v", expect, ret)
+    }
+}
+
+func TestIterOneReverse16(t *testing.T) {
+    ret := collectOne(IterOneReverse16(0x8001))
+    if expect := []uint{15, 0}; !equalUint(ret, expect) {
+        t.Fail()
+        t.Logf("expect %v but get %v", expect, ret)
+    }
+}
+
+func TestIterOneReverse32(t *testing.T) {
+    ret := collectOne(IterOneReverse32(0x80010010))
+    if expect := []uint{31, 16, 4}; !equalUint(ret, expect) {
+        t.Fail()
+        t.Logf("expect %v but get %v", expect, ret)
+    }
+}
+
+func TestIterOneReverse64(t *testing.T) {
+    ret := collectOne(IterOneReverse64(0x8000000100000001))
+    if expect := []uint{63, 32, 0}; !equalUint(ret, expect) {
+        t.Fail()
+        t.Logf("expect %v but get %v", expect, ret)
+    }
+}
+
+func TestIterRun8(t *testing.T) {
+    ret := collectRun(IterRun8(0xE6))
+    if expect := [][2]uint{{1, 2}, {5, 3}}; !equalRun(ret, expect) {
+        t.Fail()
+        t.Logf("expect %v but get %v", expect, ret)
+    }
+
+    ret = collectRun(IterRun8(0xFF))
+    if expect := [][2]uint{{0, 8}}; !equalRun(ret, expect) {
+        t.Fail()
+        t.Logf("expect %v but get %v", expect, ret)
+    }
+}
+
+func TestIterRun16(t *testing.T) {
+    ret := collectRun(IterRun16(0xF00F))
+    if expect := [][2]uint{{0, 4}, {12, 4}}; !equalRun(ret, expect) {
+        t.Fail()
+        t.Logf("expect %v but get %v", expect, ret)
+    }
+}
+
+func TestIterRun32(t *testing.T) {
+    ret := collectRun(IterRun32(0x80FF0001))
+    if expect := [][2]uint{{0, 1}, {16, 8}, {31, 1}}; !equalRun(ret, expect) {
+        t.Fail()
+        t.Logf("expect %v but get %v", expect, ret)
+    }
+}
+
+func TestIterRun64(t *testing.T) {
+    ret := collectRun(IterRun64(0xFFFFFFFFFFFFFFFF))
+    if expect := [][2]uint{{0, 64}}; !equalRun(ret, expect) {
+        t.Fail()
+        t.Logf("expect %v but get %v", expect, ret)
+    }
+
+    ret = collectRun(IterRun64(0xFFFFFFFF00000000))
+    if expect := [][2]uint{{32, 32}}; !equalRun(ret, expect) {
+        t.Fail()
+        t.Logf("expect %v but get %v", expect, ret)
+    }
+}
+
+func TestSetPositions8(t *testing.T) {
+    dst := make([]uint8, 8)
+    count, err := SetPositions8(0x81, dst)
+    if err != nil || count != 2 || dst[0] != 0 || dst[1] != 7 {
+        t.Fail()
+        t.Logf("expect [0 7] but get %v", dst[:count])
+    }
+}
+
+func TestSetPositions16(t *testing.T) {
+    dst := make([]uint8, 2)
+    count, err := SetPositions16(0x8001, dst)
+    if err != nil || count != 2 || dst[0] != 0 || dst[1] != 15 {
+        t.Fail()
+        t.Logf("expect [0 15] but get %v", dst[:count])
+    }
+}
+
+func TestSetPositions32(t *testing.T) {
+    dst := make([]uint8, 2)
+
+    //check error
+    _, err := SetPositions32(0x7, dst)
+    if err == nil {
+        t.Fail()
+        t.Log("dst too short")
+    }
+
+    count, err := SetPositions32(0x80000000, dst)
+    if err != nil || count != 1 || dst[0] != 31 {
+        t.Fail()
+        t.Logf("expect [31] but get %v", dst[:count])
+    }
+}
+
+func TestSetPositions64(t *testing.T) {
+    dst := make([]uint8, 64)
+    count, err := SetPositions64(0xFFFFFFFFFFFFFFFF, dst)
+    if err != nil || count != 64 {
+        t.Fail()
+        t.Logf("expect 64 positions but get %d", count)
+    }
+    for i := range dst {
+        if dst[i] != uint8(i) {
+            t.Fail()
+            t.Logf("expect %d at %d but get %d", i, i, dst[i])
+        }
+    }
+}
+
+func TestIterReuse(t *testing.T) {
+    //a sequence is a value and every range must start from the beginning
+    one := IterOne64(0x8000000000000101)
+    reverse := IterOneReverse32(0x80000101)
+    run := IterRun16(0xF0F0)
+    for i := 0; i < 2; i++ {
+        if ret, expect := collectOne(one), []uint{0, 8, 63}; !equalUint(ret, expect) {
+            t.Fail()
+            t.Logf("pass %d expect %v but get %v", i, expect, ret)
+        }
+        if ret, expect := collectOne(reverse), []uint{31, 8, 0}; !equalUint(ret, expect) {
+            t.Fail()
+            t.Logf("pass %d expect %v but get %v", i, expect, ret)
+        }
+        if ret, expect := collectRun(run), [][2]uint{{4, 4}, {12, 4}}; !equalRun(ret, expect) {
+            t.Fail()
+            t.Logf("pass %d expect %v but get %v", i, expect, ret)
+        }
+    }
+}
@@ -0,0 +1,334 @@
+package bitops
+
+import "fmt"
+
+// feedback polynomials including the x^n and 1 terms, the PRBS ones are those of
+// ITU-T O.150 without the output inversion some test sets apply
+const (
+    PRBS7  = 1 << 7 | 1 << 6 | 1
+    PRBS9  = 1 << 9 | 1 << 5 | 1
+    PRBS11 = 1 << 11 | 1 << 9 | 1
+    PRBS15 = 1 << 15 | 1 << 14 | 1
+    PRBS20 = 1 << 20 | 1 << 3 | 1
+    PRBS23 = 1 << 23 | 1 << 18 | 1
+    PRBS31 = 1 << 31 | 1 << 28 | 1
+    // Scrambler64b66b is x^58+x^39+1 of the IEEE 802.3 64b/66b self-synchronising
+    // scrambler
+    Scrambler64b66b = 1 << 58 | 1 << 39 | 1
+)
+
+// check poly has a constant term and a degree from 1 to 63, return the degree and
+// the tap mask where bit d-1 is set for every term x^d
+func lfsrTaps(poly uint64) (uint, uint64, error) {
+    if poly & 1 == 0 || poly < 2 {
+        return 0, 0, fmt.Errorf("invalid polynomial(%#x)", poly)
+    }
+    degree := 63 - CountLeadZero64(poly)
+    return degree, poly >> 1, nil
+}
+
+// mask of the low n bits
+func lowMask(n uint) (uint64) {
+    return ^uint64(0) >> (64 - n)
+}
+
+// lfsrJump return state after n steps of the linear map step over degree bits,
+// the map is turned into a GF(2) matrix raised to the power n by squaring
+func lfsrJump(step func(uint64) (uint64), degree uint, state uint64, n uint64) (uint64) {
+    m, _ := NewBitMatrix(degree, degree)
+    for c := uint(0); c < degree; c++ {
+        column := step(uint64(1) << c)
+        for r := range IterOne64(column) {
+            m.Set(r, c, true)
+        }
+    }
+
+    v := []uint64{state}
+    for ; n != 0; n >>= 1 {
+        if n & 1 == 1 {
+            v, _ = m.MulVec(v)
+        }
+        if n > 1 {
+            m, _ = m.Mul(m)
+        }
+    }
+    return v[0]
+}
+
+// FibonacciLFSR is a Fibonacci linear feedback shift register: the new bit is the
+// parity of the tapped state bits and is also the output. Bit 0 of the state is the
+// newest bit, so with poly x^n+...+1 the output s[k] is the XOR of s[k-d] over the
+// terms x^d
+type FibonacciLFSR struct {
+    degree uint
+    taps   uint64
+    state  uint64
+}
+
+// NewFibonacciLFSR create a register of the degree of poly with the low bits of
+// seed as state, the error is returned if poly is invalid or the state is 0
+func NewFibonacciLFSR(poly uint64, seed uint64) (*FibonacciLFSR, error) {
+    degree, taps, err := lfsrTaps(poly)
+    if err != nil {
+        return nil, err
+    }
+    if seed & lowMask(degree) == 0 {
+        return nil, fmt.Errorf("invalid seed(%#x), the state is 0", seed)
+    }
+    return &FibonacciLFSR{degree: degree, taps: taps, state: seed & lowMask(degree)}, nil
+}
+
+// State return the register content
+func (l *FibonacciLFSR) State() (uint64) {
+    return l.state
+}
+
+// step of the register as a linear map of the state
+func (l *FibonacciLFSR) step(state uint64) (uint64) {
+    bit := uint64(CountOne64(state & l.taps) & 1)
+    return (state << 1 | bit) & lowMask(l.degree)
+}
+
+// Bit shift the register once and return the new bit
+func (l *FibonacciLFSR) Bit() (uint) {
+    l.state = l.step(l.state)
+    return uint(l.state & 1)
+}
+
+// Bits return the next n bits, n up to 64, the first one in the most significant
+// position as BitWriter.WriteBits expect
+func (l *FibonacciLFSR) Bits(n uint) (uint64, error) {
+    if n > 64 {
+        return 0, fmt.Errorf("invalid length(%v)", n)
+    }
+    value := uint64(0)
+    for i := uint(0); i < n; i++ {
+        value = value << 1 | uint64(l.Bit())
+    }
+    return value, nil
+}
+
+// Read fill p with the next bits, MSB first in every byte, it never fail
+func (l *FibonacciLFSR) Read(p []byte) (int, error) {
+    for i := range p {
+        value, _ := l.Bits(8)
+        p[i] = byte(value)
+    }
+    return len(p), nil
+}
+
+// Jump advance the register by n steps in O(log n) matrix products
+func (l *FibonacciLFSR) Jump(n uint64) {
+    l.state = lfsrJump(l.step, l.degree, l.state, n)
+}
+
+// GaloisLFSR is a Galois linear feedback shift register: the output is bit 0 of the
+// state and, when it is 1, the taps are XORed into the right-shifted state. It
+// produce the sequence of the FibonacciLFSR of the same poly, one XOR per step
+type GaloisLFSR struct {
+    degree uint
+    taps   uint64
+    state  uint64
+}
+
+// NewGaloisLFSR create a register of the degree of poly with the low bits of seed
+// as state, the error is returned if poly is invalid or the state is 0
+func NewGaloisLFSR(poly uint64, seed uint64) (*GaloisLFSR, error) {
+    degree, taps, err := lfsrTaps(poly)
+    if err != nil {
+        return nil, err
+    }
+    if seed & lowMask(degree) == 0 {
+        return nil, fmt.Errorf("invalid seed(%#x), the state is 0", seed)
+    }
+    return &GaloisLFSR{degree: degree, taps: taps, state: seed & lowMask(degree)}, nil
+}
+
+// State return the register content
+func (l *GaloisLFSR) State() (uint64) {
+    return l.state
+}
+
+// step of the register as a linear map of the state
+func (l *GaloisLFSR) step(state uint64) (uint64) {
+    return (state >> 1) ^ (l.taps & -(state & 1))
+}
+
+// Bit return the output bit and shift the register once
+func (l *GaloisLFSR) Bit() (uint) {
+    bit := uint(l.state & 1)
+    l.state = l.step(l.state)
+    return bit
+}
+
+// Bits return the next n bits, n up to 64, the first one in the most significant
+// position as BitWriter.WriteBits expect
+func (l *GaloisLFSR) Bits(n uint) (uint64, error) {
+    if n > 64 {
+        return 0, fmt.Errorf("invalid length(%v)", n)
+    }
+    value := uint64(0)
+    for i := uint(0); i < n; i++ {
+        value = value << 1 | uint64(l.Bit())
+    }
+    return value, nil
+}
+
+// Read fill p with the next bits, MSB first in every byte, it never fail
+func (l *GaloisLFSR) Read(p []byte) (int, error) {
+    for i := range p {
+        value, _ := l.Bits(8)
+        p[i] = byte(value)
+    }
+    return len(p), nil
+}
+
+// Jump advance the register by n steps in O(log n) matrix products
+func (l *GaloisLFSR) Jump(n uint64) {
+    l.state = lfsrJump(l.step, l.degree, l.state, n)
+}
+
+// AdditiveScrambler XOR the data with a PRBS, the same operation scramble and
+// descramble when both sides start from the same seed
+type AdditiveScrambler struct {
+    lfsr *FibonacciLFSR
+}
+
+// NewAdditiveScrambler create a scrambler whose sequence is the FibonacciLFSR of
+// poly and seed
+func NewAdditiveScrambler(poly uint64, seed uint64) (*AdditiveScrambler, error) {
+    l, err := NewFibonacciLFSR(poly, seed)
+    if err != nil {
+        return nil, err
+    }
+    return &AdditiveScrambler{lfsr: l}, nil
+}
+
+// Scramble store src XOR the sequence to dst, MSB first in every byte. dst may be
+// src and the error is returned if dst is shorter
+func (s *AdditiveScrambler) Scramble(dst []byte, src []byte) (error) {
+    if len(dst) < len(src) {
+        return fmt.Errorf("invalid dst length(%v), need %v", len(dst), len(src))
+    }
+    for i, b := range src {
+        value, _ := s.lfsr.Bits(8)
+        dst[i] = b ^ byte(value)
+    }
+    return nil
+}
+
+// MultiplicativeScrambler is a self-synchronising scrambler: every scrambled bit is
+// the data bit XOR the taps of the previous scrambled bits, so a descrambler
+// recover the data from any state after degree bits
+type MultiplicativeScrambler struct {
+    degree uint
+    taps   uint64
+    state  uint64
+}
+
+// NewMultiplicativeScrambler create a scrambler or descrambler of poly, such as
+// Scrambler64b66b, with the low bits of seed as previous scrambled bits
+func NewMultiplicativeScrambler(poly uint64, seed uint64) (*MultiplicativeScrambler, error) {
+    degree, taps, err := lfsrTaps(poly)
+    if err != nil {
+        return nil, err
+    }
+    return &MultiplicativeScrambler{degree: degree, taps: taps, state: seed & lowMask(degree)}, nil
+}
+
+// real implementation for Scramble and Descramble, the state shift in the
+// scrambled bits
+func (s *MultiplicativeScrambler) run(dst []byte, src []byte, descramble bool) (error) {
+    if len(dst) < len(src) {
+        return fmt.Errorf("invalid dst length(%v), need %v", len(dst), len(src))
+    }
+    for i, b := range src {
+        out := byte(0)
+        for j := 7; j >= 0; j-- {
+            in := uint64(b >> j & 1)
+            bit := in ^ uint64(CountOne64(s.state & s.taps) & 1)
+            scrambled := bit
+            if descramble {
+                scrambled = in
+            }
+            s.state = (s.state << 1 | scrambled) & lowMask(s.degree)
+            out = out << 1 | byte(bit)
+        }
+        dst[i] = out
+    }
+    return nil
+}
+
+// Scramble store src scrambled to dst, MSB first in every byte. dst may be src and
+// the error is returned if dst is shorter
+func (s *MultiplicativeScrambler) Scramble(dst []byte, src []byte) (error) {
+    return s.run(dst, src, false)
+}
+
+// Descramble store src descrambled to dst, MSB first in every byte. dst may be src
+// and the error is returned if dst is shorter
+func (s *MultiplicativeScrambler) Descramble(dst []byte, src []byte) (error) {
+    return s.run(dst, src, true)
+}
+
+// PRBSChecker count the bit errors of a received PRBS. The degree bits from the
+// first 1 load the reference register, the following bits are compared with the
+// sequence it generate, so a received error count once
+type PRBSChecker struct {
+    lfsr   FibonacciLFSR
+    loaded uint
+    bits   uint64
+    errors uint64
+}
+
+// NewPRBSChecker create a checker of the PRBS of poly such as PRBS31
+func NewPRBSChecker(poly uint64) (*PRBSChecker, error) {
+    degree, taps, err := lfsrTaps(poly)
+    if err != nil {
+        return nil, err
+    }
+    return &PRBSChecker{lfsr: FibonacciLFSR{degree: degree, taps: taps}}, nil
+}
+
+// Check compare the bits of data, MSB first in every byte
+func (c *PRBSChecker) Check(data []byte) {
+    for _, b := range data {
+        for j := 7; j >= 0; j-- {
+            bit := uint(b >> j & 1)
+            if c.loaded < c.lfsr.degree {
+                c.lfsr.state = (c.lfsr.state << 1 | uint64(bit)) & lowMask(c.lfsr.degree)
+                c.loaded++
+                //a PRBS never has degree zeros in a row, load again from the next 1
+                if c.lfsr.state == 0 {
+                    c.loaded = 0
+                }
+                continue
+            }
+            if c.lfsr.Bit() != bit {
+                c.errors++
+            }
+            c.bits++
+        }
+    }
+}
+
+// Locked report whether the reference register is loaded and bits are compared
+func (c *PRBSChecker) Locked() (bool) {
+    return c.loaded >= c.lfsr.degree
+}
+
+// Bits return the number of bits compared
+func (c *PRBSChecker) Bits() (uint64) {
+    return c.bits
+}
+
+// Errors return the number of compared bits that differ from the sequence
+func (c *PRBSChecker) Errors() (uint64) {
+    return c.errors
+}
+
+// Reset clear the counters and load the reference register again from the next
+// bits, after a loss of synchronisation
+func (c *PRBSChecker) Reset() {
+    c.lfsr.state, c.loaded, c.bits, c.errors = 0, 0, 0, 0
+}
@@ -0,0 +1,233 @@
+package bitops
+
+import (
+    "bytes"
+    "testing"
+)
+
+// bitSource is the output of FibonacciLFSR and GaloisLFSR
+type bitSource interface {
+    Bit() (uint)
+    State() (uint64)
+    Jump(uint64)
+}
+
+// return the first n output bits of src
+func lfsrBits(src bitSource, n int) ([]uint) {
+    out := make([]uint, n)
+    for i := range out {
+        out[i] = src.Bit()
+    }
+    return out
+}
+
+// check every bit of s from the degree-th is the XOR of s[k-d] over the terms x^d
+func checkRecurrence(s []uint, poly uint64) (bool) {
+    degree, _, _ := lfsrTaps(poly)
+    for k := int(degree); k < len(s); k++ {
+        bit := uint(0)
+        for d := range IterOne64(poly >> 1) {
+            bit ^= s[k - int(d) - 1]
+        }
+        if bit != s[k] {
+            return false
+        }
+    }
+    return true
+}
+
+func TestLFSRPeriod(t *testing.T) {
+    for _, poly := range []uint64{PRBS7, PRBS9, PRBS11, PRBS15} {
+        degree, _, _ := lfsrTaps(poly)
+        period := 1 << degree - 1
+        fib, _ := NewFibonacciLFSR(poly, 1)
+        gal, _ := NewGaloisLFSR(poly, 1)
+        for i, l := range []bitSource{fib, gal} {
+            seen := 0
+            for n := 1; n <= period; n++ {
+                l.Bit()
+                if l.State() == 1 {
+                    seen = n
+                    break
+                }
+            }
+            if seen != period {
+                t.Fail()
+                t.Logf("poly %#x form %d expect period %d but get %d", poly, i, period, seen)
+            }
+        }
+
+        fib, _ = NewFibonacciLFSR(poly, 1)
+        gal, _ = NewGaloisLFSR(poly, 1)
+        if !checkRecurrence(lfsrBits(fib, 300), poly) || !checkRecurrence(lfsrBits(gal, 300), poly) {
+            t.Fail()
+            t.Logf("poly %#x output break the recurrence", poly)
+        }
+    }
+
+    //a primitive polynomial of degree 23 come back after 2^23-1 steps and not before
+    l, _ := NewFibonacciLFSR(PRBS23, 0x5A5A5)
+    l.Jump(1 << 23 - 1)
+    if l.State() != 0x5A5A5 {
+        t.Fail()
+        t.Logf("prbs23 jump period get %#x", l.State())
+    }
+    for _, divisor := range []uint64{47, 178481} {
+        l.Jump((1 << 23 - 1) / divisor)
+        if l.State() == 0x5A5A5 {
+            t.Fail()
+            t.Logf("prbs23 period divide by %d", divisor)
+        }
+        l.Jump(1 << 23 - 1 - (1 << 23 - 1) / divisor)
+    }
+}
+
+func TestLFSRJump(t *testing.T) {
+    for _, poly := range []uint64{PRBS7, PRBS31, Scrambler64b66b} {
+        for _, n := range []uint64{0, 1, 63, 64, 1000, 4097} {
+            fib, _ := NewFibonacciLFSR(poly, 0x1234567)
+            gal, _ := NewGaloisLFSR(poly, 0x1234567)
+            jumpFib, _ := NewFibonacciLFSR(poly, 0x1234567)
+            jumpGal, _ := NewGaloisLFSR(poly, 0x1234567)
+            for i := uint64(0); i < n; i++ {
+                fib.Bit()
+                gal.Bit()
+            }
+            jumpFib.Jump(n)
+            jumpGal.Jump(n)
+            if fib.State() != jumpFib.State() || gal.State() != jumpGal.State() {
+                t.Fail()
+                t.Logf("poly %#x jump %d get %#x %#x", poly, n, jumpFib.State(), jumpGal.State())
+            }
+        }
+    }
+}
+
+func TestLFSRBits(t *testing.T) {
+    //PRBS7 from the all-ones state start with 6 zeros
+    l, _ := NewFibonacciLFSR(PRBS7, 0x7F)
+    if value, _ := l.Bits(8); value != 0x02 {
+        t.Fail()
+        t.Logf("prbs7 first byte get %#x", value)
+    }
+
+    a, _ := NewGaloisLFSR(PRBS15, 0x1D)
+    b, _ := NewGaloisLFSR(PRBS15, 0x1D)
+    p := make([]byte, 16)
+    if n, err := a.Read(p); n != 16 || err != nil {
+        t.Fatalf("read get %d %v", n, err)
+    }
+    for i := range p {
+        if value, _ := b.Bits(8); byte(value) != p[i] {
+            t.Fatalf("byte %d expect %#x but get %#x", i, value, p[i])
+        }
+    }
+
+    if _, err := l.Bits(65); err == nil {
+        t.Fail()
+        t.Log("65 bits accepted")
+    }
+    for _, poly := range []uint64{0, 1, 0xC0} {
+        if _, err := NewFibonacciLFSR(poly, 1); err == nil {
+            t.Fail()
+            t.Logf("poly %#x accepted", poly)
+        }
+    }
+    if _, err := NewGaloisLFSR(PRBS7, 0x80); err == nil {
+        t.Fail()
+        t.Log("zero state accepted")
+    }
+}
+
+func TestScrambler(t *testing.T) {
+    data := bytes.Repeat([]byte("scrambler test 0000000000"), 8)
+
+    s, _ := NewAdditiveScrambler(PRBS15, 0x7FFF)
+    d, _ := NewAdditiveScrambler(PRBS15, 0x7FFF)
+    scrambled := make([]byte, len(data))
+    s.Scramble(scrambled, data)
+    if bytes.Equal(scrambled, data) {
+        t.Fatal("additive scrambler change nothing")
+    }
+    d.Scramble(scrambled, scrambled)
+    if !bytes.Equal(scrambled, data) {
+        t.Fail()
+        t.Logf("additive descramble get %q", scrambled)
+    }
+
+    //the descrambler recover the data after the first 58 bits whatever its state
+    m, _ := NewMultiplicativeScrambler(Scrambler64b66b, 0x3FFFFFFFFFFFFFF)
+    r, _ := NewMultiplicativeScrambler(Scrambler64b66b, 0)
+    m.Scramble(scrambled, data)
+    if bytes.Equal(scrambled, data) {
+        t.Fatal("multiplicative scrambler change nothing")
+    }
+    recovered := make([]byte, len(data))
+    r.Descramble(recovered, scrambled)
+    if !bytes.Equal(recovered[8:], data[8:]) {
+        t.Fail()
+        t.Logf("multiplicative descramble get %q", recovered)
+    }
+
+    //one flipped bit corrupt the data bit and one per tap
+    scrambled[20] ^= 0x80
+    r, _ = NewMultiplicativeScrambler(Scrambler64b66b, 0)
+    r.Descramble(recovered, scrambled)
+    diff, _ := hammingBytes(recovered[8:], data[8:])
+    if diff != 3 {
+        t.Fail()
+        t.Logf("one error multiplied to %d", diff)
+    }
+
+    if err := s.Scramble(make([]byte, 1), data); err == nil {
+        t.Fail()
+        t.Log("short dst accepted")
+    }
+    if err := m.Descramble(make([]byte, 1), data); err == nil {
+        t.Fail()
+        t.Log("short dst accepted")
+    }
+}
+
+// hammingBytes return the bits differing between a and b
+func hammingBytes(a []byte, b []byte) (uint, error) {
+    x := make([]byte, len(a))
+    if err := XorBytes(x, a, b); err != nil {
+        return 0, err
+    }
+    return PopCountBytes(x), nil
+}
+
+func TestPRBSChecker(t *testing.T) {
+    l, _ := NewFibonacciLFSR(PRBS31, 0x2468ACE)
+    stream := make([]byte, 4096)
+    l.Read(stream)
+    stream[0], stream[1], stream[2], stream[3] = 0, 0, 0, 0
+
+    c, _ := NewPRBSChecker(PRBS31)
+    c.Check(stream[:2])
+    if c.Locked() {
+        t.Fatal("locked on zeros")
+    }
+    c.Check(stream[2:100])
+    c.Check(stream[100:])
+    if !c.Locked() || c.Errors() != 0 || c.Bits() == 0 {
+        t.Fatalf("clean stream get %v %d errors in %d bits", c.Locked(), c.Errors(), c.Bits())
+    }
+    //the zero bytes before the first 1 and the 31 loading bits are not compared
+    first := 32 + uint64(CountLeadZero64(uint64(stream[4])) - 56)
+    if c.Bits() != 8 * 4096 - first - 31 {
+        t.Fail()
+        t.Logf("compared %d bits", c.Bits())
+    }
+
+    //the reference register is not disturbed by received errors
+    stream[1000] ^= 0x11
+    stream[3000] ^= 0x01
+    c.Reset()
+    c.Check(stream)
+    if c.Errors() != 3 {
+        t.Fail()
+        t.Logf("expect 3 errors but get %d", c.Errors())
+    }
+}
@@ -0,0 +1,282 @@
+package bitops
+
+import "fmt"
+
+// PackedArray store length elements of width bits back to back in []uint64, an
+// element may straddle two words. Signed arrays keep elements in two's complement
+type PackedArray struct {
+    width  uint
+    length uint
+    signed bool
+    words  []uint64
+}
+
+// number of words holding length elements of width bits
+func packedWords(width uint, length uint) (uint) {
+    return (width * length + 63) / 64
+}
+
+// real implementation for NewPackedArray and NewSignedPackedArray
+func newPackedArray(width uint, length uint, signed bool) (*PackedArray, error) {
+    if width == 0 || width > 64 {
+        return nil, fmt.Errorf("invalid width(%v)", width)
+    }
+    if length > ^uint(0) / width {
+        return nil, fmt.Errorf("invalid length(%v)", length)
+    }
+
+    return &PackedArray{width: width, length: length, signed: signed,
+                        words: make([]uint64, packedWords(width, length))}, nil
+}
+
+// NewPackedArray create an array of length unsigned elements of width bits, all 0
+func NewPackedArray(width uint, length uint) (*PackedArray, error) {
+    return newPackedArray(width, length, false)
+}
+
+// NewSignedPackedArray create an array of length signed elements of width bits,
+// all 0
+func NewSignedPackedArray(width uint, length uint) (*PackedArray, error) {
+    return newPackedArray(width, length, true)
+}
+
+// NewPackedArrayFor create an unsigned array with the smallest width holding
+// maxValue
+func NewPackedArrayFor(maxValue uint64, length uint) (*PackedArray, error) {
+    return newPackedArray(max(64 - CountLeadZero64(maxValue), 1), length, false)
+}
+
+// NewSignedPackedArrayFor create a signed array with the smallest width holding
+// every value between minValue and maxValue
+func NewSignedPackedArrayFor(minValue int64, maxValue int64, length uint) (*PackedArray, error) {
+    if minValue > maxValue {
+        return nil, fmt.Errorf("invalid min(%v) or max(%v)", minValue, maxValue)
+    }
+
+    // a value needs its significant bits plus a sign bit, leading 1s of a
+    // negative value are leading 0s of its complement
+    need := func(v int64) (uint) {
+        if v < 0 {
+            v = ^v
+        }
+        return 65 - CountLeadZero64(uint64(v))
+    }
+    return newPackedArray(max(need(minValue), need(maxValue)), length, true)
+}
+
+// Width return the number of bits of an element
+func (a *PackedArray) Width() (uint) {
+    return a.width
+}
+
+// Len return the number of elements
+func (a *PackedArray) Len() (uint) {
+    return a.length
+}
+
+// Signed return whether elements are signed
+func (a *PackedArray) Signed() (bool) {
+    return a.signed
+}
+
+// read the raw bits of element i, the index is already checked
+func (a *PackedArray) load(i uint) (uint64) {
+    bit := i * a.width
+    word, start := bit / 64, bit % 64
+    if start + a.width <= 64 {
+        value, _ := extract64(a.words[word], start, a.width)
+        return value
+    }
+
+    low := 64 - start
+    lo, _ := extract64(a.words[word], start, low)
+    hi, _ := extract64(a.words[word + 1], 0, a.width - low)
+    return lo | (hi << low)
+}
+
+// write the low width bits of value to element i, the index is already checked
+func (a *PackedArray) store(i uint, value uint64) {
+    bit := i * a.width
+    word, start := bit / 64, bit % 64
+    if start + a.width <= 64 {
+        a.words[word], _ = deposit64(a.words[word], start, a.width, value)
+        return
+    }
+
+    low := 64 - start
+    a.words[word], _ = deposit64(a.words[word], start, low, value)
+    a.words[word + 1], _ = deposit64(a.words[word + 1], 0, a.width - low, value >> low)
+}
+
+// check value fit the element width, value is two's complement for signed arrays
+func (a *PackedArray) fits(value uint64) (bool) {
+    shift := 64 - a.width
+    if a.signed {
+        return (int64(value << shift) >> shift) == int64(value)
+    }
+    return (value << shift) >> shift == value
+}
+
+// sign-extend raw element bits for signed arrays
+func (a *PackedArray) extend(value uint64) (uint64) {
+    if !a.signed {
+        return value
+    }
+
+    shift := 64 - a.width
+    return uint64(int64(value << shift) >> shift)
+}
+
+// Get return element i of an unsigned array
+func (a *PackedArray) Get(i uint) (uint64, error) {
+    if a.signed {
+        return 0, fmt.Errorf("signed array")
+    }
+    if i >= a.length {
+        return 0, fmt.Errorf("invalid index(%v)", i)
+    }
+
+    return a.load(i), nil
+}
+
+// Set write element i of an unsigned array and return error if value does not fit
+// the element width
+func (a *PackedArray) Set(i uint, value uint64) (error) {
+    if a.signed {
+        return fmt.Errorf("signed array")
+    }
+    if i >= a.length {
+        return fmt.Errorf("invalid index(%v)", i)
+    }
+    if !a.fits(value) {
+        return fmt.Errorf("invalid value(%v) for width(%v)", value, a.width)
+    }
+
+    a.store(i, value)
+    return nil
+}
+
+// GetSigned return element i of a signed array
+func (a *PackedArray) GetSigned(i uint) (int64, error) {
+    if !a.signed {
+        return 0, fmt.Errorf("unsigned array")
+    }
+    if i >= a.length {
+        return 0, fmt.Errorf("invalid index(%v)", i)
+    }
+
+    return int64(a.extend(a.load(i))), nil
+}
+
+// SetSigned write element i of a signed array and return error if value does not
+// fit the element width
+func (a *PackedArray) SetSigned(i uint, value int64) (error) {
+    if !a.signed {
+        return fmt.Errorf("unsigned array")
+    }
+    if i >= a.length {
+        return fmt.Errorf("invalid index(%v)", i)
+    }
+    if !a.fits(uint64(value)) {
+        return fmt.Errorf("invalid value(%v) for width(%v)", value, a.width)
+    }
+
+    a.store(i, uint64(value))
+    return nil
+}
+
+// Pack64 write src to the first len(src) elements. For signed arrays src hold
+// int64 values as two's complement. Nothing is written if error occurs
+func (a *PackedArray) Pack64(src []uint64) (error) {
+    if uint(len(src)) > a.length {
+        return fmt.Errorf("invalid source length(%v)", len(src))
+    }
+    for i, value := range src {
+        if !a.fits(value) {
+            return fmt.Errorf("invalid value(%v) at %v for width(%v)", value, i, a.width)
+        }
+    }
+
+    for i, value := range src {
+        a.store(uint(i), value)
+    }
+    return nil
+}
+
+// Pack32 write src to the first len(src) elements. For signed arrays src hold
+// int32 values as two's complement. Nothing is written if error occurs
+func (a *PackedArray) Pack32(src []uint32) (error) {
+    if uint(len(src)) > a.length {
+        return fmt.Errorf("invalid source length(%v)", len(src))
+    }
+    widen := func(value uint32) (uint64) {
+        if a.signed {
+            return uint64(int32(value))
+        }
+        return uint64(value)
+    }
+    for i, value := range src {
+        if !a.fits(widen(value)) {
+            return fmt.Errorf("invalid value(%v) at %v for width(%v)", value, i, a.width)
+        }
+    }
+
+    for i, value := range src {
+        a.store(uint(i), widen(value))
+    }
+    return nil
+}
+
+// Unpack64 copy every element to dst and return the number of elements. Signed
+// elements are sign-extended. It return error if dst is too short
+func (a *PackedArray) Unpack64(dst []uint64) (uint, error) {
+    if uint(len(dst)) < a.length {
+        return 0, fmt.Errorf("invalid destination length(%v)", len(dst))
+    }
+
+    for i := uint(0); i < a.length; i++ {
+        dst[i] = a.extend(a.load(i))
+    }
+    return a.length, nil
+}
+
+// Unpack32 copy every element to dst and return the number of elements. Signed
+// elements are sign-extended. It return error if dst is too short or elements are
+// wider than 32 bits
+func (a *PackedArray) Unpack32(dst []uint32) (uint, error) {
+    if a.width > 32 {
+        return 0, fmt.Errorf("invalid width(%v)", a.width)
+    }
+    if uint(len(dst)) < a.length {
+        return 0, fmt.Errorf("invalid destination length(%v)", len(dst))
+    }
+
+    for i := uint(0); i < a.length; i++ {
+        dst[i] = uint32(a.extend(a.load(i)))
+    }
+    return a.length, nil
+}
+
+// Resize change the number of elements, new elements are 0
+func (a *PackedArray) Resize(length uint) (error) {
+    if length > ^uint(0) / a.width {
+        return fmt.Errorf("invalid length(%v)", length)
+    }
+
+    n := packedWords(a.width, length)
+    if n > uint(cap(a.words)) {
+        words := make([]uint64, n)
+        copy(words, a.words)
+        a.words = words
+    } else {
+        a.words = a.words[:n]
+    }
+
+    // clear the bits past the last element so a later grow read 0
+    if used := (a.width * length) % 64; used != 0 {
+        a.words[n - 1] &= ^uint64(0) >> (64 - used)
+    }
+    clear(a.words[n:cap(a.words)])
+    a.length = length
+    return nil
+}
@@ -0,0 +1,220 @@
+package bitops
+
+import "testing"
+
+func TestPackedArray(t *testing.T) {
+    a, err := NewPackedArray(5, 100)
+    if err != nil || a.Width() != 5 || a.Len() != 100 || a.Signed() {
+        t.Fatalf("create get %v", err)
+    }
+    if len(a.words) != 8 {
+        t.Fail()
+        t.Logf("500 bits expect 8 words but get %d", len(a.words))
+    }
+
+    for i := uint(0); i < a.Len(); i++ {
+        if err = a.Set(i, uint64(i * 7) % 32); err != nil {
+            t.Fatal(err)
+        }
+    }
+    for i := uint(0); i < a.Len(); i++ {
+        if v, err := a.Get(i); err != nil || v != uint64(i * 7) % 32 {
+            t.Fail()
+            t.Logf("element %d expect %d but get %d", i, (i * 7) % 32, v)
+        }
+    }
+
+    //element 12 is bits 60..64 and straddle the first two words
+    a.Set(12, 0x1F)
+    if a.words[0] >> 60 != 0xF || a.words[1] & 1 != 1 {
+        t.Fail()
+        t.Logf("straddle get %x %x", a.words[0], a.words[1])
+    }
+
+    if err = a.Set(0, 32); err == nil {
+        t.Fail()
+        t.Log("value too wide")
+    }
+    if _, err = a.Get(100); err == nil {
+        t.Fail()
+        t.Log("index out of range")
+    }
+    if _, err = a.GetSigned(0); err == nil {
+        t.Fail()
+        t.Log("signed access on unsigned array")
+    }
+
+    for _, width := range []uint{0, 65} {
+        if _, err = NewPackedArray(width, 1); err == nil {
+            t.Fail()
+            t.Logf("width %d", width)
+        }
+    }
+}
+
+func TestSignedPackedArray(t *testing.T) {
+    a, err := NewSignedPackedArray(12, 20)
+    if err != nil || !a.Signed() {
+        t.Fatalf("create get %v", err)
+    }
+
+    for i := 0; i < 20; i++ {
+        if err = a.SetSigned(uint(i), int64(i * 211 - 2048)); err != nil {
+            t.Fatalf("element %d get %v", i, err)
+        }
+    }
+    for i := 0; i < 20; i++ {
+        if v, err := a.GetSigned(uint(i)); err != nil || v != int64(i * 211 - 2048) {
+            t.Fail()
+            t.Logf("element %d expect %d but get %d", i, i * 211 - 2048, v)
+        }
+    }
+
+    if err = a.SetSigned(0, 2048); err == nil {
+        t.Fail()
+        t.Log("2048 does not fit 12 bits")
+    }
+    if err = a.SetSigned(0, -2049); err == nil {
+        t.Fail()
+        t.Log("-2049 does not fit 12 bits")
+    }
+    if err = a.Set(0, 1); err == nil {
+        t.Fail()
+        t.Log("unsigned access on signed array")
+    }
+}
+
+func TestPackedArrayFor(t *testing.T) {
+    vectors := []struct {
+        max    uint64
+        expect uint
+    }{
+        {0, 1}, {1, 1}, {31, 5}, {32, 6}, {4095, 12}, {^uint64(0), 64},
+    }
+
+    for _, v := range vectors {
+        a, err := NewPackedArrayFor(v.max, 10)
+        if err != nil || a.Width() != v.expect {
+            t.Fail()
+            t.Logf("max %d expect width %d", v.max, v.expect)
+            continue
+        }
+        if err = a.Set(9, v.max); err != nil {
+            t.Fail()
+            t.Logf("max %d get %v", v.max, err)
+        }
+    }
+
+    signed := []struct {
+        min    int64
+        max    int64
+        expect uint
+    }{
+        {0, 0, 1}, {-1, 0, 1}, {-16, 15, 5}, {-17, 15, 6}, {-16, 16, 6}, {-1 << 63, 0, 64},
+    }
+
+    for _, v := range signed {
+        a, err := NewSignedPackedArrayFor(v.min, v.max, 2)
+        if err != nil || a.Width() != v.expect {
+            t.Fail()
+            t.Logf("[%d, %d] expect width %d", v.min, v.max, v.expect)
+            continue
+        }
+        if a.SetSigned(0, v.min) != nil || a.SetSigned(1, v.max) != nil {
+            t.Fail()
+            t.Logf("[%d, %d] does not fit", v.min, v.max)
+        }
+    }
+
+    if _, err := NewSignedPackedArrayFor(1, 0, 1); err == nil {
+        t.Fail()
+        t.Log("min larger than max")
+    }
+}
+
+func TestPackedArrayPack(t *testing.T) {
+    a, _ := NewPackedArray(12, 6)
+    src := []uint32{0xFFF, 0x123, 0, 0xABC, 1, 0x800}
+    if err := a.Pack32(src); err != nil {
+        t.Fatal(err)
+    }
+
+    dst := make([]uint32, 6)
+    if n, err := a.Unpack32(dst); err != nil || n != 6 {
+        t.Fatalf("unpack get %d %v", n, err)
+    }
+    for i := range src {
+        if dst[i] != src[i] {
+            t.Fail()
+            t.Logf("element %d expect %x but get %x", i, src[i], dst[i])
+        }
+    }
+
+    if err := a.Pack32([]uint32{1, 0x1000}); err == nil {
+        t.Fail()
+        t.Log("value too wide")
+    } else if v, _ := a.Get(0); v != 0xFFF {
+        t.Fail()
+        t.Log("failed pack must not write")
+    }
+    if _, err := a.Unpack32(dst[:5]); err == nil {
+        t.Fail()
+        t.Log("destination too short")
+    }
+
+    s, _ := NewSignedPackedArray(40, 3)
+    if err := s.Pack64([]uint64{uint64(1 << 39 - 1), ^uint64(0), uint64(1) << 63 >> 24}); err == nil {
+        t.Fail()
+        t.Log("2^39 does not fit a signed 40-bit element")
+    }
+    if err := s.Pack64([]uint64{uint64(1 << 39 - 1), ^uint64(0)}); err != nil {
+        t.Fatal(err)
+    }
+    out := make([]uint64, 3)
+    if n, err := s.Unpack64(out); err != nil || n != 3 || out[0] != 1 << 39 - 1 || out[1] != ^uint64(0) || out[2] != 0 {
+        t.Fail()
+        t.Logf("signed unpack get %x", out)
+    }
+    if _, err := s.Unpack32(make([]uint32, 3)); err == nil {
+        t.Fail()
+        t.Log("40-bit elements into uint32")
+    }
+
+    neg, _ := NewSignedPackedArray(7, 2)
+    neg.Pack32([]uint32{uint32(0xFFFFFFC0), 63})
+    if v, _ := neg.GetSigned(0); v != -64 {
+        t.Fail()
+        t.Logf("signed pack32 get %d", v)
+    }
+}
+
+func TestPackedArrayResize(t *testing.T) {
+    a, _ := NewPackedArray(7, 20)
+    for i := uint(0); i < 20; i++ {
+        a.Set(i, 0x7F)
+    }
+
+    a.Resize(10)
+    if a.Len() != 10 {
+        t.Fail()
+        t.Logf("shrink get %d", a.Len())
+    }
+    a.Resize(30)
+    for i := uint(0); i < 30; i++ {
+        expect := uint64(0)
+        if i < 10 {
+            expect = 0x7F
+        }
+        if v, err := a.Get(i); err != nil || v != expect {
+            t.Fail()
+            t.Logf("element %d expect %x but get %x", i, expect, v)
+        }
+    }
+
+    a.Resize(0)
+    a.Resize(1)
+    if v, _ := a.Get(0); v != 0 {
+        t.Fail()
+        t.Log("grow after empty")
+    }
+}
@@ -0,0 +1,182 @@
+package bitops
+
+// Portable SWAR/branch implementations of the counting, reversing and rotating
+// functions. By default the exported functions use math/bits which the compiler
+// turns into POPCNT/LZCNT/TZCNT/ROR, build with -tags purego to use these instead.
+// They are always compiled so they can be benchmarked and tested side by side
+
+// portable SWAR version of CountOne8
+func portableCountOne8(value uint8) (uint) {
+    value = (value & 0x55) + ((value >> 1) & 0x55);
+    value = (value & 0x33) + ((value >> 2) & 0x33);
+    value = (value & 0x0f) + ((value >> 4) & 0x0f);
+
+    return uint(value);
+}
+
+// portable SWAR version of CountOne16
+func portableCountOne16(value uint16) (uint) {
+    value = (value & 0x5555) + ((value >> 1) & 0x5555);
+    value = (value & 0x3333) + ((value >> 2) & 0x3333);
+    value = (value & 0x0f0f) + ((value >> 4) & 0x0f0f);
+    value = (value & 0x00ff) + ((value >> 8) & 0x00ff);
+
+    return uint(value);
+}
+
+// portable SWAR version of CountOne32
+func portableCountOne32(value uint32) (uint) {
+    value = (value & 0x55555555) + ((value >>  1) & 0x55555555);
+    value = (value & 0x33333333) + ((value >>  2) & 0x33333333);
+    value = (value & 0x0f0f0f0f) + ((value >>  4) & 0x0f0f0f0f);
+    value = (value & 0x00ff00ff) + ((value >>  8) & 0x00ff00ff);
+    value = (value & 0x0000ffff) + ((value >> 16) & 0x0000ffff);
+
+    return uint(value);
+}
+
+// portable SWAR version of CountOne64
+func portableCountOne64(value uint64) (uint) {
+    value = (value & 0x5555555555555555) + ((value >>  1) & 0x5555555555555555);
+    value = (value & 0x3333333333333333) + ((value >>  2) & 0x3333333333333333);
+    value = (value & 0x0f0f0f0f0f0f0f0f) + ((value >>  4) & 0x0f0f0f0f0f0f0f0f);
+    value = (value & 0x00ff00ff00ff00ff) + ((value >>  8) & 0x00ff00ff00ff00ff);
+    value = (value & 0x0000ffff0000ffff) + ((value >> 16) & 0x0000ffff0000ffff);
+    value = (value & 0x00000000ffffffff) + ((value >> 32) & 0x00000000ffffffff);
+
+    return uint(value);
+}
+
+// portable SWAR version of CountTrailZero32
+func portableCountTrailZero32(value uint32) (uint) {
+    var count uint = 0
+
+    if (value & 0x0000FFFF) == 0 {
+        count += 16;
+        value >>= 16;
+    }
+    if (value & 0x000000FF) == 0 {
+        count += 8;
+        value >>= 8;
+    }
+    if (value & 0x0000000F) == 0 {
+        count += 4;
+        value >>= 4;
+    }
+    if (value & 0x00000003) == 0 {
+        count += 2;
+        value >>= 2;
+    }
+    if (value & 0x00000001) == 0 {
+        count++;
+        value >>= 1;
+    }
+    if (value & 0x00000001) == 0 {
+        count++;
+    }
+
+    return count
+}
+
+// portable SWAR version of CountTrailZero64
+func portableCountTrailZero64(value uint64) (uint) {
+    var count uint = 0
+
+    if  uint32(value) == 0 {
+        count += 32;
+        value >>= 32;
+    }
+
+    return count + portableCountTrailZero32(uint32(value))
+}
+
+// portable SWAR version of CountLeadZero32
+func portableCountLeadZero32(value uint32) (uint) {
+    var count uint = 0
+
+    if (value & 0xFFFF0000) == 0 {
+        count += 16;
+        value <<= 16;
+    }
+    if (value & 0xFF000000) == 0 {
+        count += 8;
+        value <<= 8;
+    }
+    if (value & 0xF0000000) == 0 {
+        count += 4;
+        value <<= 4;
+    }
+    if (value & 0xC0000000) == 0 {
+        count += 2;
+        value <<= 2;
+    }
+    if (value & 0x80000000) == 0 {
+        count++;
+        value <<= 1;
+    }
+    if (value & 0x80000000) == 0 {
+        count++;
+    }
+
+    return count
+}
+
+// portable SWAR version of CountLeadZero64
+func portableCountLeadZero64(value uint64) (uint) {
+    var count uint = 0
+
+    if (value >> 32) == 0 {
+        count += 32
+    } else {
+        value >>= 32;
+    }
+
+    return count + portableCountLeadZero32(uint32(value))
+}
+
+// portable SWAR version of Reverse32
+func portableReverse32(value uint32) (uint32) {
+    value = ((value >> 1) & 0x55555555) | ((value & 0x55555555) << 1)
+    value = ((value >> 2) & 0x33333333) | ((value & 0x33333333) << 2)
+    value = ((value >> 4) & 0x0F0F0F0F) | ((value & 0x0F0F0F0F) << 4)
+    value = ((value >> 8) & 0x00FF00FF) | ((value & 0x00FF00FF) << 8)
+    value = ( value >> 16             ) | ( value               << 16)
+
+    return value
+}
+
+// portable SWAR version of Reverse64
+func portableReverse64(value uint64) (uint64) {
+    high :=  portableReverse32(uint32(value))
+    low :=  portableReverse32(uint32(value >> 32))
+
+    return (uint64(high) << 32) | uint64(low)
+}
+
+// portable SWAR version of RotateRight32
+func portableRotateRight32(value uint32, shift uint) (uint32) {
+    shift = shift & 0x1F
+
+    return (value >> shift) | (value << (32 - shift))
+}
+
+// portable SWAR version of RotateLeft32
+func portableRotateLeft32(value uint32, shift uint) (uint32) {
+    shift = shift & 0x1F
+
+    return (value << shift) | (value >> (32 - shift))
+}
+
+// portable SWAR version of RotateRight64
+func portableRotateRight64(value uint64, shift uint) (uint64) {
+    shift = shift & 0x3F
+
+    return (value >> shift) | (value << (64 - shift))
+}
+
+// portable SWAR version of RotateLeft64
+func portableRotateLeft64(value uint64, shift uint) (uint64) {
+    shift = shift & 0x3F
+
+    return (value << shift) | (value >> (64 - shift))
+}
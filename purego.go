@@ -0,0 +1,64 @@
+//go:build purego
+
+package bitops
+
+// portable versions selected by the purego build tag, see portable.go
+
+// the portable popcount is slow enough for carry-save blocks to pay off
+const fastCountOne = false
+
+func countOne8(value uint8) (uint) {
+    return portableCountOne8(value)
+}
+
+func countOne16(value uint16) (uint) {
+    return portableCountOne16(value)
+}
+
+func countOne32(value uint32) (uint) {
+    return portableCountOne32(value)
+}
+
+func countOne64(value uint64) (uint) {
+    return portableCountOne64(value)
+}
+
+func countTrailZero32(value uint32) (uint) {
+    return portableCountTrailZero32(value)
+}
+
+func countTrailZero64(value uint64) (uint) {
+    return portableCountTrailZero64(value)
+}
+
+func countLeadZero32(value uint32) (uint) {
+    return portableCountLeadZero32(value)
+}
+
+func countLeadZero64(value uint64) (uint) {
+    return portableCountLeadZero64(value)
+}
+
+func reverse32(value uint32) (uint32) {
+    return portableReverse32(value)
+}
+
+func reverse64(value uint64) (uint64) {
+    return portableReverse64(value)
+}
+
+func rotateRight32(value uint32, shift uint) (uint32) {
+    return portableRotateRight32(value, shift)
+}
+
+func rotateLeft32(value uint32, shift uint) (uint32) {
+    return portableRotateLeft32(value, shift)
+}
+
+func rotateRight64(value uint64, shift uint) (uint64) {
+    return portableRotateRight64(value, shift)
+}
+
+func rotateLeft64(value uint64, shift uint) (uint64) {
+    return portableRotateLeft64(value, shift)
+}
@@ -0,0 +1,263 @@
+package bitops
+
+import (
+    "errors"
+    "fmt"
+    "slices"
+)
+
+// ErrUncorrectable is returned when a codeword has more errors than the code can
+// correct
+var ErrUncorrectable = errors.New("uncorrectable codeword")
+
+// ReedSolomon is a systematic Reed-Solomon code over GF(2^8) with PolyGF256. A
+// codeword is the data symbols followed by the parity symbols, data+parity is at
+// most 255 and shorter codes are shortened ones. The generator roots are x^0 to
+// x^(parity-1) as in QR codes, so up to parity erasures or parity/2 errors are
+// corrected, or any mix with 2*errors+erasures <= parity
+type ReedSolomon struct {
+    field  *GaloisField
+    data   int
+    parity int
+    //generator polynomial, highest degree first with gen[0] = 1
+    gen    []uint16
+}
+
+// NewReedSolomon create a code with data and parity symbols per codeword, the error
+// is returned if either is 0 or the codeword is longer than 255
+func NewReedSolomon(data int, parity int) (*ReedSolomon, error) {
+    if data <= 0 || parity <= 0 || data + parity > 255 {
+        return nil, fmt.Errorf("invalid data(%v) or parity(%v)", data, parity)
+    }
+
+    f, _ := NewGaloisField(8, PolyGF256)
+    gen := []uint16{1}
+    for j := 0; j < parity; j++ {
+        //multiply by (x - x^j)
+        root := f.Exp(j)
+        next := make([]uint16, len(gen) + 1)
+        for i, g := range gen {
+            next[i] ^= g
+            next[i + 1] ^= f.Mul(g, root)
+        }
+        gen = next
+    }
+    return &ReedSolomon{field: f, data: data, parity: parity, gen: gen}, nil
+}
+
+// DataLen return the number of data symbols of a codeword
+func (rs *ReedSolomon) DataLen() (int) {
+    return rs.data
+}
+
+// ParityLen return the number of parity symbols of a codeword
+func (rs *ReedSolomon) ParityLen() (int) {
+    return rs.parity
+}
+
+// Encode return the codeword of data, data followed by its parity. The error is
+// returned if data is not DataLen long
+func (rs *ReedSolomon) Encode(data []byte) ([]byte, error) {
+    if len(data) != rs.data {
+        return nil, fmt.Errorf("invalid data length(%v), need %v", len(data), rs.data)
+    }
+
+    //remainder of data * x^parity divided by the generator, highest degree first
+    rem := make([]uint16, rs.parity)
+    for _, d := range data {
+        feedback := uint16(d) ^ rem[0]
+        copy(rem, rem[1:])
+        rem[rs.parity - 1] = 0
+        if feedback != 0 {
+            for i := range rem {
+                rem[i] ^= rs.field.Mul(feedback, rs.gen[i + 1])
+            }
+        }
+    }
+
+    codeword := make([]byte, 0, rs.data + rs.parity)
+    codeword = append(codeword, data...)
+    for _, r := range rem {
+        codeword = append(codeword, byte(r))
+    }
+    return codeword, nil
+}
+
+// syndromes of codeword, low degree first, and whether they are all 0
+func (rs *ReedSolomon) syndromes(codeword []byte) ([]uint16, bool) {
+    s := make([]uint16, rs.parity)
+    clean := true
+    for j := range s {
+        root := rs.field.Exp(j)
+        for _, c := range codeword {
+            s[j] = rs.field.Mul(s[j], root) ^ uint16(c)
+        }
+        clean = clean && s[j] == 0
+    }
+    return s, clean
+}
+
+// value of the polynomial p, low degree first, at x
+func (rs *ReedSolomon) eval(p []uint16, x uint16) (uint16) {
+    y := uint16(0)
+    for i := len(p) - 1; i >= 0; i-- {
+        y = rs.field.Mul(y, x) ^ p[i]
+    }
+    return y
+}
+
+// locator x^k of the symbol at pos, the first symbol has the highest degree
+func (rs *ReedSolomon) locator(pos int) (uint16) {
+    return rs.field.Exp(rs.data + rs.parity - 1 - pos)
+}
+
+// erasure locator, the product of (1 - X x) of every erasure, low degree first
+func (rs *ReedSolomon) erasureLocator(erasures []int) ([]uint16, error) {
+    gamma := []uint16{1}
+    seen := make(map[int]bool)
+    for _, pos := range erasures {
+        if pos < 0 || pos >= rs.data + rs.parity || seen[pos] {
+            return nil, fmt.Errorf("invalid erasure(%v)", pos)
+        }
+        seen[pos] = true
+        x := rs.locator(pos)
+        next := make([]uint16, len(gamma) + 1)
+        for i, g := range gamma {
+            next[i] ^= g
+            next[i + 1] ^= rs.field.Mul(g, x)
+        }
+        gamma = next
+    }
+    return gamma, nil
+}
+
+// correct the errors of codeword located by lambda, low degree first, with the
+// Chien search and the Forney algorithm. It return the number of corrections
+func (rs *ReedSolomon) correct(codeword []byte, s []uint16, lambda []uint16) (int, error) {
+    for len(lambda) > 1 && lambda[len(lambda) - 1] == 0 {
+        lambda = lambda[:len(lambda) - 1]
+    }
+
+    //omega = s * lambda mod x^parity
+    omega := make([]uint16, rs.parity)
+    for i, l := range lambda {
+        for j := 0; i + j < rs.parity; j++ {
+            omega[i + j] ^= rs.field.Mul(l, s[j])
+        }
+    }
+    //formal derivative, only odd terms survive in characteristic 2
+    derivative := make([]uint16, max(len(lambda) - 1, 1))
+    for i := 1; i < len(lambda); i += 2 {
+        derivative[i - 1] = lambda[i]
+    }
+
+    fixed := slices.Clone(codeword)
+    roots := 0
+    for pos := range fixed {
+        x := rs.locator(pos)
+        inv, _ := rs.field.Inverse(x)
+        if rs.eval(lambda, inv) != 0 {
+            continue
+        }
+        roots++
+        denominator := rs.eval(derivative, inv)
+        if denominator == 0 {
+            return 0, ErrUncorrectable
+        }
+        e, _ := rs.field.Div(rs.field.Mul(x, rs.eval(omega, inv)), denominator)
+        fixed[pos] ^= byte(e)
+    }
+    if roots != len(lambda) - 1 {
+        return 0, ErrUncorrectable
+    }
+    if _, clean := rs.syndromes(fixed); !clean {
+        return 0, ErrUncorrectable
+    }
+
+    changed := 0
+    for i := range fixed {
+        if fixed[i] != codeword[i] {
+            changed++
+        }
+    }
+    copy(codeword, fixed)
+    return changed, nil
+}
+
+// check the codeword length and the erasures, return the syndromes and the erasure
+// locator
+func (rs *ReedSolomon) prepare(codeword []byte, erasures []int) ([]uint16, bool, []uint16, error) {
+    if len(codeword) != rs.data + rs.parity {
+        return nil, false, nil, fmt.Errorf("invalid codeword length(%v), need %v", len(codeword), rs.data + rs.parity)
+    }
+    if len(erasures) > rs.parity {
+        return nil, false, nil, ErrUncorrectable
+    }
+    gamma, err := rs.erasureLocator(erasures)
+    if err != nil {
+        return nil, false, nil, err
+    }
+    s, clean := rs.syndromes(codeword)
+    return s, clean, gamma, nil
+}
+
+// DecodeErasures correct in place the symbols of codeword at the erasure positions
+// assuming every other symbol is right, up to ParityLen erasures. It return the
+// number of symbols changed, or ErrUncorrectable and leave codeword unchanged if
+// the codeword is still wrong after the correction
+func (rs *ReedSolomon) DecodeErasures(codeword []byte, erasures []int) (int, error) {
+    s, clean, gamma, err := rs.prepare(codeword, erasures)
+    if err != nil || clean {
+        return 0, err
+    }
+    return rs.correct(codeword, s, gamma)
+}
+
+// Decode correct in place the errors of codeword, erasures give the positions
+// known to be wrong and may be nil. Locations are found by Berlekamp-Massey
+// initialised with the erasure locator, so 2*errors+erasures up to ParityLen are
+// corrected. It return the number of symbols changed, or ErrUncorrectable and
+// leave codeword unchanged
+func (rs *ReedSolomon) Decode(codeword []byte, erasures []int) (int, error) {
+    s, clean, gamma, err := rs.prepare(codeword, erasures)
+    if err != nil || clean {
+        return 0, err
+    }
+
+    //Berlekamp-Massey over the syndromes, polynomials low degree first
+    v := len(erasures)
+    lambda, b := slices.Clone(gamma), slices.Clone(gamma)
+    length := v
+    for r := v; r < rs.parity; r++ {
+        delta := uint16(0)
+        for i := 0; i < len(lambda) && i <= r; i++ {
+            delta ^= rs.field.Mul(lambda[i], s[r - i])
+        }
+
+        b = append([]uint16{0}, b...)
+        if delta == 0 {
+            continue
+        }
+        next := slices.Clone(lambda)
+        for len(next) < len(b) {
+            next = append(next, 0)
+        }
+        for i, c := range b {
+            next[i] ^= rs.field.Mul(delta, c)
+        }
+        if 2 * length <= r + v {
+            length = r + v + 1 - length
+            inv, _ := rs.field.Inverse(delta)
+            b = make([]uint16, len(lambda))
+            for i, c := range lambda {
+                b[i] = rs.field.Mul(c, inv)
+            }
+        }
+        lambda = next
+    }
+
+    if 2 * length - v > rs.parity {
+        return 0, ErrUncorrectable
+    }
+    return rs.correct(codeword, s, lambda)
+}
@@ -0,0 +1,162 @@
+package bitops
+
+import (
+    "bytes"
+    "math/rand"
+    "testing"
+)
+
+// corrupt count distinct random positions of codeword with non-zero errors
+func corrupt(rng *rand.Rand, codeword []byte, count int) ([]int) {
+    positions := rng.Perm(len(codeword))[:count]
+    for _, pos := range positions {
+        codeword[pos] ^= byte(rng.Intn(255) + 1)
+    }
+    return positions
+}
+
+func TestReedSolomonQR(t *testing.T) {
+    //1-M QR code example of ISO/IEC 18004 annex I
+    data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+    rs, err := NewReedSolomon(16, 10)
+    if err != nil {
+        t.Fatal(err)
+    }
+    codeword, err := rs.Encode(data)
+    if expect := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}; err != nil || !bytes.Equal(codeword[16:], expect) {
+        t.Fail()
+        t.Logf("parity expect %v but get %v %v", expect, codeword[16:], err)
+    }
+    if !bytes.Equal(codeword[:16], data) {
+        t.Fail()
+        t.Log("code is not systematic")
+    }
+    if n, err := rs.Decode(codeword, nil); err != nil || n != 0 {
+        t.Fail()
+        t.Logf("clean codeword get %d %v", n, err)
+    }
+}
+
+func TestReedSolomonErrors(t *testing.T) {
+    rng := rand.New(rand.NewSource(1))
+    for _, size := range [][2]int{{223, 32}, {16, 10}, {1, 2}, {200, 55}, {10, 7}} {
+        rs, err := NewReedSolomon(size[0], size[1])
+        if err != nil {
+            t.Fatal(err)
+        }
+        for trial := 0; trial < 20; trial++ {
+            data := make([]byte, size[0])
+            rng.Read(data)
+            codeword, _ := rs.Encode(data)
+            received := bytes.Clone(codeword)
+            //up to the correction limit
+            count := rng.Intn(size[1] / 2 + 1)
+            corrupt(rng, received, count)
+            n, err := rs.Decode(received, nil)
+            if err != nil || n != count || !bytes.Equal(received, codeword) {
+                t.Fatalf("rs(%d,%d) with %d errors get %d %v", size[0] + size[1], size[0], count, n, err)
+            }
+        }
+    }
+}
+
+func TestReedSolomonErasures(t *testing.T) {
+    rng := rand.New(rand.NewSource(2))
+    rs, _ := NewReedSolomon(100, 20)
+    for trial := 0; trial < 50; trial++ {
+        data := make([]byte, 100)
+        rng.Read(data)
+        codeword, _ := rs.Encode(data)
+
+        //erasures only, up to parity
+        received := bytes.Clone(codeword)
+        erasures := corrupt(rng, received, rng.Intn(21))
+        if n, err := rs.DecodeErasures(received, erasures); err != nil || n != len(erasures) || !bytes.Equal(received, codeword) {
+            t.Fatalf("%d erasures get %d %v", len(erasures), n, err)
+        }
+
+        //errors and erasures with 2*errors+erasures <= parity
+        received = bytes.Clone(codeword)
+        errCount := rng.Intn(11)
+        positions := corrupt(rng, received, errCount + rng.Intn(21 - 2 * errCount))
+        erasures = positions[errCount:]
+        if n, err := rs.Decode(received, erasures); err != nil || n != len(positions) || !bytes.Equal(received, codeword) {
+            t.Fatalf("%d errors and %d erasures get %d %v", errCount, len(erasures), n, err)
+        }
+
+        //an erased symbol that is right is not changed
+        received = bytes.Clone(codeword)
+        if n, err := rs.Decode(received, []int{3, 50}); err != nil || n != 0 {
+            t.Fatalf("right erasures get %d %v", n, err)
+        }
+    }
+}
+
+func TestReedSolomonUncorrectable(t *testing.T) {
+    rng := rand.New(rand.NewSource(3))
+    rs, _ := NewReedSolomon(200, 16)
+    detected := 0
+    for trial := 0; trial < 50; trial++ {
+        data := make([]byte, 200)
+        rng.Read(data)
+        codeword, _ := rs.Encode(data)
+        received := bytes.Clone(codeword)
+        corrupt(rng, received, 9)
+        saved := bytes.Clone(received)
+        if _, err := rs.Decode(received, nil); err == ErrUncorrectable {
+            detected++
+            if !bytes.Equal(received, saved) {
+                t.Fatal("codeword changed on failure")
+            }
+        }
+    }
+    //a miscorrection to another codeword is possible but rare
+    if detected < 45 {
+        t.Fail()
+        t.Logf("only %d of 50 uncorrectable codewords detected", detected)
+    }
+
+    //erasure decoding with an extra error
+    data := make([]byte, 200)
+    codeword, _ := rs.Encode(data)
+    erasures := corrupt(rng, codeword, 5)
+    if _, err := rs.DecodeErasures(codeword, erasures[1:]); err != ErrUncorrectable {
+        t.Fail()
+        t.Logf("erasures with errors get %v", err)
+    }
+}
+
+func TestReedSolomonInvalid(t *testing.T) {
+    for _, size := range [][2]int{{0, 4}, {4, 0}, {250, 6}} {
+        if _, err := NewReedSolomon(size[0], size[1]); err == nil {
+            t.Fail()
+            t.Logf("size %v should be invalid", size)
+        }
+    }
+    rs, _ := NewReedSolomon(10, 4)
+    if _, err := rs.Encode(make([]byte, 9)); err == nil {
+        t.Fail()
+        t.Log("short data")
+    }
+    codeword := make([]byte, 14)
+    if _, err := rs.Decode(codeword[:13], nil); err == nil {
+        t.Fail()
+        t.Log("short codeword")
+    }
+    if _, err := rs.Decode(codeword, []int{14}); err == nil {
+        t.Fail()
+        t.Log("erasure out of range")
+    }
+    if _, err := rs.Decode(codeword, []int{1, 1}); err == nil {
+        t.Fail()
+        t.Log("duplicated erasure")
+    }
+    if _, err := rs.DecodeErasures(codeword, []int{0, 1, 2, 3, 4}); err != ErrUncorrectable {
+        t.Fail()
+        t.Log("more erasures than parity")
+    }
+    if rs.DataLen() != 10 || rs.ParityLen() != 4 {
+        t.Fail()
+        t.Log("lengths")
+    }
+}
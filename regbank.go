@@ -0,0 +1,221 @@
+package bitops
+
+import (
+    "fmt"
+    "sync"
+)
+
+// RegisterBank simulate a block of memory-mapped registers for testing drivers
+// without hardware. Software accesses through Read and Write follow the access
+// type of every field, bits not covered by a field read as 0 and ignore writes.
+// Peek and Poke play the hardware side and bypass the access rules
+type RegisterBank struct {
+    mu       sync.Mutex
+    regs     []Register
+    byOffset map[uint64]int
+    byName   map[string]int
+    values   []uint64
+    onRead   map[int][]func(uint64)
+    onWrite  map[int][]func(uint64)
+}
+
+// NewRegisterBank create a bank of registers at their reset values. It return error
+// if a register is invalid or two registers share a name or an offset
+func NewRegisterBank(regs []Register) (*RegisterBank, error) {
+    b := &RegisterBank{regs: make([]Register, len(regs)), byOffset: make(map[uint64]int),
+                       byName: make(map[string]int), values: make([]uint64, len(regs)),
+                       onRead: make(map[int][]func(uint64)), onWrite: make(map[int][]func(uint64))}
+    for i, r := range regs {
+        if err := r.Validate(); err != nil {
+            return nil, err
+        }
+        if _, ok := b.byName[r.Name]; ok {
+            return nil, fmt.Errorf("duplicated register(%v)", r.Name)
+        }
+        if _, ok := b.byOffset[r.Offset]; ok {
+            return nil, fmt.Errorf("duplicated offset(%#x) of register(%v)", r.Offset, r.Name)
+        }
+
+        b.regs[i] = r.Clone()
+        b.byName[r.Name] = i
+        b.byOffset[r.Offset] = i
+        b.values[i] = r.ResetValue()
+    }
+    return b, nil
+}
+
+// Registers return the register descriptions of the bank
+func (b *RegisterBank) Registers() ([]Register) {
+    regs := make([]Register, len(b.regs))
+    for i := range regs {
+        regs[i] = b.regs[i].Clone()
+    }
+    return regs
+}
+
+// Register return the description of the register of the given name
+func (b *RegisterBank) Register(name string) (Register, bool) {
+    i, ok := b.byName[name]
+    if !ok {
+        return Register{}, false
+    }
+    return b.regs[i].Clone(), true
+}
+
+// index of the register at offset
+func (b *RegisterBank) lookup(offset uint64) (int, error) {
+    i, ok := b.byOffset[offset]
+    if !ok {
+        return 0, fmt.Errorf("invalid offset(%#x)", offset)
+    }
+    return i, nil
+}
+
+// Reset put every register back to its reset value, hooks are not called
+func (b *RegisterBank) Reset() {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    for i, r := range b.regs {
+        b.values[i] = r.ResetValue()
+    }
+}
+
+// Read return the register at offset as software see it. Write-only and reserved
+// fields read as 0 and read-clear fields are cleared after the read. It return
+// error if no register is at offset or no field of the register is readable
+func (b *RegisterBank) Read(offset uint64) (uint64, error) {
+    i, err := b.lookup(offset)
+    if err != nil {
+        return 0, err
+    }
+
+    b.mu.Lock()
+    r := &b.regs[i]
+    value, readable := uint64(0), false
+    for _, f := range r.Fields {
+        if f.Access.readable() {
+            value |= b.values[i] & f.Mask()
+            readable = true
+        }
+        if f.Access == AccessRC {
+            b.values[i] &^= f.Mask()
+        }
+    }
+    if !readable {
+        b.mu.Unlock()
+        return 0, fmt.Errorf("read write-only register(%v)", r.Name)
+    }
+    hooks := b.onRead[i]
+    b.mu.Unlock()
+
+    for _, hook := range hooks {
+        hook(value)
+    }
+    return value, nil
+}
+
+// Write store value to the register at offset as software write it. Read-only and
+// read-clear fields ignore the write, W1C/W1S fields clear/set the bits written as
+// 1. It return error and change nothing if no register is at offset, value is
+// wider than the register, a reserved bit is written 1 or no field is writable
+func (b *RegisterBank) Write(offset uint64, value uint64) (error) {
+    i, err := b.lookup(offset)
+    if err != nil {
+        return err
+    }
+    r := &b.regs[i]
+    if r.Width < 64 && value >> r.Width != 0 {
+        return fmt.Errorf("invalid value(%#x) for register(%v)", value, r.Name)
+    }
+
+    b.mu.Lock()
+    stored, writable := b.values[i], false
+    for _, f := range r.Fields {
+        mask := f.Mask()
+        switch f.Access {
+        case AccessRW, AccessWO:
+            stored = (stored &^ mask) | (value & mask)
+        case AccessW1C:
+            stored &^= value & mask
+        case AccessW1S:
+            stored |= value & mask
+        case AccessReserved:
+            if value & mask != 0 {
+                b.mu.Unlock()
+                return fmt.Errorf("write 1 to reserved field(%v.%v)", r.Name, f.Name)
+            }
+        }
+        writable = writable || f.Access.writable()
+    }
+    if !writable {
+        b.mu.Unlock()
+        return fmt.Errorf("write read-only register(%v)", r.Name)
+    }
+    b.values[i] = stored
+    hooks := b.onWrite[i]
+    b.mu.Unlock()
+
+    for _, hook := range hooks {
+        hook(value)
+    }
+    return nil
+}
+
+// Peek return the stored value of the register at offset without side effect
+func (b *RegisterBank) Peek(offset uint64) (uint64, error) {
+    i, err := b.lookup(offset)
+    if err != nil {
+        return 0, err
+    }
+
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    return b.values[i], nil
+}
+
+// Poke store value to the register at offset as hardware do, ignoring access
+// types and hooks. Bits outside the fields are dropped
+func (b *RegisterBank) Poke(offset uint64, value uint64) (error) {
+    i, err := b.lookup(offset)
+    if err != nil {
+        return err
+    }
+
+    mask := uint64(0)
+    for _, f := range b.regs[i].Fields {
+        mask |= f.Mask()
+    }
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    b.values[i] = value & mask
+    return nil
+}
+
+// OnRead call fn with the value software read from the named register after every
+// Read. Hooks run without the bank lock and may use Peek and Poke
+func (b *RegisterBank) OnRead(name string, fn func(value uint64)) (error) {
+    i, ok := b.byName[name]
+    if !ok {
+        return fmt.Errorf("invalid register(%v)", name)
+    }
+
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    b.onRead[i] = append(b.onRead[i], fn)
+    return nil
+}
+
+// OnWrite call fn with the value software wrote to the named register after every
+// successful Write. Hooks run without the bank lock and may use Peek and Poke
+func (b *RegisterBank) OnWrite(name string, fn func(value uint64)) (error) {
+    i, ok := b.byName[name]
+    if !ok {
+        return fmt.Errorf("invalid register(%v)", name)
+    }
+
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    b.onWrite[i] = append(b.onWrite[i], fn)
+    return nil
+}
@@ -0,0 +1,255 @@
+package bitops
+
+import "testing"
+
+// a small UART-like block used by the register tests
+func testRegisters() ([]Register) {
+    return []Register{
+        {Name: "CTRL", Offset: 0x00, Width: 32, Fields: []Field{
+            {Name: "EN", High: 0, Low: 0, Access: AccessRW},
+            {Name: "MODE", High: 2, Low: 1, Access: AccessRW, Reset: 2},
+            {Name: "START", High: 3, Low: 3, Access: AccessW1S},
+            {Name: "RSVD", High: 7, Low: 4, Access: AccessReserved},
+            {Name: "DIV", High: 23, Low: 8, Access: AccessRW, Reset: 12},
+        }},
+        {Name: "STATUS", Offset: 0x04, Width: 32, Fields: []Field{
+            {Name: "BUSY", High: 0, Low: 0, Access: AccessRO},
+            {Name: "DONE", High: 1, Low: 1, Access: AccessW1C},
+            {Name: "ERR", High: 2, Low: 2, Access: AccessW1C},
+        }},
+        {Name: "DATA", Offset: 0x08, Width: 8, Fields: []Field{
+            {Name: "VAL", High: 7, Low: 0, Access: AccessRC},
+        }},
+        {Name: "KEY", Offset: 0x0C, Width: 16, Fields: []Field{
+            {Name: "KEY", High: 15, Low: 0, Access: AccessWO, Reset: 0xFFFF},
+        }},
+    }
+}
+
+func TestAccess(t *testing.T) {
+    for a := AccessRW; a <= AccessReserved; a++ {
+        if ret, err := ParseAccess(a.String()); err != nil || ret != a {
+            t.Fail()
+            t.Logf("parse %v get %v %v", a, ret, err)
+        }
+    }
+    if ret, err := ParseAccess("w1c"); err != nil || ret != AccessW1C {
+        t.Fail()
+        t.Logf("lower case get %v", ret)
+    }
+    if _, err := ParseAccess("RWX"); err == nil {
+        t.Fail()
+        t.Log("unknown access")
+    }
+    if s := Access(100).String(); s != "Access(100)" {
+        t.Fail()
+        t.Logf("get %s", s)
+    }
+}
+
+func TestRegisterField(t *testing.T) {
+    r := testRegisters()[0]
+    if v := r.ResetValue(); v != 0xC04 {
+        t.Fail()
+        t.Logf("reset expect 0xC04 but get %x", v)
+    }
+
+    f, ok := r.Field("DIV")
+    if !ok || f.Width() != 16 || f.Mask() != 0xFFFF00 {
+        t.Fatalf("DIV get %v", f)
+    }
+    if v, err := f.Get(0x12345678); err != nil || v != 0x3456 {
+        t.Fail()
+        t.Logf("get DIV %x", v)
+    }
+    if v, err := f.Set(0x12345678, 0xABCD); err != nil || v != 0x12ABCD78 {
+        t.Fail()
+        t.Logf("set DIV %x", v)
+    }
+    if _, err := f.Set(0, 0x10000); err == nil {
+        t.Fail()
+        t.Log("value wider than field")
+    }
+    if _, ok = r.Field("NONE"); ok {
+        t.Fail()
+        t.Log("unknown field")
+    }
+
+    invalid := []Register{
+        {Name: "W", Width: 24},
+        {Name: "H", Width: 8, Fields: []Field{{Name: "A", High: 8, Low: 0}}},
+        {Name: "O", Width: 8, Fields: []Field{{Name: "A", High: 3, Low: 0}, {Name: "B", High: 4, Low: 3}}},
+        {Name: "D", Width: 8, Fields: []Field{{Name: "A", High: 3, Low: 0}, {Name: "A", High: 4, Low: 4}}},
+        {Name: "R", Width: 8, Fields: []Field{{Name: "A", High: 3, Low: 0, Reset: 16}}},
+    }
+    for _, r := range invalid {
+        if err := r.Validate(); err == nil {
+            t.Fail()
+            t.Logf("register %s should be invalid", r.Name)
+        }
+    }
+}
+
+func TestRegisterBank(t *testing.T) {
+    b, err := NewRegisterBank(testRegisters())
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if v, err := b.Read(0x00); err != nil || v != 0xC04 {
+        t.Fail()
+        t.Logf("CTRL reset get %x %v", v, err)
+    }
+    if err = b.Write(0x00, 0x1F01 | 0x2); err != nil {
+        t.Fatal(err)
+    }
+    if v, _ := b.Read(0x00); v != 0x1F03 {
+        t.Fail()
+        t.Logf("CTRL get %x", v)
+    }
+
+    //writing 0 to a W1S bit keep it set
+    b.Write(0x00, 0x8)
+    b.Write(0x00, 0x0)
+    if v, _ := b.Peek(0x00); v != 0x8 {
+        t.Fail()
+        t.Logf("W1S get %x", v)
+    }
+
+    if err = b.Write(0x00, 0x10); err == nil {
+        t.Fail()
+        t.Log("write 1 to reserved")
+    }
+    if v, _ := b.Peek(0x00); v != 0x8 {
+        t.Fail()
+        t.Log("failed write must not change the register")
+    }
+    if err = b.Write(0x00, 1 << 32); err == nil {
+        t.Fail()
+        t.Log("value wider than register")
+    }
+    if _, err = b.Read(0x10); err == nil {
+        t.Fail()
+        t.Log("unmapped offset")
+    }
+}
+
+func TestRegisterBankIndependentCopies(t *testing.T) {
+    regs := testRegisters()
+    regs[0].Fields[1].Enums = []EnumValue{{Name: "FAST", Value: 2}}
+    b, err := NewRegisterBank(regs)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    //the bank keep its own fields and enums and hand out copies
+    regs[0].Fields[1].Name = "CHANGED"
+    regs[0].Fields[1].Enums[0].Name = "CHANGED"
+    got, _ := b.Register("CTRL")
+    if got.Fields[1].Name != "MODE" || got.Fields[1].Enums[0].Name != "FAST" {
+        t.Fail()
+        t.Logf("bank share fields with the caller, get %+v", got.Fields[1])
+    }
+    got.Fields[1].Enums[0].Name = "CHANGED"
+    b.Registers()[0].Fields[1].Enums[0].Name = "CHANGED"
+    if again, _ := b.Register("CTRL"); again.Fields[1].Enums[0].Name != "FAST" {
+        t.Fail()
+        t.Logf("bank share fields with a returned register, get %+v", again.Fields[1])
+    }
+}
+
+func TestRegisterBankAccess(t *testing.T) {
+    b, _ := NewRegisterBank(testRegisters())
+
+    //hardware raise BUSY, DONE and ERR, software clear DONE only
+    b.Poke(0x04, 0xFF)
+    if v, _ := b.Read(0x04); v != 0x7 {
+        t.Fail()
+        t.Logf("STATUS get %x", v)
+    }
+    b.Write(0x04, 0x3)
+    if v, _ := b.Read(0x04); v != 0x5 {
+        t.Fail()
+        t.Logf("W1C get %x", v)
+    }
+
+    b.Poke(0x08, 0x5A)
+    if v, _ := b.Read(0x08); v != 0x5A {
+        t.Fail()
+        t.Logf("DATA get %x", v)
+    }
+    if v, _ := b.Read(0x08); v != 0 {
+        t.Fail()
+        t.Logf("read-clear DATA get %x", v)
+    }
+    if err := b.Write(0x08, 1); err == nil {
+        t.Fail()
+        t.Log("write read-only register")
+    }
+
+    if _, err := b.Read(0x0C); err == nil {
+        t.Fail()
+        t.Log("read write-only register")
+    }
+    b.Write(0x0C, 0x1234)
+    if v, _ := b.Peek(0x0C); v != 0x1234 {
+        t.Fail()
+        t.Logf("KEY get %x", v)
+    }
+
+    b.Reset()
+    if v, _ := b.Peek(0x0C); v != 0xFFFF {
+        t.Fail()
+        t.Logf("KEY reset get %x", v)
+    }
+}
+
+func TestRegisterBankHooks(t *testing.T) {
+    b, _ := NewRegisterBank(testRegisters())
+
+    //writing START run the job, BUSY drop and DONE rise at the next STATUS read
+    b.OnWrite("CTRL", func(value uint64) {
+        if value & 0x8 != 0 {
+            b.Poke(0x04, 0x1)
+        }
+    })
+    reads := 0
+    b.OnRead("STATUS", func(value uint64) {
+        reads++
+        if value & 0x1 != 0 {
+            b.Poke(0x04, 0x2)
+        }
+    })
+
+    b.Write(0x00, 0x9)
+    if v, _ := b.Read(0x04); v != 0x1 {
+        t.Fail()
+        t.Logf("STATUS after start get %x", v)
+    }
+    if v, _ := b.Read(0x04); v != 0x2 {
+        t.Fail()
+        t.Logf("STATUS after done get %x", v)
+    }
+    if reads != 2 {
+        t.Fail()
+        t.Logf("expect 2 reads but get %d", reads)
+    }
+
+    if b.OnRead("NONE", func(uint64) {}) == nil || b.OnWrite("NONE", func(uint64) {}) == nil {
+        t.Fail()
+        t.Log("hook on unknown register")
+    }
+
+    regs := testRegisters()
+    regs[1].Offset = 0
+    if _, err := NewRegisterBank(regs); err == nil {
+        t.Fail()
+        t.Log("duplicated offset")
+    }
+    regs = testRegisters()
+    regs[1].Name = "CTRL"
+    if _, err := NewRegisterBank(regs); err == nil {
+        t.Fail()
+        t.Log("duplicated name")
+    }
+}
@@ -0,0 +1,149 @@
+package bitops
+
+import (
+    "encoding/json"
+    "fmt"
+    "slices"
+    "strings"
+)
+
+// BitRange is the bits High:Low of a word
+type BitRange struct {
+    High uint `json:"high"`
+    Low  uint `json:"low"`
+}
+
+// DiffBits return the runs of bits where old and new differ from LSB to MSB
+func DiffBits(old uint64, new uint64) ([]BitRange) {
+    var ranges []BitRange
+    for start, length := range IterRun64(old ^ new) {
+        ranges = append(ranges, BitRange{High: start + length - 1, Low: start})
+    }
+    return ranges
+}
+
+// FieldChange is a field whose value differ between two register values. Bits
+// outside every field are reported with an empty Field. Reserved is set for
+// reserved fields and bits outside the fields, OldEnum/NewEnum name the values
+type FieldChange struct {
+    Register string `json:"register"`
+    Offset   uint64 `json:"offset"`
+    Field    string `json:"field,omitempty"`
+    BitRange
+    Old      uint64 `json:"old"`
+    New      uint64 `json:"new"`
+    OldEnum  string `json:"old_enum,omitempty"`
+    NewEnum  string `json:"new_enum,omitempty"`
+    Reserved bool   `json:"reserved,omitempty"`
+}
+
+// String return the change as REGISTER.FIELD[high:low] old -> new, reserved
+// changes are marked with a leading !
+func (c FieldChange) String() (string) {
+    value := func(v uint64, enum string) (string) {
+        if enum != "" {
+            return fmt.Sprintf("%s(%#x)", enum, v)
+        }
+        return fmt.Sprintf("%#x", v)
+    }
+
+    mark, name := " ", c.Register
+    if c.Reserved {
+        mark = "!"
+    }
+    if c.Field != "" {
+        name += "." + c.Field
+    }
+    return fmt.Sprintf("%s %s[%d:%d] %s -> %s", mark, name, c.High, c.Low,
+                       value(c.Old, c.OldEnum), value(c.New, c.NewEnum))
+}
+
+// RegisterDiff is the list of changes between two register values or dumps in
+// register then bit order
+type RegisterDiff []FieldChange
+
+// String return one change per line
+func (d RegisterDiff) String() (string) {
+    var sb strings.Builder
+    for _, c := range d {
+        sb.WriteString(c.String())
+        sb.WriteByte('\n')
+    }
+    return sb.String()
+}
+
+// MarshalJSON return the changes as an array, an empty diff is [] rather than null
+func (d RegisterDiff) MarshalJSON() ([]byte, error) {
+    if d == nil {
+        return []byte("[]"), nil
+    }
+    return json.Marshal([]FieldChange(d))
+}
+
+// Reserved return the changes of reserved fields and bits outside the fields
+func (d RegisterDiff) Reserved() (RegisterDiff) {
+    var out RegisterDiff
+    for _, c := range d {
+        if c.Reserved {
+            out = append(out, c)
+        }
+    }
+    return out
+}
+
+// DiffRegister return the fields of r whose value differ between old and new,
+// followed by the changed runs of bits outside every field
+func DiffRegister(r Register, old uint64, new uint64) (RegisterDiff) {
+    var diff RegisterDiff
+    covered := uint64(0)
+    fields := slices.Clone(r.Fields)
+    slices.SortFunc(fields, func(a, b Field) (int) {
+        return int(a.Low) - int(b.Low)
+    })
+
+    for _, f := range fields {
+        covered |= f.Mask()
+        if (old ^ new) & f.Mask() == 0 {
+            continue
+        }
+        o, n := (old & f.Mask()) >> f.Low, (new & f.Mask()) >> f.Low
+        diff = append(diff, FieldChange{Register: r.Name, Offset: r.Offset, Field: f.Name,
+            BitRange: BitRange{High: f.High, Low: f.Low}, Old: o, New: n,
+            OldEnum: enumName(f, o), NewEnum: enumName(f, n), Reserved: f.Access == AccessReserved})
+    }
+
+    for _, b := range DiffBits(old &^ covered, new &^ covered) {
+        mask := (^uint64(0) >> (63 - b.High + b.Low)) << b.Low
+        diff = append(diff, FieldChange{Register: r.Name, Offset: r.Offset, BitRange: b,
+            Old: (old & mask) >> b.Low, New: (new & mask) >> b.Low, Reserved: true})
+    }
+    return diff
+}
+
+// DiffDump return the changes between two dumps mapping register offsets to
+// values. A register missing from both dumps is skipped, it return error if a
+// register is in one dump only or a dump has an offset without register
+func DiffDump(regs []Register, old map[uint64]uint64, new map[uint64]uint64) (RegisterDiff, error) {
+    known := make(map[uint64]bool)
+    var diff RegisterDiff
+    for _, r := range regs {
+        known[r.Offset] = true
+        o, inOld := old[r.Offset]
+        n, inNew := new[r.Offset]
+        if inOld != inNew {
+            return nil, fmt.Errorf("register(%v) in one dump only", r.Name)
+        }
+        if inOld {
+            diff = append(diff, DiffRegister(r, o, n)...)
+        }
+    }
+
+    for _, dump := range []map[uint64]uint64{old, new} {
+        for offset := range dump {
+            if !known[offset] {
+                return nil, fmt.Errorf("invalid offset(%#x)", offset)
+            }
+        }
+    }
+    return diff, nil
+}
@@ -0,0 +1,83 @@
+package bitops
+
+import (
+    "encoding/json"
+    "slices"
+    "strings"
+    "testing"
+)
+
+func TestDiffBits(t *testing.T) {
+    ret := DiffBits(0xF0F0, 0x0FF1)
+    if expect := []BitRange{{0, 0}, {15, 8}}; !slices.Equal(ret, expect) {
+        t.Fail()
+        t.Logf("expect %v but get %v", expect, ret)
+    }
+    if ret = DiffBits(0, ^uint64(0)); !slices.Equal(ret, []BitRange{{63, 0}}) {
+        t.Fail()
+        t.Logf("full word get %v", ret)
+    }
+    if ret = DiffBits(42, 42); ret != nil {
+        t.Fail()
+        t.Logf("equal get %v", ret)
+    }
+}
+
+func TestDiffRegister(t *testing.T) {
+    ctrl := testPeripheral().Registers[0]
+    //MODE NORMAL -> FAST, RSVD 0 -> 1, DIV 12 -> 13, bits 31:30 outside the fields
+    diff := DiffRegister(ctrl, 0xC03, 0xC0000D15)
+    expect := "  CTRL.MODE[2:1] NORMAL(0x1) -> FAST(0x2)\n" +
+              "! CTRL.RSVD[7:4] 0x0 -> 0x1\n" +
+              "  CTRL.DIV[23:8] 0xc -> 0xd\n" +
+              "! CTRL[31:30] 0x0 -> 0x3\n"
+    if s := diff.String(); s != expect {
+        t.Fail()
+        t.Logf("expect\n%s but get\n%s", expect, s)
+    }
+    if reserved := diff.Reserved(); len(reserved) != 2 || reserved[0].Field != "RSVD" || reserved[1].Field != "" {
+        t.Fail()
+        t.Logf("reserved get %v", reserved)
+    }
+    if diff = DiffRegister(ctrl, 0xC03, 0xC03); len(diff) != 0 {
+        t.Fail()
+        t.Logf("equal get %v", diff)
+    }
+}
+
+func TestDiffDump(t *testing.T) {
+    regs := testPeripheral().Registers
+    old := map[uint64]uint64{0x00: 0xC04, 0x04: 0x1, 0x08: 0x5A}
+    new := map[uint64]uint64{0x00: 0xC04, 0x04: 0x6, 0x08: 0x5A}
+    diff, err := DiffDump(regs, old, new)
+    if err != nil {
+        t.Fatal(err)
+    }
+    data, _ := json.Marshal(diff)
+    expect := `[{"register":"STATUS","offset":4,"field":"BUSY","high":0,"low":0,"old":1,"new":0},` +
+              `{"register":"STATUS","offset":4,"field":"DONE","high":1,"low":1,"old":0,"new":1},` +
+              `{"register":"STATUS","offset":4,"field":"ERR","high":2,"low":2,"old":0,"new":1}]`
+    if string(data) != expect {
+        t.Fail()
+        t.Logf("expect %s but get %s", expect, data)
+    }
+
+    diff, _ = DiffDump(regs, old, old)
+    if data, _ = json.Marshal(diff); string(data) != "[]" {
+        t.Fail()
+        t.Logf("no change get %s", data)
+    }
+
+    new[0x0C] = 1
+    if _, err = DiffDump(regs, old, new); err == nil || !strings.Contains(err.Error(), "KEY") {
+        t.Fail()
+        t.Logf("register in one dump get %v", err)
+    }
+    delete(new, 0x0C)
+    new[0x40] = 1
+    old[0x40] = 1
+    if _, err = DiffDump(regs, old, new); err == nil {
+        t.Fail()
+        t.Log("offset without register")
+    }
+}
@@ -0,0 +1,209 @@
+package bitops
+
+import (
+    "fmt"
+    "slices"
+    "strings"
+)
+
+// Access describe how software access a register field
+type Access uint
+
+const (
+    // AccessRW read and write
+    AccessRW Access = iota
+    // AccessRO read only, writes are ignored
+    AccessRO
+    // AccessWO write only, reads return 0
+    AccessWO
+    // AccessW1C writing 1 clear the bit, writing 0 has no effect
+    AccessW1C
+    // AccessW1S writing 1 set the bit, writing 0 has no effect
+    AccessW1S
+    // AccessRC reading clear the field, writes are ignored
+    AccessRC
+    // AccessReserved read as 0 and must be written 0
+    AccessReserved
+)
+
+var accessNames = [...]string{"RW", "RO", "WO", "W1C", "W1S", "RC", "RSVD"}
+
+// String return the short name of the access type such as W1C
+func (a Access) String() (string) {
+    if a < Access(len(accessNames)) {
+        return accessNames[a]
+    }
+    return fmt.Sprintf("Access(%d)", uint(a))
+}
+
+// ParseAccess return the access type of a short name, case insensitive
+func ParseAccess(s string) (Access, error) {
+    for i, name := range accessNames {
+        if strings.EqualFold(s, name) {
+            return Access(i), nil
+        }
+    }
+    return 0, fmt.Errorf("invalid access(%v)", s)
+}
+
+// readable return whether software read the stored field bits
+func (a Access) readable() (bool) {
+    return a != AccessWO && a != AccessReserved
+}
+
+// writable return whether software writes change the field
+func (a Access) writable() (bool) {
+    return a == AccessRW || a == AccessWO || a == AccessW1C || a == AccessW1S
+}
+
+// EnumValue name one value of a field
+type EnumValue struct {
+    Name        string
+    Value       uint64
+    Description string
+}
+
+// Field describe the bits High:Low of a register
+type Field struct {
+    Name        string
+    High        uint
+    Low         uint
+    Access      Access
+    Reset       uint64
+    Description string
+    Enums       []EnumValue
+}
+
+// Width return the number of bits of the field
+func (f Field) Width() (uint) {
+    return f.High - f.Low + 1
+}
+
+// Mask return the bits of the field in the register
+func (f Field) Mask() (uint64) {
+    return (^uint64(0) >> (64 - f.Width())) << f.Low
+}
+
+// Get return the field from a register value
+func (f Field) Get(value uint64) (uint64, error) {
+    return GetField64(value, f.High, f.Low)
+}
+
+// Set return the register value with the field replaced, it return error if field
+// does not fit
+func (f Field) Set(value uint64, field uint64) (uint64, error) {
+    if f.High < f.Low || f.High > 63 || field >> (f.Width() - 1) > 1 {
+        return value, fmt.Errorf("invalid value(%v) for field(%v)", field, f.Name)
+    }
+
+    return SetField64(value, f.High, f.Low, field)
+}
+
+// Register describe a register of Width bits at Offset of a register bank.
+// UndefinedReset mark the bits whose value after reset is not defined
+type Register struct {
+    Name           string
+    Offset         uint64
+    Width          uint
+    Fields         []Field
+    Description    string
+    UndefinedReset uint64
+}
+
+// Field return the field of the given name
+func (r Register) Field(name string) (Field, bool) {
+    for _, f := range r.Fields {
+        if f.Name == name {
+            return f, true
+        }
+    }
+    return Field{}, false
+}
+
+// Clone return a copy of r that share no fields or enums with it
+func (r Register) Clone() (Register) {
+    r.Fields = slices.Clone(r.Fields)
+    for i := range r.Fields {
+        r.Fields[i].Enums = slices.Clone(r.Fields[i].Enums)
+    }
+    return r
+}
+
+// ResetValue return the register value after reset composed of the field resets,
+// undefined bits are 0
+func (r Register) ResetValue() (uint64) {
+    value := uint64(0)
+    for _, f := range r.Fields {
+        value |= (f.Reset << f.Low) & f.Mask()
+    }
+    return value &^ r.UndefinedReset
+}
+
+// Validate check the width is 8, 16, 32 or 64, fields are inside the register,
+// do not overlap, have unique names and reset and enum values fitting their width
+func (r Register) Validate() (error) {
+    if r.Width != 8 && r.Width != 16 && r.Width != 32 && r.Width != 64 {
+        return fmt.Errorf("invalid width(%v) of register(%v)", r.Width, r.Name)
+    }
+
+    used := uint64(0)
+    names := make(map[string]bool)
+    for _, f := range r.Fields {
+        if f.High >= r.Width || f.Low > f.High {
+            return fmt.Errorf("invalid high(%v) or low(%v) of field(%v.%v)", f.High, f.Low, r.Name, f.Name)
+        }
+        if used & f.Mask() != 0 {
+            return fmt.Errorf("overlapped field(%v.%v)", r.Name, f.Name)
+        }
+        if names[f.Name] {
+            return fmt.Errorf("duplicated field(%v.%v)", r.Name, f.Name)
+        }
+        if f.Reset >> (f.Width() - 1) > 1 {
+            return fmt.Errorf("invalid reset(%v) of field(%v.%v)", f.Reset, r.Name, f.Name)
+        }
+        for _, e := range f.Enums {
+            if e.Value >> (f.Width() - 1) > 1 {
+                return fmt.Errorf("invalid value(%v) of enum(%v.%v.%v)", e.Value, r.Name, f.Name, e.Name)
+            }
+        }
+        used |= f.Mask()
+        names[f.Name] = true
+    }
+    return nil
+}
+
+// Peripheral is a block of registers at BaseAddress, register offsets are relative
+// to BaseAddress
+type Peripheral struct {
+    Name        string
+    BaseAddress uint64
+    Description string
+    Registers   []Register
+}
+
+// Device is a set of peripherals as described by a CMSIS-SVD or IP-XACT file
+type Device struct {
+    Name        string
+    Description string
+    Peripherals []Peripheral
+}
+
+// Peripheral return the peripheral of the given name
+func (d *Device) Peripheral(name string) (*Peripheral, bool) {
+    for i := range d.Peripherals {
+        if d.Peripherals[i].Name == name {
+            return &d.Peripherals[i], true
+        }
+    }
+    return nil, false
+}
+
+// Register return the register of the given name
+func (p *Peripheral) Register(name string) (Register, bool) {
+    for _, r := range p.Registers {
+        if r.Name == name {
+            return r, true
+        }
+    }
+    return Register{}, false
+}
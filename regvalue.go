@@ -0,0 +1,220 @@
+package bitops
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "strconv"
+    "strings"
+)
+
+// RegisterValue is a raw register word decoded with its layout. It marshal to
+// JSON as {"EN":1,"MODE":"FAST","DIV":12} and to text as EN=1 MODE=FAST DIV=12,
+// fields in layout order with enum names for known values. Reserved fields are
+// left out while they are 0. Unmarshal need Register set, start from its reset
+// value and replace the fields given
+type RegisterValue struct {
+    Register Register
+    Raw      uint64
+}
+
+// Decode return raw decoded with the layout of r
+func (r Register) Decode(raw uint64) (RegisterValue) {
+    return RegisterValue{Register: r, Raw: raw}
+}
+
+// Field return the value of the named field
+func (v RegisterValue) Field(name string) (uint64, error) {
+    f, ok := v.Register.Field(name)
+    if !ok {
+        return 0, fmt.Errorf("invalid field(%v) of register(%v)", name, v.Register.Name)
+    }
+    return f.Get(v.Raw)
+}
+
+// SetField replace the named field, it return error if the field does not exist or
+// the value does not fit
+func (v *RegisterValue) SetField(name string, field uint64) (error) {
+    f, ok := v.Register.Field(name)
+    if !ok {
+        return fmt.Errorf("invalid field(%v) of register(%v)", name, v.Register.Name)
+    }
+    return v.set(f, field)
+}
+
+// store field to f of the raw word through SetField32 or SetField64
+func (v *RegisterValue) set(f Field, field uint64) (error) {
+    if f.High >= v.Register.Width || field >> (f.Width() - 1) > 1 {
+        return fmt.Errorf("invalid value(%v) for field(%v.%v)", field, v.Register.Name, f.Name)
+    }
+
+    if v.Register.Width <= 32 {
+        raw, err := SetField32(uint32(v.Raw), f.High, f.Low, uint32(field))
+        v.Raw = uint64(raw)
+        return err
+    }
+    raw, err := SetField64(v.Raw, f.High, f.Low, field)
+    v.Raw = raw
+    return err
+}
+
+// fields to render and their values, it return error if Raw has bits outside the
+// fields of the register
+func (v RegisterValue) decoded() ([]Field, []uint64, error) {
+    covered := uint64(0)
+    for _, f := range v.Register.Fields {
+        covered |= f.Mask()
+    }
+    if v.Raw &^ covered != 0 {
+        return nil, nil, fmt.Errorf("invalid value(%#x) for register(%v)", v.Raw, v.Register.Name)
+    }
+
+    var fields []Field
+    var values []uint64
+    for _, f := range v.Register.Fields {
+        value := (v.Raw & f.Mask()) >> f.Low
+        if f.Access == AccessReserved && value == 0 {
+            continue
+        }
+        fields = append(fields, f)
+        values = append(values, value)
+    }
+    return fields, values, nil
+}
+
+// enum name of value, empty if none
+func enumName(f Field, value uint64) (string) {
+    for _, e := range f.Enums {
+        if e.Value == value {
+            return e.Name
+        }
+    }
+    return ""
+}
+
+// value of s for f: an enum name or a number
+func parseFieldValue(f Field, s string) (uint64, error) {
+    for _, e := range f.Enums {
+        if e.Name == s {
+            return e.Value, nil
+        }
+    }
+    return parseRegisterNumber(s)
+}
+
+// MarshalText return the fields as NAME=VALUE separated by spaces
+func (v RegisterValue) MarshalText() ([]byte, error) {
+    fields, values, err := v.decoded()
+    if err != nil {
+        return nil, err
+    }
+
+    var buf bytes.Buffer
+    for i, f := range fields {
+        if i > 0 {
+            buf.WriteByte(' ')
+        }
+        buf.WriteString(f.Name)
+        buf.WriteByte('=')
+        if name := enumName(f, values[i]); name != "" {
+            buf.WriteString(name)
+        } else {
+            buf.WriteString(strconv.FormatUint(values[i], 10))
+        }
+    }
+    return buf.Bytes(), nil
+}
+
+// UnmarshalText parse NAME=VALUE pairs separated by spaces, VALUE is an enum name
+// or a number. It return error and keep Raw on an unknown field or a value that
+// does not fit
+func (v *RegisterValue) UnmarshalText(text []byte) (error) {
+    next := RegisterValue{Register: v.Register, Raw: v.Register.ResetValue()}
+    for _, pair := range strings.Fields(string(text)) {
+        name, value, ok := strings.Cut(pair, "=")
+        if !ok {
+            return fmt.Errorf("invalid field(%v) of register(%v)", pair, v.Register.Name)
+        }
+        f, ok := v.Register.Field(name)
+        if !ok {
+            return fmt.Errorf("invalid field(%v) of register(%v)", name, v.Register.Name)
+        }
+        field, err := parseFieldValue(f, value)
+        if err != nil {
+            return fmt.Errorf("invalid value(%v) for field(%v.%v)", value, v.Register.Name, name)
+        }
+        if err = next.set(f, field); err != nil {
+            return err
+        }
+    }
+
+    v.Raw = next.Raw
+    return nil
+}
+
+// MarshalJSON return the fields as an object in layout order, values with an enum
+// name are strings and others numbers
+func (v RegisterValue) MarshalJSON() ([]byte, error) {
+    fields, values, err := v.decoded()
+    if err != nil {
+        return nil, err
+    }
+
+    var buf bytes.Buffer
+    buf.WriteByte('{')
+    for i, f := range fields {
+        if i > 0 {
+            buf.WriteByte(',')
+        }
+        key, _ := json.Marshal(f.Name)
+        buf.Write(key)
+        buf.WriteByte(':')
+        if name := enumName(f, values[i]); name != "" {
+            value, _ := json.Marshal(name)
+            buf.Write(value)
+        } else {
+            buf.WriteString(strconv.FormatUint(values[i], 10))
+        }
+    }
+    buf.WriteByte('}')
+    return buf.Bytes(), nil
+}
+
+// UnmarshalJSON parse an object of fields, a value is a number or a string holding
+// an enum name or a number. It return error and keep Raw on an unknown field or a
+// value that does not fit. null leave v unchanged as encoding/json types do
+func (v *RegisterValue) UnmarshalJSON(data []byte) (error) {
+    if string(bytes.TrimSpace(data)) == "null" {
+        return nil
+    }
+    var object map[string]json.RawMessage
+    if err := json.Unmarshal(data, &object); err != nil {
+        return err
+    }
+
+    next := RegisterValue{Register: v.Register, Raw: v.Register.ResetValue()}
+    for name, raw := range object {
+        f, ok := v.Register.Field(name)
+        if !ok {
+            return fmt.Errorf("invalid field(%v) of register(%v)", name, v.Register.Name)
+        }
+
+        var field uint64
+        var err error
+        var s string
+        if json.Unmarshal(raw, &s) == nil {
+            field, err = parseFieldValue(f, s)
+        } else {
+            field, err = strconv.ParseUint(string(raw), 10, 64)
+        }
+        if err != nil {
+            return fmt.Errorf("invalid value(%s) for field(%v.%v)", raw, v.Register.Name, name)
+        }
+        if err = next.set(f, field); err != nil {
+            return err
+        }
+    }
+
+    v.Raw = next.Raw
+    return nil
+}
@@ -0,0 +1,101 @@
+package bitops
+
+import (
+    "encoding/json"
+    "testing"
+)
+
+func TestRegisterValueJSON(t *testing.T) {
+    ctrl := testPeripheral().Registers[0]
+    v := ctrl.Decode(0xC05)
+    data, err := json.Marshal(v)
+    if expect := `{"EN":1,"MODE":"FAST","START":0,"DIV":12}`; err != nil || string(data) != expect {
+        t.Fail()
+        t.Logf("expect %s but get %s %v", expect, data, err)
+    }
+
+    ret := ctrl.Decode(0)
+    if err = json.Unmarshal(data, &ret); err != nil || ret.Raw != 0xC05 {
+        t.Fail()
+        t.Logf("unmarshal get %x %v", ret.Raw, err)
+    }
+
+    //missing fields keep their reset value, strings may hold numbers
+    if err = json.Unmarshal([]byte(`{"EN":1,"DIV":"0x20","MODE":1}`), &ret); err != nil || ret.Raw != 0x2003 {
+        t.Fail()
+        t.Logf("partial get %x %v", ret.Raw, err)
+    }
+
+    //a reserved field is rendered only when set
+    data, _ = json.Marshal(ctrl.Decode(0x34))
+    if expect := `{"EN":0,"MODE":"FAST","START":0,"RSVD":3,"DIV":0}`; string(data) != expect {
+        t.Fail()
+        t.Logf("expect %s but get %s", expect, data)
+    }
+
+    invalid := []string{`{"NONE":1}`, `{"MODE":"TURBO"}`, `{"MODE":4}`, `{"DIV":65536}`, `{"EN":-1}`, `{"EN":1.5}`, `[1]`, `1`, `"EN"`}
+    for _, s := range invalid {
+        ret.Raw = 0x1234
+        if err = json.Unmarshal([]byte(s), &ret); err == nil || ret.Raw != 0x1234 {
+            t.Fail()
+            t.Logf("unmarshal %s get %x %v", s, ret.Raw, err)
+        }
+    }
+    //null is a no-op, also as an object member
+    ret.Raw = 0x1234
+    if err = ret.UnmarshalJSON([]byte(" null ")); err != nil || ret.Raw != 0x1234 {
+        t.Fail()
+        t.Logf("null get %x %v", ret.Raw, err)
+    }
+    holder := struct{ Value RegisterValue }{ret}
+    if err = json.Unmarshal([]byte(`{"Value":null}`), &holder); err != nil || holder.Value.Raw != 0x1234 {
+        t.Fail()
+        t.Logf("null member get %x %v", holder.Value.Raw, err)
+    }
+    if _, err = json.Marshal(ctrl.Decode(1 << 30)); err == nil {
+        t.Fail()
+        t.Log("bit outside the fields")
+    }
+}
+
+func TestRegisterValueText(t *testing.T) {
+    regs := testPeripheral().Registers
+    v := regs[0].Decode(0xC05)
+    text, err := v.MarshalText()
+    if expect := "EN=1 MODE=FAST START=0 DIV=12"; err != nil || string(text) != expect {
+        t.Fail()
+        t.Logf("expect %s but get %s %v", expect, text, err)
+    }
+
+    ret := regs[0].Decode(0)
+    if err = ret.UnmarshalText(text); err != nil || ret.Raw != 0xC05 {
+        t.Fail()
+        t.Logf("unmarshal get %x %v", ret.Raw, err)
+    }
+    for _, s := range []string{"EN", "NONE=1", "MODE=TURBO", "EN=2"} {
+        if err = ret.UnmarshalText([]byte(s)); err == nil || ret.Raw != 0xC05 {
+            t.Fail()
+            t.Logf("unmarshal %s get %x %v", s, ret.Raw, err)
+        }
+    }
+
+    //a 64-bit register go through SetField64
+    wide := Register{Name: "W", Width: 64, Fields: []Field{{Name: "HI", High: 63, Low: 32}, {Name: "LO", High: 31, Low: 0}}}
+    w := wide.Decode(0)
+    if err = w.UnmarshalText([]byte("HI=0xFFFFFFFF LO=7")); err != nil || w.Raw != 0xFFFFFFFF00000007 {
+        t.Fail()
+        t.Logf("64-bit get %x %v", w.Raw, err)
+    }
+    if err = w.SetField("LO", 1 << 32); err == nil {
+        t.Fail()
+        t.Log("value wider than field")
+    }
+    if value, err := w.Field("HI"); err != nil || value != 0xFFFFFFFF {
+        t.Fail()
+        t.Logf("HI get %x", value)
+    }
+    if _, err = w.Field("NONE"); err == nil {
+        t.Fail()
+        t.Log("unknown field")
+    }
+}
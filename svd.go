@@ -0,0 +1,648 @@
+package bitops
+
+import (
+    "cmp"
+    "encoding/xml"
+    "fmt"
+    "io"
+    "reflect"
+    "slices"
+    "strconv"
+    "strings"
+)
+
+// parseRegisterNumber parse the numbers of SVD and IP-XACT files: decimal, 0x hex,
+// 0b or # binary, an optional k/M/G/T multiplier, or a Verilog literal such as 'h1F
+func parseRegisterNumber(s string) (uint64, error) {
+    text := strings.ReplaceAll(strings.TrimSpace(s), "_", "")
+    base, scale := 10, uint64(1)
+    if i := strings.IndexByte(text, '\''); i >= 0 && i + 1 < len(text) {
+        bases := map[byte]int{'b': 2, 'o': 8, 'd': 10, 'h': 16}
+        base = bases[text[i + 1] | 0x20]
+        text = text[i + 2:]
+    } else {
+        switch lower := strings.ToLower(text); {
+        case strings.HasPrefix(lower, "0x"):
+            base, text = 16, text[2:]
+        case strings.HasPrefix(lower, "0b"):
+            base, text = 2, text[2:]
+        case strings.HasPrefix(lower, "#"):
+            base, text = 2, text[1:]
+        }
+        if base == 10 && text != "" {
+            if i := strings.IndexByte("kmgt", text[len(text) - 1] | 0x20); i >= 0 {
+                scale, text = uint64(1) << (10 * (i + 1)), text[:len(text) - 1]
+            }
+        }
+    }
+
+    value, err := strconv.ParseUint(text, max(base, 2), 64)
+    if err != nil || base == 0 || value > ^uint64(0) / scale {
+        return 0, fmt.Errorf("invalid number(%v)", s)
+    }
+    return value * scale, nil
+}
+
+// collapse the line breaks and indentation of XML descriptions
+func cleanDescription(s string) (string) {
+    return strings.Join(strings.Fields(s), " ")
+}
+
+// access type of the SVD and IP-XACT access, modifiedWriteValues and readAction
+func xmlAccess(access string, modified string, readAction string) (Access, error) {
+    switch modified {
+    case "oneToClear":
+        return AccessW1C, nil
+    case "oneToSet":
+        return AccessW1S, nil
+    }
+    if readAction == "clear" {
+        return AccessRC, nil
+    }
+
+    switch access {
+    case "", "read-write", "read-writeOnce":
+        return AccessRW, nil
+    case "read-only":
+        return AccessRO, nil
+    case "write-only", "writeOnce":
+        return AccessWO, nil
+    }
+    return 0, fmt.Errorf("invalid access(%v)", access)
+}
+
+// values of an enumerated value, binary values may have x don't care digits
+func enumValues(s string) ([]uint64, error) {
+    text := strings.ToLower(strings.TrimSpace(s))
+    if !strings.Contains(text, "x") || strings.HasPrefix(text, "0x") {
+        value, err := parseRegisterNumber(s)
+        return []uint64{value}, err
+    }
+
+    text = strings.TrimPrefix(strings.TrimPrefix(text, "#"), "0b")
+    if strings.Count(text, "x") > 8 || len(text) > 64 {
+        return nil, fmt.Errorf("invalid enumerated value(%v)", s)
+    }
+    values := []uint64{0}
+    for _, c := range text {
+        n := len(values)
+        for i := 0; i < n; i++ {
+            values[i] <<= 1
+            switch c {
+            case '1':
+                values[i] |= 1
+            case 'x':
+                values = append(values, values[i] | 1)
+            case '0':
+            default:
+                return nil, fmt.Errorf("invalid enumerated value(%v)", s)
+            }
+        }
+    }
+    slices.Sort(values)
+    return values, nil
+}
+
+// register properties SVD inherit from device to peripheral, cluster and register
+type svdProperties struct {
+    Size       string `xml:"size"`
+    Access     string `xml:"access"`
+    ResetValue string `xml:"resetValue"`
+    ResetMask  string `xml:"resetMask"`
+}
+
+// return p with the properties set by child replaced
+func (p svdProperties) inherit(child svdProperties) (svdProperties) {
+    for _, pair := range [][2]*string{{&p.Size, &child.Size}, {&p.Access, &child.Access},
+                                      {&p.ResetValue, &child.ResetValue}, {&p.ResetMask, &child.ResetMask}} {
+        if *pair[1] != "" {
+            *pair[0] = *pair[1]
+        }
+    }
+    return p
+}
+
+// repetition of a peripheral, cluster, register or field
+type svdDim struct {
+    Dim          string `xml:"dim"`
+    DimIncrement string `xml:"dimIncrement"`
+    DimIndex     string `xml:"dimIndex"`
+}
+
+// names of the repeated elements and the address or bit step between them, %s or
+// [%s] in name is replaced by the index
+func (d svdDim) expand(name string) ([]string, uint64, error) {
+    if d.Dim == "" {
+        return []string{name}, 0, nil
+    }
+
+    count, err := parseRegisterNumber(d.Dim)
+    if err != nil || count == 0 || count > 1 << 16 {
+        return nil, 0, fmt.Errorf("invalid dim(%v) of %v", d.Dim, name)
+    }
+    step, err := parseRegisterNumber(d.DimIncrement)
+    if err != nil {
+        return nil, 0, fmt.Errorf("invalid dimIncrement(%v) of %v", d.DimIncrement, name)
+    }
+
+    var index []string
+    first, last, isRange := strings.Cut(d.DimIndex, "-")
+    switch {
+    case d.DimIndex == "":
+        for i := uint64(0); i < count; i++ {
+            index = append(index, strconv.FormatUint(i, 10))
+        }
+    case isRange && len(first) == 1 && len(last) == 1 && first[0] >= 'A' && last[0] <= 'Z':
+        for c := first[0]; c <= last[0]; c++ {
+            index = append(index, string(c))
+        }
+    case isRange:
+        lo, err1 := strconv.ParseUint(first, 10, 64)
+        hi, err2 := strconv.ParseUint(last, 10, 64)
+        if err1 != nil || err2 != nil || lo > hi || hi - lo >= count {
+            return nil, 0, fmt.Errorf("invalid dimIndex(%v) of %v", d.DimIndex, name)
+        }
+        for i := lo; i <= hi; i++ {
+            index = append(index, strconv.FormatUint(i, 10))
+        }
+    default:
+        for _, s := range strings.Split(d.DimIndex, ",") {
+            index = append(index, strings.TrimSpace(s))
+        }
+    }
+    if uint64(len(index)) != count {
+        return nil, 0, fmt.Errorf("invalid dimIndex(%v) of %v", d.DimIndex, name)
+    }
+
+    names := make([]string, count)
+    for i, s := range index {
+        switch {
+        case strings.Contains(name, "[%s]"):
+            names[i] = strings.Replace(name, "[%s]", s, 1)
+        case strings.Contains(name, "%s"):
+            names[i] = strings.Replace(name, "%s", s, 1)
+        default:
+            names[i] = name + s
+        }
+    }
+    return names, step, nil
+}
+
+type svdEnumeratedValues struct {
+    Usage  string `xml:"usage"`
+    Values []struct {
+        Name        string `xml:"name"`
+        Description string `xml:"description"`
+        Value       string `xml:"value"`
+        IsDefault   string `xml:"isDefault"`
+    } `xml:"enumeratedValue"`
+}
+
+type svdField struct {
+    DerivedFrom         string `xml:"derivedFrom,attr"`
+    Name                string `xml:"name"`
+    Description         string `xml:"description"`
+    svdDim
+    BitOffset           string `xml:"bitOffset"`
+    BitWidth            string `xml:"bitWidth"`
+    Lsb                 string `xml:"lsb"`
+    Msb                 string `xml:"msb"`
+    BitRange            string `xml:"bitRange"`
+    Access              string `xml:"access"`
+    ModifiedWriteValues string `xml:"modifiedWriteValues"`
+    ReadAction          string `xml:"readAction"`
+    EnumeratedValues    []svdEnumeratedValues `xml:"enumeratedValues"`
+}
+
+type svdRegister struct {
+    DerivedFrom   string `xml:"derivedFrom,attr"`
+    Name          string `xml:"name"`
+    Description   string `xml:"description"`
+    AddressOffset string `xml:"addressOffset"`
+    svdDim
+    svdProperties
+    Fields        []svdField `xml:"fields>field"`
+}
+
+type svdCluster struct {
+    DerivedFrom   string `xml:"derivedFrom,attr"`
+    Name          string `xml:"name"`
+    Description   string `xml:"description"`
+    AddressOffset string `xml:"addressOffset"`
+    svdDim
+    svdProperties
+    Registers     []svdRegister `xml:"register"`
+    Clusters      []svdCluster `xml:"cluster"`
+}
+
+type svdPeripheral struct {
+    DerivedFrom string `xml:"derivedFrom,attr"`
+    Name        string `xml:"name"`
+    Description string `xml:"description"`
+    BaseAddress string `xml:"baseAddress"`
+    svdDim
+    svdProperties
+    Registers   []svdRegister `xml:"registers>register"`
+    Clusters    []svdCluster `xml:"registers>cluster"`
+}
+
+type svdDevice struct {
+    Name        string `xml:"name"`
+    Description string `xml:"description"`
+    svdProperties
+    Peripherals []svdPeripheral `xml:"peripherals>peripheral"`
+}
+
+// fill the empty strings and slices of dst from base, recursing into embedded
+// structs, so a derived element inherit everything it does not set itself
+func svdMerge(dst reflect.Value, base reflect.Value) {
+    for i := 0; i < dst.NumField(); i++ {
+        f := dst.Field(i)
+        switch f.Kind() {
+        case reflect.String, reflect.Slice:
+            if f.Len() == 0 {
+                f.Set(base.Field(i))
+            }
+        case reflect.Struct:
+            svdMerge(f, base.Field(i))
+        }
+    }
+}
+
+// svdRoot resolve dotted derivedFrom paths from the device, active hold the paths
+// being looked up so a cycle through several scopes is detected
+type svdRoot struct {
+    device *svdDevice
+    active map[string]bool
+}
+
+// find the element called name among items once they are resolved, nil if none
+func svdFind[T any](items []T, name string, root *svdRoot) (*T, error) {
+    out, err := svdResolve(items, root)
+    if err != nil {
+        return nil, err
+    }
+    for i := range out {
+        if reflect.ValueOf(&out[i]).Elem().FieldByName("Name").String() == name {
+            return &out[i], nil
+        }
+    }
+    return nil, nil
+}
+
+// lookup return the element at a dotted path such as PERIPH.CLUSTER.REG.FIELD,
+// every scope on the way is resolved first so derived elements are found too
+func (root *svdRoot) lookup(path string) (reflect.Value, error) {
+    if root.active[path] {
+        return reflect.Value{}, fmt.Errorf("circular derivedFrom(%v)", path)
+    }
+    root.active[path] = true
+    defer delete(root.active, path)
+
+    parts := strings.Split(path, ".")
+    p, err := svdFind(root.device.Peripherals, parts[0], root)
+    if err != nil || p == nil {
+        return reflect.Value{}, cmp.Or(err, fmt.Errorf("invalid derivedFrom(%v)", path))
+    }
+    cur := reflect.ValueOf(p).Elem()
+    regs, clusters, fields := p.Registers, p.Clusters, []svdField(nil)
+    for _, part := range parts[1:] {
+        r, err1 := svdFind(regs, part, root)
+        c, err2 := svdFind(clusters, part, root)
+        f, err3 := svdFind(fields, part, root)
+        if err := cmp.Or(err1, err2, err3); err != nil {
+            return reflect.Value{}, err
+        }
+        switch {
+        case r != nil:
+            cur, regs, clusters, fields = reflect.ValueOf(r).Elem(), nil, nil, r.Fields
+        case c != nil:
+            cur, regs, clusters, fields = reflect.ValueOf(c).Elem(), c.Registers, c.Clusters, nil
+        case f != nil:
+            cur, regs, clusters, fields = reflect.ValueOf(f).Elem(), nil, nil, nil
+        default:
+            return reflect.Value{}, fmt.Errorf("invalid derivedFrom(%v)", path)
+        }
+    }
+    return cur, nil
+}
+
+// resolve the derivedFrom attributes of the elements of one scope. A dotted path
+// is looked up from the device root, a bare name in the same scope
+func svdResolve[T any](items []T, root *svdRoot) ([]T, error) {
+    out := slices.Clone(items)
+    index := make(map[string]int)
+    for i := range out {
+        index[reflect.ValueOf(&out[i]).Elem().FieldByName("Name").String()] = i
+    }
+
+    // 1 while resolving so a cycle is detected, 2 once done
+    state := make([]int, len(out))
+    var visit func(i int) (error)
+    visit = func(i int) (error) {
+        v := reflect.ValueOf(&out[i]).Elem()
+        from := v.FieldByName("DerivedFrom")
+        switch {
+        case state[i] == 2 || from.String() == "":
+            state[i] = 2
+            return nil
+        case state[i] == 1:
+            return fmt.Errorf("circular derivedFrom(%v)", from.String())
+        }
+
+        state[i] = 1
+        path := from.String()
+        var base reflect.Value
+        if strings.Contains(path, ".") {
+            b, err := root.lookup(path)
+            if err != nil {
+                return err
+            }
+            if b.Type() != v.Type() {
+                return fmt.Errorf("invalid derivedFrom(%v)", path)
+            }
+            base = b
+        } else {
+            j, ok := index[path]
+            if !ok {
+                return fmt.Errorf("invalid derivedFrom(%v)", path)
+            }
+            if err := visit(j); err != nil {
+                return err
+            }
+            base = reflect.ValueOf(&out[j]).Elem()
+        }
+        from.SetString("")
+        svdMerge(v, base)
+        state[i] = 2
+        return nil
+    }
+
+    for i := range out {
+        if err := visit(i); err != nil {
+            return nil, err
+        }
+    }
+    return out, nil
+}
+
+// bit position of a field given by bitRange, lsb/msb or bitOffset/bitWidth
+func (f svdField) bits() (uint, uint, error) {
+    var low, high uint64
+    var err1, err2 error
+    switch {
+    case f.BitRange != "":
+        msb, lsb, ok := strings.Cut(strings.Trim(strings.TrimSpace(f.BitRange), "[]"), ":")
+        if !ok {
+            return 0, 0, fmt.Errorf("invalid bitRange(%v) of field(%v)", f.BitRange, f.Name)
+        }
+        high, err1 = parseRegisterNumber(msb)
+        low, err2 = parseRegisterNumber(lsb)
+    case f.Lsb != "" || f.Msb != "":
+        high, err1 = parseRegisterNumber(f.Msb)
+        low, err2 = parseRegisterNumber(f.Lsb)
+    default:
+        width := uint64(1)
+        low, err1 = parseRegisterNumber(f.BitOffset)
+        if f.BitWidth != "" {
+            width, err2 = parseRegisterNumber(f.BitWidth)
+        }
+        high = low + width - 1
+        if width == 0 {
+            high = 64
+        }
+    }
+    if err1 != nil || err2 != nil || high > 63 || low > high {
+        return 0, 0, fmt.Errorf("invalid bit position of field(%v)", f.Name)
+    }
+    return uint(high), uint(low), nil
+}
+
+// enumerated values of the first read usage set, default entries are dropped
+func (f svdField) enums() ([]EnumValue, error) {
+    if len(f.EnumeratedValues) == 0 {
+        return nil, nil
+    }
+
+    set := f.EnumeratedValues[0]
+    for _, s := range f.EnumeratedValues {
+        if s.Usage == "" || s.Usage == "read" || s.Usage == "read-write" {
+            set = s
+            break
+        }
+    }
+
+    var enums []EnumValue
+    for _, e := range set.Values {
+        if e.IsDefault == "true" || e.Value == "" {
+            continue
+        }
+        values, err := enumValues(e.Value)
+        if err != nil {
+            return nil, err
+        }
+        for _, value := range values {
+            enums = append(enums, EnumValue{Name: e.Name, Value: value, Description: cleanDescription(e.Description)})
+        }
+    }
+    return enums, nil
+}
+
+// register layout of r without name and offset, a register without fields get a
+// single VALUE field covering its width
+func (r svdRegister) layout(props svdProperties, root *svdRoot) (Register, error) {
+    width := uint64(32)
+    var reset, resetMask uint64
+    var err error
+    if props.Size != "" {
+        if width, err = parseRegisterNumber(props.Size); err != nil || width == 0 || width > 64 {
+            return Register{}, fmt.Errorf("invalid size(%v) of register(%v)", props.Size, r.Name)
+        }
+    }
+    widthMask := ^uint64(0) >> (64 - width)
+    resetMask = widthMask
+    if props.ResetValue != "" {
+        if reset, err = parseRegisterNumber(props.ResetValue); err != nil {
+            return Register{}, err
+        }
+    }
+    if props.ResetMask != "" {
+        if resetMask, err = parseRegisterNumber(props.ResetMask); err != nil {
+            return Register{}, err
+        }
+    }
+
+    reg := Register{Width: uint(width), Description: cleanDescription(r.Description),
+                    UndefinedReset: widthMask &^ resetMask}
+    fields, err := svdResolve(r.Fields, root)
+    if err != nil {
+        return Register{}, err
+    }
+    if len(fields) == 0 {
+        access, err := xmlAccess(props.Access, "", "")
+        if err != nil {
+            return Register{}, err
+        }
+        reg.Fields = []Field{{Name: "VALUE", High: uint(width) - 1, Low: 0, Access: access, Reset: reset & widthMask}}
+        return reg, nil
+    }
+
+    for _, f := range fields {
+        high, low, err := f.bits()
+        if err != nil {
+            return Register{}, err
+        }
+        access := props.Access
+        if f.Access != "" {
+            access = f.Access
+        }
+        acc, err := xmlAccess(access, f.ModifiedWriteValues, f.ReadAction)
+        if err != nil {
+            return Register{}, err
+        }
+        enums, err := f.enums()
+        if err != nil {
+            return Register{}, err
+        }
+        names, step, err := f.expand(f.Name)
+        if err != nil {
+            return Register{}, err
+        }
+
+        for i, name := range names {
+            field := Field{Name: name, High: high + uint(step) * uint(i), Low: low + uint(step) * uint(i),
+                           Access: acc, Description: cleanDescription(f.Description), Enums: slices.Clone(enums)}
+            if field.High > 63 {
+                return Register{}, fmt.Errorf("invalid bit position of field(%v)", name)
+            }
+            field.Reset = (reset & field.Mask()) >> field.Low
+            reg.Fields = append(reg.Fields, field)
+        }
+    }
+    return reg, nil
+}
+
+// flatten registers and clusters of a scope, cluster registers are named
+// CLUSTER_REGISTER and offsets are relative to the peripheral
+func svdRegisters(regs []svdRegister, clusters []svdCluster, props svdProperties,
+                  prefix string, offset uint64, root *svdRoot) ([]Register, error) {
+    regs, err := svdResolve(regs, root)
+    if err != nil {
+        return nil, err
+    }
+
+    var out []Register
+    for _, r := range regs {
+        rp := props.inherit(r.svdProperties)
+        off, err := parseRegisterNumber(r.AddressOffset)
+        if err != nil {
+            return nil, fmt.Errorf("invalid addressOffset(%v) of register(%v)", r.AddressOffset, r.Name)
+        }
+        names, step, err := r.expand(r.Name)
+        if err != nil {
+            return nil, err
+        }
+        reg, err := r.layout(rp, root)
+        if err != nil {
+            return nil, err
+        }
+
+        for i, name := range names {
+            //every dim element get its own fields
+            elem := reg.Clone()
+            elem.Name = prefix + name
+            elem.Offset = offset + off + step * uint64(i)
+            if err = elem.Validate(); err != nil {
+                return nil, err
+            }
+            out = append(out, elem)
+        }
+    }
+
+    clusters, err = svdResolve(clusters, root)
+    if err != nil {
+        return nil, err
+    }
+    for _, c := range clusters {
+        off, err := parseRegisterNumber(c.AddressOffset)
+        if err != nil {
+            return nil, fmt.Errorf("invalid addressOffset(%v) of cluster(%v)", c.AddressOffset, c.Name)
+        }
+        names, step, err := c.expand(c.Name)
+        if err != nil {
+            return nil, err
+        }
+
+        for i, name := range names {
+            sub, err := svdRegisters(c.Registers, c.Clusters, props.inherit(c.svdProperties),
+                                     prefix + name + "_", offset + off + step * uint64(i), root)
+            if err != nil {
+                return nil, err
+            }
+            out = append(out, sub...)
+        }
+    }
+
+    slices.SortStableFunc(out, func(a, b Register) (int) {
+        return cmpUint64(a.Offset, b.Offset)
+    })
+    return out, nil
+}
+
+// deep copy of regs, so derived and dim peripherals share no fields
+func cloneRegisters(regs []Register) ([]Register) {
+    out := make([]Register, len(regs))
+    for i, r := range regs {
+        out[i] = r.Clone()
+    }
+    return out
+}
+
+// three-way comparison for sorting
+func cmpUint64(a uint64, b uint64) (int) {
+    switch {
+    case a < b:
+        return -1
+    case a > b:
+        return 1
+    }
+    return 0
+}
+
+// ParseSVD read a CMSIS-SVD device description. derivedFrom, dim arrays, clusters,
+// enumerated values and inherited register properties are resolved, so every
+// register of the result has its own offset, fields and reset value
+func ParseSVD(r io.Reader) (*Device, error) {
+    var d svdDevice
+    if err := xml.NewDecoder(r).Decode(&d); err != nil {
+        return nil, fmt.Errorf("invalid SVD: %w", err)
+    }
+
+    root := &svdRoot{device: &d, active: make(map[string]bool)}
+    periphs, err := svdResolve(d.Peripherals, root)
+    if err != nil {
+        return nil, err
+    }
+    device := &Device{Name: d.Name, Description: cleanDescription(d.Description)}
+    for _, p := range periphs {
+        base, err := parseRegisterNumber(p.BaseAddress)
+        if err != nil {
+            return nil, fmt.Errorf("invalid baseAddress(%v) of peripheral(%v)", p.BaseAddress, p.Name)
+        }
+        names, step, err := p.expand(p.Name)
+        if err != nil {
+            return nil, err
+        }
+        regs, err := svdRegisters(p.Registers, p.Clusters, d.svdProperties.inherit(p.svdProperties), "", 0, root)
+        if err != nil {
+            return nil, fmt.Errorf("peripheral(%v): %w", p.Name, err)
+        }
+
+        for i, name := range names {
+            device.Peripherals = append(device.Peripherals, Peripheral{Name: name,
+                BaseAddress: base + step * uint64(i), Description: cleanDescription(p.Description),
+                Registers: cloneRegisters(regs)})
+        }
+    }
+    return device, nil
+}
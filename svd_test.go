@@ -0,0 +1,238 @@
+package bitops
+
+import (
+    "os"
+    "strings"
+    "testing"
+)
+
+func TestParseRegisterNumber(t *testing.T) {
+    vectors := []struct {
+        s     string
+        value uint64
+    }{
+        {"42", 42}, {"0x2A", 42}, {"0X2a", 42}, {"#101010", 42}, {"0b101010", 42},
+        {"4k", 4096}, {"1M", 1 << 20}, {"'h2A", 42}, {"8'b0010_1010", 42}, {"'d42", 42}, {" 010 ", 10},
+    }
+    for _, v := range vectors {
+        if ret, err := parseRegisterNumber(v.s); err != nil || ret != v.value {
+            t.Fail()
+            t.Logf("parse %q get %d %v", v.s, ret, err)
+        }
+    }
+    for _, s := range []string{"", "0x", "12q", "'q1", "#102", "99999999999999999999", "17179869184G"} {
+        if _, err := parseRegisterNumber(s); err == nil {
+            t.Fail()
+            t.Logf("parse %q should fail", s)
+        }
+    }
+}
+
+func TestParseSVD(t *testing.T) {
+    f, err := os.Open("testdata/sample.svd")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer f.Close()
+    d, err := ParseSVD(f)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if d.Name != "SAMPLE" || d.Description != "Sample device for the SVD parser" || len(d.Peripherals) != 2 {
+        t.Fatalf("device get %+v", d)
+    }
+
+    uart, ok := d.Peripheral("UART0")
+    if !ok || uart.BaseAddress != 0x40001000 {
+        t.Fatalf("UART0 get %+v", uart)
+    }
+    var names []string
+    for _, r := range uart.Registers {
+        names = append(names, r.Name)
+    }
+    expect := "CTRL STATUS DATA FIFO0 FIFO1 CHA_CFG CHA_CNT CHB_CFG CHB_CNT"
+    if s := strings.Join(names, " "); s != expect {
+        t.Fatalf("registers expect %s but get %s", expect, s)
+    }
+
+    ctrl, _ := uart.Register("CTRL")
+    if ctrl.ResetValue() != 0xC04 || ctrl.Description != "Control" {
+        t.Fail()
+        t.Logf("CTRL reset get %x", ctrl.ResetValue())
+    }
+    mode, _ := ctrl.Field("MODE")
+    if mode.High != 2 || mode.Low != 1 || mode.Reset != 2 || len(mode.Enums) != 3 || mode.Enums[2] != (EnumValue{Name: "FAST", Value: 2}) {
+        t.Fail()
+        t.Logf("MODE get %+v", mode)
+    }
+    if start, _ := ctrl.Field("START"); start.Access != AccessW1S || start.Low != 3 {
+        t.Fail()
+        t.Logf("START get %+v", start)
+    }
+
+    status, _ := uart.Register("STATUS")
+    if status.UndefinedReset != 1 || len(status.Fields) != 7 {
+        t.Fail()
+        t.Logf("STATUS get %+v", status)
+    }
+    if errField, _ := status.Field("ERR"); errField.Access != AccessW1C || errField.Width() != 1 || errField.Low != 2 {
+        t.Fail()
+        t.Logf("derived ERR get %+v", errField)
+    }
+    if irq, _ := status.Field("IRQ3"); irq.Low != 7 || irq.Access != AccessRO {
+        t.Fail()
+        t.Logf("IRQ3 get %+v", irq)
+    }
+
+    if data, _ := uart.Register("DATA"); data.Width != 8 || data.Fields[0].Access != AccessRC {
+        t.Fail()
+        t.Logf("DATA get %+v", data)
+    }
+    fifo, _ := uart.Register("FIFO1")
+    if fifo.Offset != 0x14 || fifo.Width != 16 || fifo.Fields[0].Access != AccessWO || fifo.Fields[0].Width() != 16 {
+        t.Fail()
+        t.Logf("FIFO1 get %+v", fifo)
+    }
+    cfg, _ := uart.Register("CHB_CFG")
+    if cfg.Offset != 0x30 || cfg.ResetValue() != 1 {
+        t.Fail()
+        t.Logf("CHB_CFG get %+v", cfg)
+    }
+
+    //the derived peripheral copy every register at its own base address
+    uart1, ok := d.Peripheral("UART1")
+    if !ok || uart1.BaseAddress != 0x40002000 || len(uart1.Registers) != len(uart.Registers) || uart1.Description != "Serial port" {
+        t.Fatalf("UART1 get %+v", uart1)
+    }
+    if _, err = NewRegisterBank(uart1.Registers); err != nil {
+        t.Fail()
+        t.Logf("bank of UART1 get %v", err)
+    }
+}
+
+func TestParseSVDInvalid(t *testing.T) {
+    wrap := func(registers string) (string) {
+        return "<device><name>D</name><peripherals><peripheral><name>P</name><baseAddress>0</baseAddress><registers>" +
+               registers + "</registers></peripheral></peripherals></device>"
+    }
+    invalid := map[string]string{
+        "xml":        "<device>",
+        "derived":    wrap("<register derivedFrom='NONE'><name>A</name><addressOffset>0</addressOffset></register>"),
+        "circular":   wrap("<register derivedFrom='B'><name>A</name></register><register derivedFrom='A'><name>B</name></register>"),
+        "overlapped": wrap("<register><name>A</name><addressOffset>0</addressOffset><fields>" +
+                           "<field><name>X</name><bitRange>[3:0]</bitRange></field>" +
+                           "<field><name>Y</name><bitRange>[4:3]</bitRange></field></fields></register>"),
+        "outside":    wrap("<register><name>A</name><addressOffset>0</addressOffset><size>8</size><fields>" +
+                           "<field><name>X</name><bitOffset>6</bitOffset><bitWidth>4</bitWidth></field></fields></register>"),
+        "access":     wrap("<register><name>A</name><addressOffset>0</addressOffset><access>execute</access></register>"),
+        "dim":        wrap("<register><name>A%s</name><dim>3</dim><dimIncrement>4</dimIncrement><dimIndex>X,Y</dimIndex>" +
+                           "<addressOffset>0</addressOffset></register>"),
+        "offset":     wrap("<register><name>A</name><addressOffset>zero</addressOffset></register>"),
+    }
+    for name, s := range invalid {
+        if _, err := ParseSVD(strings.NewReader(s)); err == nil {
+            t.Fail()
+            t.Logf("%s should fail", name)
+        }
+    }
+
+    //a don't care digit expand to every matching value
+    d, err := ParseSVD(strings.NewReader(wrap("<register><name>A</name><addressOffset>0</addressOffset><fields>" +
+        "<field><name>X</name><bitRange>[2:0]</bitRange><enumeratedValues><enumeratedValue><name>ODD</name>" +
+        "<value>#xx1</value></enumeratedValue></enumeratedValues></field></fields></register>")))
+    if err != nil {
+        t.Fatal(err)
+    }
+    x, _ := d.Peripherals[0].Registers[0].Field("X")
+    if len(x.Enums) != 4 || x.Enums[0].Value != 1 || x.Enums[3].Value != 7 {
+        t.Fail()
+        t.Logf("don't care get %+v", x.Enums)
+    }
+}
+
+func TestParseSVDDerivedPath(t *testing.T) {
+    periph := func(name string, registers string) (string) {
+        return "<peripheral><name>" + name + "</name><baseAddress>0</baseAddress><registers>" + registers +
+               "</registers></peripheral>"
+    }
+    device := func(periphs ...string) (string) {
+        return "<device><name>D</name><peripherals>" + strings.Join(periphs, "") + "</peripherals></device>"
+    }
+    ctrl := "<register><name>CTRL</name><addressOffset>0</addressOffset><fields>" +
+            "<field><name>EN</name><bitRange>[0:0]</bitRange></field></fields></register>"
+
+    //a dotted path start from the device, not from the register scope
+    d, err := ParseSVD(strings.NewReader(device(periph("A", ctrl),
+        periph("B", "<register derivedFrom='A.CTRL'><name>CTRL</name><addressOffset>4</addressOffset></register>" +
+                    "<cluster><name>CH</name><addressOffset>8</addressOffset>" +
+                    "<register derivedFrom='A.CTRL'><name>CFG</name><addressOffset>0</addressOffset></register></cluster>"),
+        periph("C", "<register derivedFrom='B.CH.CFG'><name>CFG</name><addressOffset>0</addressOffset></register>"))))
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, name := range []string{"B.CTRL", "B.CH_CFG", "C.CFG"} {
+        p, _ := d.Peripheral(name[:1])
+        r, ok := p.Register(name[2:])
+        if _, en := r.Field("EN"); !ok || !en {
+            t.Fail()
+            t.Logf("%s get %+v", name, r)
+        }
+    }
+    if p, _ := d.Peripheral("B"); p.Registers[0].Offset != 4 {
+        t.Fail()
+        t.Logf("derived offset get %d", p.Registers[0].Offset)
+    }
+
+    invalid := map[string]string{
+        "missing":   device(periph("A", "<register derivedFrom='B.CTRL'><name>CTRL</name></register>")),
+        "kind":      device(periph("A", ctrl + "<cluster derivedFrom='A.CTRL'><name>CL</name></cluster>")),
+        "circular":  device(periph("A", "<register derivedFrom='B.CTRL'><name>CTRL</name></register>"),
+                            periph("B", "<register derivedFrom='A.CTRL'><name>CTRL</name></register>")),
+        "self":      device(periph("A", "<register derivedFrom='A.CTRL'><name>CTRL</name></register>")),
+    }
+    for name, s := range invalid {
+        if _, err := ParseSVD(strings.NewReader(s)); err == nil {
+            t.Fail()
+            t.Logf("%s should fail", name)
+        }
+    }
+}
+
+func TestParseSVDIndependentCopies(t *testing.T) {
+    f, err := os.Open("testdata/sample.svd")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer f.Close()
+    d, err := ParseSVD(f)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    //dim elements and derived peripherals must not share fields or enums
+    uart0, _ := d.Peripheral("UART0")
+    uart1, _ := d.Peripheral("UART1")
+    fifo0, _ := uart0.Register("FIFO0")
+    fifo1, _ := uart0.Register("FIFO1")
+    ctrl0, _ := uart0.Register("CTRL")
+    ctrl1, _ := uart1.Register("CTRL")
+    name := fifo1.Fields[0].Name
+    fifo0.Fields[0].Name = "CHANGED"
+    if fifo1.Fields[0].Name != name {
+        t.Fail()
+        t.Log("FIFO1 share fields with FIFO0")
+    }
+    for i, f := range ctrl0.Fields {
+        if len(f.Enums) == 0 {
+            continue
+        }
+        enum := ctrl1.Fields[i].Enums[0].Name
+        ctrl0.Fields[i].Enums[0].Name = "CHANGED"
+        ctrl0.Fields[i].Name = "CHANGED"
+        if ctrl1.Fields[i].Enums[0].Name != enum || ctrl1.Fields[i].Name == "CHANGED" {
+            t.Fail()
+            t.Log("UART1 share fields with UART0")
+        }
+        break
+    }
+}
@@ -0,0 +1,279 @@
+package bitops
+
+// SWAR (SIMD within a register) helpers treat a word as independent lanes and are
+// named element bits x lane count as Go SIMD types: 8x8 is 8 byte lanes of a
+// uint64, 8x4 is 4 byte lanes of a uint32, 16x4 and 16x2 are 16-bit lanes of a
+// uint64 and a uint32. Lane 0 is the least significant, the first byte of a
+// little-endian load. Lane masks are all ones in a selected lane and 0 elsewhere
+// and lane values are unsigned
+
+// lane layout, one is 1 in every lane and high the top bit of every lane
+type swarLanes struct {
+    bits uint
+    one  uint64
+    high uint64
+}
+
+var (
+    lanes8  = swarLanes{bits: 8, one: 0x0101010101010101, high: 0x8080808080808080}
+    lanes16 = swarLanes{bits: 16, one: 0x0001000100010001, high: 0x8000800080008000}
+)
+
+// spread the top bit of every lane to the whole lane
+func (l swarLanes) fill(high uint64) (uint64) {
+    return (high >> (l.bits - 1)) * (1 << l.bits - 1)
+}
+
+// mask of the lanes equal to 0, exact since no carry leave a lane
+func (l swarLanes) zero(v uint64) (uint64) {
+    low := ^l.high
+    return l.fill(^((v & low) + low | v | low))
+}
+
+// mask of the lanes equal to x
+func (l swarLanes) equal(v uint64, x uint64) (uint64) {
+    return l.zero(v ^ l.one * x)
+}
+
+// per-lane a + b modulo the lane size
+func (l swarLanes) add(a uint64, b uint64) (uint64) {
+    return ((a &^ l.high) + (b &^ l.high)) ^ ((a ^ b) & l.high)
+}
+
+// per-lane a - b modulo the lane size
+func (l swarLanes) sub(a uint64, b uint64) (uint64) {
+    return ((a | l.high) - (b &^ l.high)) ^ ((a ^ ^b) & l.high)
+}
+
+// mask of the lanes where a < b, the borrow out of every lane of a - b
+func (l swarLanes) less(a uint64, b uint64) (uint64) {
+    return l.fill((^a & b | ^(a ^ b) & l.sub(a, b)) & l.high)
+}
+
+// per-lane minimum of a and b
+func (l swarLanes) min(a uint64, b uint64) (uint64) {
+    m := l.less(a, b)
+    return a & m | b &^ m
+}
+
+// per-lane maximum of a and b
+func (l swarLanes) max(a uint64, b uint64) (uint64) {
+    m := l.less(a, b)
+    return b & m | a &^ m
+}
+
+// index of the lowest non-zero lane of mask, -1 if mask is 0
+func (l swarLanes) first(mask uint64) (int) {
+    if mask == 0 {
+        return -1
+    }
+    return int(CountTrailZero64(mask) / l.bits)
+}
+
+// HasZero8x8 report whether a byte of v is 0
+func HasZero8x8(v uint64) (bool) {
+    return (v - lanes8.one) &^ v & lanes8.high != 0
+}
+
+// HasZero8x4 report whether a byte of v is 0
+func HasZero8x4(v uint32) (bool) {
+    return HasZero8x8(uint64(v) | 0xFFFFFFFF00000000)
+}
+
+// HasZero16x4 report whether a 16-bit lane of v is 0
+func HasZero16x4(v uint64) (bool) {
+    return (v - lanes16.one) &^ v & lanes16.high != 0
+}
+
+// HasZero16x2 report whether a 16-bit lane of v is 0
+func HasZero16x2(v uint32) (bool) {
+    return HasZero16x4(uint64(v) | 0xFFFFFFFF00000000)
+}
+
+// HasEqual8x8 report whether a byte of v is x
+func HasEqual8x8(v uint64, x uint8) (bool) {
+    return HasZero8x8(v ^ lanes8.one * uint64(x))
+}
+
+// HasEqual8x4 report whether a byte of v is x
+func HasEqual8x4(v uint32, x uint8) (bool) {
+    return HasZero8x4(v ^ uint32(lanes8.one) * uint32(x))
+}
+
+// HasEqual16x4 report whether a 16-bit lane of v is x
+func HasEqual16x4(v uint64, x uint16) (bool) {
+    return HasZero16x4(v ^ lanes16.one * uint64(x))
+}
+
+// HasEqual16x2 report whether a 16-bit lane of v is x
+func HasEqual16x2(v uint32, x uint16) (bool) {
+    return HasZero16x2(v ^ uint32(lanes16.one) * uint32(x))
+}
+
+// ZeroLanes8x8 return the mask of the bytes of v equal to 0
+func ZeroLanes8x8(v uint64) (uint64) {
+    return lanes8.zero(v)
+}
+
+// ZeroLanes8x4 return the mask of the bytes of v equal to 0
+func ZeroLanes8x4(v uint32) (uint32) {
+    return uint32(lanes8.zero(uint64(v)))
+}
+
+// ZeroLanes16x4 return the mask of the 16-bit lanes of v equal to 0
+func ZeroLanes16x4(v uint64) (uint64) {
+    return lanes16.zero(v)
+}
+
+// ZeroLanes16x2 return the mask of the 16-bit lanes of v equal to 0
+func ZeroLanes16x2(v uint32) (uint32) {
+    return uint32(lanes16.zero(uint64(v)))
+}
+
+// EqualLanes8x8 return the mask of the bytes of v equal to x
+func EqualLanes8x8(v uint64, x uint8) (uint64) {
+    return lanes8.equal(v, uint64(x))
+}
+
+// EqualLanes8x4 return the mask of the bytes of v equal to x
+func EqualLanes8x4(v uint32, x uint8) (uint32) {
+    return uint32(lanes8.equal(uint64(v), uint64(x)))
+}
+
+// EqualLanes16x4 return the mask of the 16-bit lanes of v equal to x
+func EqualLanes16x4(v uint64, x uint16) (uint64) {
+    return lanes16.equal(v, uint64(x))
+}
+
+// EqualLanes16x2 return the mask of the 16-bit lanes of v equal to x
+func EqualLanes16x2(v uint32, x uint16) (uint32) {
+    return uint32(lanes16.equal(uint64(v), uint64(x)))
+}
+
+// LessLanes8x8 return the mask of the bytes where a is less than b
+func LessLanes8x8(a uint64, b uint64) (uint64) {
+    return lanes8.less(a, b)
+}
+
+// LessLanes8x4 return the mask of the bytes where a is less than b
+func LessLanes8x4(a uint32, b uint32) (uint32) {
+    return uint32(lanes8.less(uint64(a), uint64(b)))
+}
+
+// LessLanes16x4 return the mask of the 16-bit lanes where a is less than b
+func LessLanes16x4(a uint64, b uint64) (uint64) {
+    return lanes16.less(a, b)
+}
+
+// LessLanes16x2 return the mask of the 16-bit lanes where a is less than b
+func LessLanes16x2(a uint32, b uint32) (uint32) {
+    return uint32(lanes16.less(uint64(a), uint64(b)))
+}
+
+// Add8x8 return the per-byte sum of a and b, a carry does not reach the next byte
+func Add8x8(a uint64, b uint64) (uint64) {
+    return lanes8.add(a, b)
+}
+
+// Add8x4 return the per-byte sum of a and b, a carry does not reach the next byte
+func Add8x4(a uint32, b uint32) (uint32) {
+    return uint32(lanes8.add(uint64(a), uint64(b)))
+}
+
+// Add16x4 return the per-lane sum of a and b, a carry does not reach the next lane
+func Add16x4(a uint64, b uint64) (uint64) {
+    return lanes16.add(a, b)
+}
+
+// Add16x2 return the per-lane sum of a and b, a carry does not reach the next lane
+func Add16x2(a uint32, b uint32) (uint32) {
+    return uint32(lanes16.add(uint64(a), uint64(b)))
+}
+
+// Sub8x8 return the per-byte difference a - b, a borrow does not reach the next
+// byte
+func Sub8x8(a uint64, b uint64) (uint64) {
+    return lanes8.sub(a, b)
+}
+
+// Sub8x4 return the per-byte difference a - b, a borrow does not reach the next
+// byte
+func Sub8x4(a uint32, b uint32) (uint32) {
+    return uint32(lanes8.sub(uint64(a), uint64(b)))
+}
+
+// Sub16x4 return the per-lane difference a - b, a borrow does not reach the next
+// lane
+func Sub16x4(a uint64, b uint64) (uint64) {
+    return lanes16.sub(a, b)
+}
+
+// Sub16x2 return the per-lane difference a - b, a borrow does not reach the next
+// lane
+func Sub16x2(a uint32, b uint32) (uint32) {
+    return uint32(lanes16.sub(uint64(a), uint64(b)))
+}
+
+// Min8x8 return the per-byte minimum of a and b
+func Min8x8(a uint64, b uint64) (uint64) {
+    return lanes8.min(a, b)
+}
+
+// Min8x4 return the per-byte minimum of a and b
+func Min8x4(a uint32, b uint32) (uint32) {
+    return uint32(lanes8.min(uint64(a), uint64(b)))
+}
+
+// Min16x4 return the per-lane minimum of a and b
+func Min16x4(a uint64, b uint64) (uint64) {
+    return lanes16.min(a, b)
+}
+
+// Min16x2 return the per-lane minimum of a and b
+func Min16x2(a uint32, b uint32) (uint32) {
+    return uint32(lanes16.min(uint64(a), uint64(b)))
+}
+
+// Max8x8 return the per-byte maximum of a and b
+func Max8x8(a uint64, b uint64) (uint64) {
+    return lanes8.max(a, b)
+}
+
+// Max8x4 return the per-byte maximum of a and b
+func Max8x4(a uint32, b uint32) (uint32) {
+    return uint32(lanes8.max(uint64(a), uint64(b)))
+}
+
+// Max16x4 return the per-lane maximum of a and b
+func Max16x4(a uint64, b uint64) (uint64) {
+    return lanes16.max(a, b)
+}
+
+// Max16x2 return the per-lane maximum of a and b
+func Max16x2(a uint32, b uint32) (uint32) {
+    return uint32(lanes16.max(uint64(a), uint64(b)))
+}
+
+// FirstLane8x8 return the index of the first non-zero byte of a lane mask such as
+// EqualLanes8x8 return, -1 if mask is 0
+func FirstLane8x8(mask uint64) (int) {
+    return lanes8.first(mask)
+}
+
+// FirstLane8x4 return the index of the first non-zero byte of a lane mask, -1 if
+// mask is 0
+func FirstLane8x4(mask uint32) (int) {
+    return lanes8.first(uint64(mask))
+}
+
+// FirstLane16x4 return the index of the first non-zero 16-bit lane of a lane mask,
+// -1 if mask is 0
+func FirstLane16x4(mask uint64) (int) {
+    return lanes16.first(mask)
+}
+
+// FirstLane16x2 return the index of the first non-zero 16-bit lane of a lane mask,
+// -1 if mask is 0
+func FirstLane16x2(mask uint32) (int) {
+    return lanes16.first(uint64(mask))
+}
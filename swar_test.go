@@ -0,0 +1,148 @@
+package bitops
+
+import (
+    "encoding/binary"
+    "math/rand"
+    "testing"
+)
+
+// per-lane reference of op over the lanes of a and b, bits wide in a word of size
+func laneRef(a uint64, b uint64, bits uint, size uint, op func(x, y uint64) (uint64)) (uint64) {
+    mask := uint64(1) << bits - 1
+    r := uint64(0)
+    for s := uint(0); s < size; s += bits {
+        r |= (op(a >> s & mask, b >> s & mask) & mask) << s
+    }
+    return r
+}
+
+// random words with many 0, equal and extreme lanes
+func swarSamples() ([][2]uint64) {
+    rnd := rand.New(rand.NewSource(50))
+    special := []uint64{0, 1, 0x7F, 0x80, 0xFF, 0x7FFF, 0x8000, 0xFFFF}
+    samples := [][2]uint64{{0, 0}, {^uint64(0), 0}, {0, ^uint64(0)}, {0x8080808080808080, 0x7F7F7F7F7F7F7F7F}}
+    for i := 0; i < 5000; i++ {
+        var w [2]uint64
+        for j := range w {
+            w[j] = rnd.Uint64()
+            for k := uint(0); k < 64; k += 8 {
+                if rnd.Intn(3) == 0 {
+                    w[j] = w[j] &^ (0xFF << k) | (special[rnd.Intn(len(special))] & 0xFF) << k
+                }
+            }
+        }
+        if rnd.Intn(4) == 0 {
+            w[1] = w[0] ^ 0xFF << (8 * rnd.Intn(8))
+        }
+        samples = append(samples, w)
+    }
+    return samples
+}
+
+func TestSWARLanes(t *testing.T) {
+    full := func(c bool) (uint64) {
+        if c {
+            return ^uint64(0)
+        }
+        return 0
+    }
+    ops := []struct {
+        name string
+        f8   func(a, b uint64) (uint64)
+        f16  func(a, b uint64) (uint64)
+        ref  func(x, y uint64) (uint64)
+    }{
+        {"add", Add8x8, Add16x4, func(x, y uint64) (uint64) { return x + y }},
+        {"sub", Sub8x8, Sub16x4, func(x, y uint64) (uint64) { return x - y }},
+        {"min", Min8x8, Min16x4, func(x, y uint64) (uint64) { return min(x, y) }},
+        {"max", Max8x8, Max16x4, func(x, y uint64) (uint64) { return max(x, y) }},
+        {"less", LessLanes8x8, LessLanes16x4, func(x, y uint64) (uint64) { return full(x < y) }},
+        {"zero", func(a, b uint64) (uint64) { return ZeroLanes8x8(a) },
+                 func(a, b uint64) (uint64) { return ZeroLanes16x4(a) },
+                 func(x, y uint64) (uint64) { return full(x == 0) }},
+    }
+    ops32 := []struct {
+        f8  func(a, b uint32) (uint32)
+        f16 func(a, b uint32) (uint32)
+    }{
+        {Add8x4, Add16x2}, {Sub8x4, Sub16x2}, {Min8x4, Min16x2}, {Max8x4, Max16x2}, {LessLanes8x4, LessLanes16x2},
+        {func(a, b uint32) (uint32) { return ZeroLanes8x4(a) }, func(a, b uint32) (uint32) { return ZeroLanes16x2(a) }},
+    }
+
+    for _, w := range swarSamples() {
+        a, b := w[0], w[1]
+        for i, op := range ops {
+            if r, expect := op.f8(a, b), laneRef(a, b, 8, 64, op.ref); r != expect {
+                t.Fatalf("%s 8x8 %#x %#x expect %#x but get %#x", op.name, a, b, expect, r)
+            }
+            if r, expect := op.f16(a, b), laneRef(a, b, 16, 64, op.ref); r != expect {
+                t.Fatalf("%s 16x4 %#x %#x expect %#x but get %#x", op.name, a, b, expect, r)
+            }
+            if r, expect := ops32[i].f8(uint32(a), uint32(b)), laneRef(a, b, 8, 32, op.ref); uint64(r) != expect {
+                t.Fatalf("%s 8x4 %#x %#x expect %#x but get %#x", op.name, uint32(a), uint32(b), expect, r)
+            }
+            if r, expect := ops32[i].f16(uint32(a), uint32(b)), laneRef(a, b, 16, 32, op.ref); uint64(r) != expect {
+                t.Fatalf("%s 16x2 %#x %#x expect %#x but get %#x", op.name, uint32(a), uint32(b), expect, r)
+            }
+        }
+
+        if HasZero8x8(a) != (ZeroLanes8x8(a) != 0) || HasZero16x4(a) != (ZeroLanes16x4(a) != 0) ||
+           HasZero8x4(uint32(a)) != (ZeroLanes8x4(uint32(a)) != 0) || HasZero16x2(uint32(a)) != (ZeroLanes16x2(uint32(a)) != 0) {
+            t.Fatalf("has zero of %#x", a)
+        }
+        x := uint8(b)
+        if m := EqualLanes8x8(a, x); m != ZeroLanes8x8(a ^ 0x0101010101010101 * uint64(x)) || HasEqual8x8(a, x) != (m != 0) {
+            t.Fatalf("equal %#x of %#x get %#x", x, a, m)
+        }
+        if m := EqualLanes8x4(uint32(a), x); uint64(m) != EqualLanes8x8(a, x) & 0xFFFFFFFF || HasEqual8x4(uint32(a), x) != (m != 0) {
+            t.Fatalf("equal %#x of %#x get %#x", x, uint32(a), m)
+        }
+        y := uint16(b)
+        if m := EqualLanes16x4(a, y); m != ZeroLanes16x4(a ^ 0x0001000100010001 * uint64(y)) || HasEqual16x4(a, y) != (m != 0) {
+            t.Fatalf("equal %#x of %#x get %#x", y, a, m)
+        }
+        if m := EqualLanes16x2(uint32(a), y); uint64(m) != EqualLanes16x4(a, y) & 0xFFFFFFFF || HasEqual16x2(uint32(a), y) != (m != 0) {
+            t.Fatalf("equal %#x of %#x get %#x", y, uint32(a), m)
+        }
+    }
+}
+
+func TestFirstLane(t *testing.T) {
+    //scan for the first quote or backslash as a JSON string parser does
+    text := []byte(`abcdefgh0123"5\7`)
+    first := -1
+    for i := 0; i < len(text) && first < 0; i += 8 {
+        v := binary.LittleEndian.Uint64(text[i:])
+        if lane := FirstLane8x8(EqualLanes8x8(v, '"') | EqualLanes8x8(v, '\\')); lane >= 0 {
+            first = i + lane
+        }
+    }
+    if first != 12 {
+        t.Fail()
+        t.Logf("expect quote at 12 but get %d", first)
+    }
+
+    vectors := []struct {
+        mask   uint64
+        lane8  int
+        lane16 int
+    }{{0, -1, -1}, {0xFF, 0, 0}, {0xFF00, 1, 0}, {0xFF0000, 2, 1}, {0xFF00000000000000, 7, 3}, {0xFFFF00000000, 4, 2}}
+    for _, v := range vectors {
+        if ret := FirstLane8x8(v.mask); ret != v.lane8 {
+            t.Fail()
+            t.Logf("first lane 8x8 of %#x expect %d but get %d", v.mask, v.lane8, ret)
+        }
+        if ret := FirstLane16x4(v.mask); ret != v.lane16 {
+            t.Fail()
+            t.Logf("first lane 16x4 of %#x expect %d but get %d", v.mask, v.lane16, ret)
+        }
+        if v.mask >> 32 == 0 && (FirstLane8x4(uint32(v.mask)) != v.lane8 || FirstLane16x2(uint32(v.mask)) != v.lane16) {
+            t.Fail()
+            t.Logf("first lane 32-bit of %#x", v.mask)
+        }
+    }
+    if FirstLane8x4(0) != -1 || FirstLane16x2(0) != -1 {
+        t.Fail()
+        t.Log("first lane of 0")
+    }
+}
@@ -0,0 +1,420 @@
+package bitops
+
+import (
+    "fmt"
+    "math/bits"
+    "strings"
+)
+
+// Uint128 is an unsigned 128-bit integer. Hi keeps bit 127:64 and Lo keeps bit 63:0.
+// All arithmetic wraps around modulo 2^128 like the native unsigned types
+type Uint128 struct {
+    Hi uint64
+    Lo uint64
+}
+
+// NewUint128 build a 128-bit value from the high and low 64-bit halves
+func NewUint128(hi uint64, lo uint64) (Uint128) {
+    return Uint128{Hi: hi, Lo: lo}
+}
+
+// Uint128From64 zero-extend a 64-bit value to 128-bit
+func Uint128From64(value uint64) (Uint128) {
+    return Uint128{Lo: value}
+}
+
+// IsZero report whether u is 0
+func (u Uint128) IsZero() (bool) {
+    return u.Hi == 0 && u.Lo == 0
+}
+
+// Equal report whether u and v are the same
+func (u Uint128) Equal(v Uint128) (bool) {
+    return u == v
+}
+
+// Cmp compare u and v and return -1, 0 or +1 for u < v, u == v and u > v
+func (u Uint128) Cmp(v Uint128) (int) {
+    switch {
+    case u.Hi < v.Hi:
+        return -1
+    case u.Hi > v.Hi:
+        return 1
+    case u.Lo < v.Lo:
+        return -1
+    case u.Lo > v.Lo:
+        return 1
+    }
+    return 0
+}
+
+// And return u & v
+func (u Uint128) And(v Uint128) (Uint128) {
+    return Uint128{u.Hi & v.Hi, u.Lo & v.Lo}
+}
+
+// Or return u | v
+func (u Uint128) Or(v Uint128) (Uint128) {
+    return Uint128{u.Hi | v.Hi, u.Lo | v.Lo}
+}
+
+// Xor return u ^ v
+func (u Uint128) Xor(v Uint128) (Uint128) {
+    return Uint128{u.Hi ^ v.Hi, u.Lo ^ v.Lo}
+}
+
+// AndNot return u &^ v
+func (u Uint128) AndNot(v Uint128) (Uint128) {
+    return Uint128{u.Hi &^ v.Hi, u.Lo &^ v.Lo}
+}
+
+// Not return ^u
+func (u Uint128) Not() (Uint128) {
+    return Uint128{^u.Hi, ^u.Lo}
+}
+
+// Lsh return u << shift, the result is 0 if shift is larger than 127
+func (u Uint128) Lsh(shift uint) (Uint128) {
+    switch {
+    case shift >= 128:
+        return Uint128{}
+    case shift >= 64:
+        return Uint128{u.Lo << (shift - 64), 0}
+    case shift == 0:
+        return u
+    }
+    return Uint128{(u.Hi << shift) | (u.Lo >> (64 - shift)), u.Lo << shift}
+}
+
+// Rsh return u >> shift, the result is 0 if shift is larger than 127
+func (u Uint128) Rsh(shift uint) (Uint128) {
+    switch {
+    case shift >= 128:
+        return Uint128{}
+    case shift >= 64:
+        return Uint128{0, u.Hi >> (shift - 64)}
+    case shift == 0:
+        return u
+    }
+    return Uint128{u.Hi >> shift, (u.Lo >> shift) | (u.Hi << (64 - shift))}
+}
+
+// Add return u + v
+func (u Uint128) Add(v Uint128) (Uint128) {
+    lo, carry := bits.Add64(u.Lo, v.Lo, 0)
+    hi, _ := bits.Add64(u.Hi, v.Hi, carry)
+    return Uint128{hi, lo}
+}
+
+// Sub return u - v
+func (u Uint128) Sub(v Uint128) (Uint128) {
+    lo, borrow := bits.Sub64(u.Lo, v.Lo, 0)
+    hi, _ := bits.Sub64(u.Hi, v.Hi, borrow)
+    return Uint128{hi, lo}
+}
+
+// Mul return u * v
+func (u Uint128) Mul(v Uint128) (Uint128) {
+    hi, lo := bits.Mul64(u.Lo, v.Lo)
+    hi += u.Hi * v.Lo + u.Lo * v.Hi
+    return Uint128{hi, lo}
+}
+
+// QuoRem return the quotient and remainder of u / v. It returns an error
+// and leave u unchanged if v is 0
+func (u Uint128) QuoRem(v Uint128) (Uint128, Uint128, error) {
+    if v.IsZero() {
+        return u, Uint128{}, fmt.Errorf("division by zero")
+    }
+
+    if v.Hi == 0 {
+        // two-step long division by a 64-bit divisor
+        qhi, r := u.Hi / v.Lo, u.Hi % v.Lo
+        qlo, r := bits.Div64(r, u.Lo, v.Lo)
+        return Uint128{qhi, qlo}, Uint128{0, r}, nil
+    }
+
+    // the quotient fits in 64 bits, use shift and subtract
+    if CountLeadZero128(u) > CountLeadZero128(v) {
+        return Uint128{}, u, nil
+    }
+
+    var quo Uint128
+    rem := u
+    shift := CountLeadZero128(v) - CountLeadZero128(u)
+    divisor := v.Lsh(shift)
+    for i := int(shift); i >= 0; i-- {
+        quo = quo.Lsh(1)
+        if rem.Cmp(divisor) >= 0 {
+            rem = rem.Sub(divisor)
+            quo.Lo |= 1
+        }
+        divisor = divisor.Rsh(1)
+    }
+
+    return quo, rem, nil
+}
+
+// Quo return u / v, see QuoRem for error
+func (u Uint128) Quo(v Uint128) (Uint128, error) {
+    quo, _, err := u.QuoRem(v)
+    return quo, err
+}
+
+// Rem return u % v, see QuoRem for error
+func (u Uint128) Rem(v Uint128) (Uint128, error) {
+    _, rem, err := u.QuoRem(v)
+    return rem, err
+}
+
+// String return the hexadecimal representation with 0x prefix
+func (u Uint128) String() (string) {
+    return "0x" + u.Text(16)
+}
+
+// Text return the representation of u in the given base (2, 10 or 16) without prefix.
+// Other base fall back to 16
+func (u Uint128) Text(base int) (string) {
+    switch base {
+    case 2:
+        if u.Hi == 0 {
+            return fmt.Sprintf("%b", u.Lo)
+        }
+        return fmt.Sprintf("%b%064b", u.Hi, u.Lo)
+    case 10:
+        if u.Hi == 0 {
+            return fmt.Sprintf("%d", u.Lo)
+        }
+        // peel off 19 decimal digits at a time
+        const chunk = 10000000000000000000
+        var parts []string
+        for !u.IsZero() {
+            quo, rem, _ := u.QuoRem(Uint128From64(chunk))
+            u = quo
+            if u.IsZero() {
+                parts = append(parts, fmt.Sprintf("%d", rem.Lo))
+            } else {
+                parts = append(parts, fmt.Sprintf("%019d", rem.Lo))
+            }
+        }
+        var sb strings.Builder
+        for i := len(parts) - 1; i >= 0; i-- {
+            sb.WriteString(parts[i])
+        }
+        return sb.String()
+    }
+
+    if u.Hi == 0 {
+        return fmt.Sprintf("%x", u.Lo)
+    }
+    return fmt.Sprintf("%x%016x", u.Hi, u.Lo)
+}
+
+// ParseUint128 parse s in the given base (2, 10 or 16). If base is 0, the base is
+// implied by the prefix 0b/0B, 0x/0X or decimal otherwise. Underscores are allowed
+// between digits
+func ParseUint128(s string, base int) (Uint128, error) {
+    var ret Uint128
+    orig := s
+
+    if base == 0 {
+        base = 10
+        if len(s) > 2 && s[0] == '0' {
+            switch s[1] {
+            case 'x', 'X':
+                base = 16
+                s = s[2:]
+            case 'b', 'B':
+                base = 2
+                s = s[2:]
+            }
+        }
+    }
+    if base != 2 && base != 10 && base != 16 {
+        return ret, fmt.Errorf("invalid base(%v)", base)
+    }
+    if len(s) == 0 {
+        return ret, fmt.Errorf("invalid syntax(%q)", orig)
+    }
+
+    b := Uint128From64(uint64(base))
+    limit, _, _ := Uint128{^uint64(0), ^uint64(0)}.QuoRem(b)
+    digits := 0
+    for i := 0; i < len(s); i++ {
+        c := s[i]
+        if c == '_' {
+            continue
+        }
+
+        var d uint64
+        switch {
+        case '0' <= c && c <= '9':
+            d = uint64(c - '0')
+        case 'a' <= c && c <= 'f':
+            d = uint64(c - 'a' + 10)
+        case 'A' <= c && c <= 'F':
+            d = uint64(c - 'A' + 10)
+        default:
+            return Uint128{}, fmt.Errorf("invalid syntax(%q)", orig)
+        }
+        if d >= uint64(base) {
+            return Uint128{}, fmt.Errorf("invalid syntax(%q)", orig)
+        }
+
+        product := ret.Mul(b)
+        next := product.Add(Uint128From64(d))
+        if ret.Cmp(limit) > 0 || next.Cmp(product) < 0 {
+            return Uint128{}, fmt.Errorf("value out of range(%q)", orig)
+        }
+        ret = next
+        digits++
+    }
+    if digits == 0 {
+        return Uint128{}, fmt.Errorf("invalid syntax(%q)", orig)
+    }
+
+    return ret, nil
+}
+
+// real implementation for Extract128 and GetField128
+func extract128(value Uint128, start uint, length uint) (Uint128, error) {
+    mask := Uint128{^uint64(0), ^uint64(0)}.Rsh(128 - length)
+    return value.Rsh(start).And(mask), nil
+}
+
+// real implementation for Deposit128 and SetField128
+func deposit128(value Uint128, start uint, length uint, field Uint128) (Uint128, error) {
+    mask := Uint128{^uint64(0), ^uint64(0)}.Rsh(128 - length).Lsh(start)
+    return value.AndNot(mask).Or(field.Lsh(start).And(mask)), nil
+}
+
+// Extract128 specify field from Uint128 by staring position and length
+// LSB/MSB are 0/127 and return original value if error occurs
+func Extract128(value Uint128, start uint, length uint) (Uint128, error) {
+    if start > 127 || length > 128 - start {
+        return value, fmt.Errorf("invalid start(%v) or length(%v)", start, length)
+    }
+
+    return extract128(value, start, length)
+}
+
+// GetField128 specify field between high and low bit from Uint128
+// LSB/MSB are 0/127 and return original value if error occurs
+func GetField128(value Uint128, high uint, low uint) (Uint128, error) {
+    if high > 127 || low > 127 || high < low {
+        return value, fmt.Errorf("invalid high(%v) or low(%v)", high, low)
+    }
+
+    return extract128(value, low, high - low + 1)
+}
+
+// Deposit128 specified field to Uint128 variable by staring position and length
+// LSB/MSB are 0/127 and return original value if error occurs
+func Deposit128(value Uint128, start uint, length uint, field Uint128) (Uint128, error) {
+    if start > 127 || length > 128 - start {
+        return value, fmt.Errorf("invalid start(%v) or length(%v)", start, length)
+    }
+
+    return deposit128(value, start, length, field)
+}
+
+// SetField128 specified field to Uint128 variable between high and low bit
+// LSB/MSB are 0/127 and return original value if error occurs
+func SetField128(value Uint128, high uint, low uint, field Uint128) (Uint128, error) {
+    if high > 127 || low > 127 || high < low {
+        return value, fmt.Errorf("invalid high(%v) or low(%v)", high, low)
+    }
+
+    return deposit128(value, low, high - low + 1, field)
+}
+
+// CountOne128 return number of 1 in Uint128 variable
+func CountOne128(value Uint128) (uint) {
+    return CountOne64(value.Hi) + CountOne64(value.Lo)
+}
+
+// CountZero128 return number of 0 in Uint128 variable
+func CountZero128(value Uint128) (uint) {
+    return 128 - CountOne128(value)
+}
+
+// CountTrailZero128 return number of trailing 0 in a 128-bit value
+func CountTrailZero128(value Uint128) (uint) {
+    if value.Lo == 0 {
+        return 64 + CountTrailZero64(value.Hi)
+    }
+    return CountTrailZero64(value.Lo)
+}
+
+// CountTrailOne128 return number of trailing 1 in a 128-bit value
+func CountTrailOne128(value Uint128) (uint) {
+    return CountTrailZero128(value.Not())
+}
+
+// CountLeadZero128 return number of leading 0 in a 128-bit value
+func CountLeadZero128(value Uint128) (uint) {
+    if value.Hi == 0 {
+        return 64 + CountLeadZero64(value.Lo)
+    }
+    return CountLeadZero64(value.Hi)
+}
+
+// CountLeadOne128 return number of leading 1 in a 128-bit value
+func CountLeadOne128(value Uint128) (uint) {
+    return CountLeadZero128(value.Not())
+}
+
+// SetBit128 set the specified bit to 1 for 128-bit value and return the new value
+func SetBit128(value Uint128, pos uint) (Uint128, error) {
+    if pos >= 128 {
+        return value, fmt.Errorf("invalid position(%v)", pos)
+    }
+
+    return value.Or(Uint128From64(1).Lsh(pos)), nil
+}
+
+// ToggleBit128 toggle the specified bit for 128-bit value and return the new value
+func ToggleBit128(value Uint128, pos uint) (Uint128, error) {
+    if pos >= 128 {
+        return value, fmt.Errorf("invalid position(%v)", pos)
+    }
+
+    return value.Xor(Uint128From64(1).Lsh(pos)), nil
+}
+
+// ClearBit128 set the specified bit to 0 for 128-bit value and return the new value
+func ClearBit128(value Uint128, pos uint) (Uint128, error) {
+    if pos >= 128 {
+        return value, fmt.Errorf("invalid position(%v)", pos)
+    }
+
+    return value.AndNot(Uint128From64(1).Lsh(pos)), nil
+}
+
+// TestBit128 return whether the specified bit is 1 for 128-bit value
+func TestBit128(value Uint128, pos uint) (bool, error) {
+    if pos >= 128 {
+        return false, fmt.Errorf("invalid position(%v)", pos)
+    }
+
+    return !value.And(Uint128From64(1).Lsh(pos)).IsZero(), nil
+}
+
+// Reverse128 reverse the bit order for 128-bit variable
+func Reverse128(value Uint128) (Uint128) {
+    return Uint128{Reverse64(value.Lo), Reverse64(value.Hi)}
+}
+
+// RotateRight128 rotate an 128-bit value right
+func RotateRight128(value Uint128, shift uint) (Uint128) {
+    shift = shift & 0x7F
+
+    return value.Rsh(shift).Or(value.Lsh(128 - shift))
+}
+
+// RotateLeft128 rotate an 128-bit value left
+func RotateLeft128(value Uint128, shift uint) (Uint128) {
+    shift = shift & 0x7F
+
+    return value.Lsh(shift).Or(value.Rsh(128 - shift))
+}
@@ -0,0 +1,338 @@
+package bitops
+
+import "testing"
+
+func TestUint128Arith(t *testing.T) {
+    var a, b, ret, expect Uint128
+
+    a = NewUint128(0, 0xFFFFFFFFFFFFFFFF)
+    b = Uint128From64(1)
+    ret = a.Add(b)
+    if expect = NewUint128(1, 0); ret != expect {
+        t.Fail()
+        t.Logf("add carry expect %v but get %v", expect, ret)
+    }
+
+    ret = expect.Sub(b)
+    if ret != a {
+        t.Fail()
+        t.Logf("sub borrow expect %v but get %v", a, ret)
+    }
+
+    ret = Uint128{}.Sub(b)
+    if expect = NewUint128(^uint64(0), ^uint64(0)); ret != expect {
+        t.Fail()
+        t.Logf("sub wrap expect %v but get %v", expect, ret)
+    }
+
+    ret = a.Mul(a)
+    if expect = NewUint128(0xFFFFFFFFFFFFFFFE, 0x1); ret != expect {
+        t.Fail()
+        t.Logf("mul expect %v but get %v", expect, ret)
+    }
+
+    a = NewUint128(0x0123456789ABCDEF, 0xFEDCBA9876543210)
+    b = NewUint128(0x1, 0x0)
+    quo, rem, err := a.QuoRem(b)
+    if err != nil || quo != Uint128From64(0x0123456789ABCDEF) || rem != Uint128From64(0xFEDCBA9876543210) {
+        t.Fail()
+        t.Logf("quorem get %v %v", quo, rem)
+    }
+
+    b = Uint128From64(10)
+    quo, rem, err = a.QuoRem(b)
+    if err != nil || quo.Mul(b).Add(rem) != a || rem.Cmp(b) >= 0 {
+        t.Fail()
+        t.Logf("quorem by 64-bit get %v %v", quo, rem)
+    }
+
+    b = NewUint128(0x00000000FFFFFFFF, 0x1234)
+    quo, rem, err = a.QuoRem(b)
+    if err != nil || quo.Mul(b).Add(rem) != a || rem.Cmp(b) >= 0 {
+        t.Fail()
+        t.Logf("quorem by 128-bit get %v %v", quo, rem)
+    }
+
+    _, _, err = a.QuoRem(Uint128{})
+    if err == nil {
+        t.Fail()
+        t.Log("division by zero")
+    }
+}
+
+func TestUint128Shift(t *testing.T) {
+    a := NewUint128(0x8000000000000001, 0x8000000000000001)
+
+    if ret, expect := a.Lsh(1), NewUint128(0x0000000000000003, 0x0000000000000002); ret != expect {
+        t.Fail()
+        t.Logf("lsh expect %v but get %v", expect, ret)
+    }
+    if ret, expect := a.Rsh(1), NewUint128(0x4000000000000000, 0xC000000000000000); ret != expect {
+        t.Fail()
+        t.Logf("rsh expect %v but get %v", expect, ret)
+    }
+    if ret, expect := a.Lsh(64), NewUint128(0x8000000000000001, 0); ret != expect {
+        t.Fail()
+        t.Logf("lsh 64 expect %v but get %v", expect, ret)
+    }
+    if ret, expect := a.Rsh(127), Uint128From64(1); ret != expect {
+        t.Fail()
+        t.Logf("rsh 127 expect %v but get %v", expect, ret)
+    }
+    if ret := a.Lsh(128); !ret.IsZero() {
+        t.Fail()
+        t.Logf("lsh 128 expect 0 but get %v", ret)
+    }
+}
+
+func TestUint128Cmp(t *testing.T) {
+    a := NewUint128(1, 0)
+    b := NewUint128(0, ^uint64(0))
+
+    if a.Cmp(b) != 1 || b.Cmp(a) != -1 || a.Cmp(a) != 0 || !a.Equal(a) {
+        t.Fail()
+        t.Log("compare high and low half")
+    }
+}
+
+func TestUint128Text(t *testing.T) {
+    a := NewUint128(0x0123456789ABCDEF, 0x0000000000000010)
+
+    if ret, expect := a.String(), "0x123456789abcdef0000000000000010"; ret != expect {
+        t.Fail()
+        t.Logf("expect %s but get %s", expect, ret)
+    }
+
+    max := NewUint128(^uint64(0), ^uint64(0))
+    if ret, expect := max.Text(10), "340282366920938463463374607431768211455"; ret != expect {
+        t.Fail()
+        t.Logf("expect %s but get %s", expect, ret)
+    }
+
+    if ret, expect := NewUint128(1, 5).Text(2), "1" + "0000000000000000000000000000000000000000000000000000000000000101"; ret != expect {
+        t.Fail()
+        t.Logf("expect %s but get %s", expect, ret)
+    }
+}
+
+func TestParseUint128(t *testing.T) {
+    max := NewUint128(^uint64(0), ^uint64(0))
+    vectors := []struct {
+        s      string
+        base   int
+        expect Uint128
+    }{
+        {"0x123456789abcdef0000000000000010", 0, NewUint128(0x0123456789ABCDEF, 0x10)},
+        {"0b1_0000_0001", 0, Uint128From64(0x101)},
+        {"340282366920938463463374607431768211455", 10, max},
+        {"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF", 16, max},
+        {"42", 0, Uint128From64(42)},
+    }
+
+    for _, v := range vectors {
+        ret, err := ParseUint128(v.s, v.base)
+        if err != nil || ret != v.expect {
+            t.Fail()
+            t.Logf("%s expect %v but get %v (%v)", v.s, v.expect, ret, err)
+        }
+        if back, _ := ParseUint128(ret.Text(10), 10); back != ret {
+            t.Fail()
+            t.Logf("%s does not round trip", v.s)
+        }
+    }
+
+    //check error
+    for _, s := range []string{"", "0x", "0b102", "1FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF", "340282366920938463463374607431768211456", "12z"} {
+        if _, err := ParseUint128(s, 0); err == nil {
+            t.Fail()
+            t.Logf("%q should fail", s)
+        }
+    }
+    if _, err := ParseUint128("1FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF", 16); err == nil {
+        t.Fail()
+        t.Log("overflow in base 16")
+    }
+    if _, err := ParseUint128("10", 8); err == nil {
+        t.Fail()
+        t.Log("invalid base")
+    }
+}
+
+func TestExtract128(t *testing.T) {
+    value := NewUint128(0xF0F0F0F0F0F0F0F0, 0xF0F0F0F0F0F0F0F0)
+
+    //check error
+    if _, err := Extract128(value, 128, 0); err == nil {
+        t.Fail()
+        t.Log("invalid start")
+    }
+    if _, err := Extract128(value, 127, 2); err == nil {
+        t.Fail()
+        t.Log("invalid length from valid start")
+    }
+
+    //check pass case
+    if field, err := Extract128(value, 127, 1); err != nil || field != Uint128From64(1) {
+        t.Fail()
+        t.Log("MSB")
+    }
+    if field, err := Extract128(value, 60, 8); err != nil || field != Uint128From64(0x0F) {
+        t.Fail()
+        t.Logf("across halves get %v", field)
+    }
+    if field, err := Extract128(value, 0, 128); err != nil || field != value {
+        t.Fail()
+        t.Logf("full width get %v", field)
+    }
+}
+
+func TestGetField128(t *testing.T) {
+    value := NewUint128(0x0123456789ABCDEF, 0xFEDCBA9876543210)
+
+    if _, err := GetField128(value, 10, 20); err == nil {
+        t.Fail()
+        t.Log("high < low")
+    }
+    if field, err := GetField128(value, 71, 56); err != nil || field != Uint128From64(0xEFFE) {
+        t.Fail()
+        t.Logf("across halves get %v", field)
+    }
+}
+
+func TestDeposit128(t *testing.T) {
+    value := NewUint128(0, 0)
+
+    if _, err := Deposit128(value, 120, 9, Uint128From64(1)); err == nil {
+        t.Fail()
+        t.Log("invalid length from valid start")
+    }
+    ret, err := Deposit128(value, 60, 8, Uint128From64(0x1FF))
+    if expect := NewUint128(0xF, 0xF000000000000000); err != nil || ret != expect {
+        t.Fail()
+        t.Logf("expect %v but get %v", expect, ret)
+    }
+}
+
+func TestSetField128(t *testing.T) {
+    value := NewUint128(^uint64(0), ^uint64(0))
+
+    if _, err := SetField128(value, 128, 0, Uint128{}); err == nil {
+        t.Fail()
+        t.Log("invalid high")
+    }
+    ret, err := SetField128(value, 127, 64, Uint128{})
+    if expect := NewUint128(0, ^uint64(0)); err != nil || ret != expect {
+        t.Fail()
+        t.Logf("expect %v but get %v", expect, ret)
+    }
+}
+
+func TestCountOne128(t *testing.T) {
+    value := NewUint128(0xF0F0F0F0F0F0F0F0, 0x1)
+    if count := CountOne128(value); count != 33 {
+        t.Fail()
+        t.Logf("expect 33 but get %d", count)
+    }
+    if count := CountZero128(value); count != 95 {
+        t.Fail()
+        t.Logf("expect 95 but get %d", count)
+    }
+}
+
+func TestCountTrailZero128(t *testing.T) {
+    var i uint
+    for i = 0; i < 128; i++ {
+        value := Uint128From64(1).Lsh(i)
+        if count := CountTrailZero128(value); count != i {
+            t.Fail()
+            t.Logf("expect %d for %v but get %d", i, value, count)
+        }
+        if count := CountTrailOne128(value.Sub(Uint128From64(1))); count != i {
+            t.Fail()
+            t.Logf("expect %d trailing one for %v but get %d", i, value, count)
+        }
+    }
+    if count := CountTrailZero128(Uint128{}); count != 128 {
+        t.Fail()
+        t.Logf("expect 128 but get %d", count)
+    }
+}
+
+func TestCountLeadZero128(t *testing.T) {
+    var i uint
+    for i = 0; i < 128; i++ {
+        value := Uint128From64(1).Lsh(127 - i)
+        if count := CountLeadZero128(value); count != i {
+            t.Fail()
+            t.Logf("expect %d for %v but get %d", i, value, count)
+        }
+        if count := CountLeadOne128(value.Sub(Uint128From64(1)).Not()); count != i + 1 {
+            t.Fail()
+            t.Logf("expect %d leading one for %v but get %d", i + 1, value, count)
+        }
+    }
+}
+
+func TestSetBit128(t *testing.T) {
+    var value Uint128
+
+    if _, err := SetBit128(value, 128); err == nil {
+        t.Fail()
+        t.Log("128th bit error")
+    }
+
+    value, err := SetBit128(value, 100)
+    if err != nil || value != NewUint128(1 << 36, 0) {
+        t.Fail()
+        t.Logf("set bit 100 get %v", value)
+    }
+    if set, err := TestBit128(value, 100); err != nil || !set {
+        t.Fail()
+        t.Log("test bit 100")
+    }
+    value, err = ToggleBit128(value, 0)
+    if err != nil || value != NewUint128(1 << 36, 1) {
+        t.Fail()
+        t.Logf("toggle bit 0 get %v", value)
+    }
+    value, err = ClearBit128(value, 100)
+    if err != nil || value != Uint128From64(1) {
+        t.Fail()
+        t.Logf("clear bit 100 get %v", value)
+    }
+    if _, err := TestBit128(value, 128); err == nil {
+        t.Fail()
+        t.Log("test 128th bit error")
+    }
+}
+
+func TestReverse128(t *testing.T) {
+    value := NewUint128(0x0123456789ABCDEF, 0x1)
+    expect := NewUint128(0x8000000000000000, 0xF7B3D591E6A2C480)
+
+    if ret := Reverse128(value); ret != expect {
+        t.Fail()
+        t.Logf("%v expect %v but get %v", value, expect, ret)
+    }
+}
+
+func TestRotate128(t *testing.T) {
+    value := NewUint128(0x0123456789ABCDEF, 0xFEDCBA9876543210)
+
+    if ret, expect := RotateRight128(value, 64), NewUint128(0xFEDCBA9876543210, 0x0123456789ABCDEF); ret != expect {
+        t.Fail()
+        t.Logf("expect %v but get %v", expect, ret)
+    }
+    if ret, expect := RotateLeft128(value, 4), NewUint128(0x123456789ABCDEFF, 0xEDCBA98765432100); ret != expect {
+        t.Fail()
+        t.Logf("expect %v but get %v", expect, ret)
+    }
+    if ret := RotateLeft128(RotateRight128(value, 77), 77); ret != value {
+        t.Fail()
+        t.Logf("expect %v but get %v", value, ret)
+    }
+    if ret := RotateLeft128(value, 128); ret != value {
+        t.Fail()
+        t.Logf("expect %v but get %v", value, ret)
+    }
+}
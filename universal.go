@@ -0,0 +1,325 @@
+package bitops
+
+import "fmt"
+
+// Universal integer codes on BitWriter/BitReader. Unary n is n 0 bits followed by
+// a 1 bit, so the prefixes of Elias gamma and exp-Golomb are unary codes and the
+// readers find them with CountLeadZero64. Readers consume nothing if error occurs
+
+// consume the 0 bits up to the next 1 and the 1 itself, return the number of 0
+func (r *BitReader) readZeroRun() (uint64, error) {
+    var zeros uint64
+    for {
+        avail := min(r.Remaining(), 64)
+        if avail == 0 {
+            return 0, ErrTruncated
+        }
+
+        bits, _ := r.PeekBits(uint(avail))
+        n := CountLeadZero64(bits << (64 - avail))
+        if uint64(n) < avail {
+            r.pos += uint64(n) + 1
+            return zeros + uint64(n), nil
+        }
+        zeros += avail
+        r.pos += avail
+    }
+}
+
+// write n 0 bits followed by a 1 bit
+func (w *BitWriter) writeZeroRun(n uint64) {
+    for ; n >= 64; n -= 64 {
+        w.WriteBits(0, 64)
+    }
+    w.WriteBits(1, uint(n) + 1)
+}
+
+// WriteUnary write n as n 0 bits followed by a 1 bit
+func (w *BitWriter) WriteUnary(n uint64) {
+    w.writeZeroRun(n)
+}
+
+// ReadUnary read a unary code
+func (r *BitReader) ReadUnary() (uint64, error) {
+    pos := r.pos
+    n, err := r.readZeroRun()
+    r.restore(pos, err)
+    return n, err
+}
+
+// WriteEliasGamma write n > 0 as floor(log2 n) 0 bits followed by n in binary
+func (w *BitWriter) WriteEliasGamma(n uint64) (error) {
+    if n == 0 {
+        return fmt.Errorf("invalid value(%v)", n)
+    }
+
+    length := 64 - CountLeadZero64(n)
+    w.WriteBits(0, length - 1)
+    w.WriteBits(n, length)
+    return nil
+}
+
+// real implementation for ReadEliasGamma, the position is restored by the caller
+func (r *BitReader) readEliasGamma() (uint64, error) {
+    zeros, err := r.readZeroRun()
+    if err != nil {
+        return 0, err
+    }
+    if zeros > 63 {
+        return 0, ErrOverflow
+    }
+
+    low, err := r.ReadBits(uint(zeros))
+    return (uint64(1) << zeros) | low, err
+}
+
+// ReadEliasGamma read an Elias gamma code, it return ErrOverflow if the value does
+// not fit in 64 bits
+func (r *BitReader) ReadEliasGamma() (uint64, error) {
+    pos := r.pos
+    n, err := r.readEliasGamma()
+    r.restore(pos, err)
+    return n, err
+}
+
+// WriteEliasDelta write n > 0 as the Elias gamma code of its bit length followed by
+// n in binary without the leading 1
+func (w *BitWriter) WriteEliasDelta(n uint64) (error) {
+    if n == 0 {
+        return fmt.Errorf("invalid value(%v)", n)
+    }
+
+    length := 64 - CountLeadZero64(n)
+    w.WriteEliasGamma(uint64(length))
+    w.WriteBits(n, length - 1)
+    return nil
+}
+
+// ReadEliasDelta read an Elias delta code, it return ErrOverflow if the value does
+// not fit in 64 bits
+func (r *BitReader) ReadEliasDelta() (uint64, error) {
+    pos := r.pos
+    length, err := r.readEliasGamma()
+    if err == nil && length > 64 {
+        err = ErrOverflow
+    }
+
+    var low uint64
+    if err == nil {
+        low, err = r.ReadBits(uint(length - 1))
+    }
+    r.restore(pos, err)
+    if err != nil {
+        return 0, err
+    }
+    return (uint64(1) << (length - 1)) | low, nil
+}
+
+// WriteEliasOmega write n > 0 as the recursive Elias omega code, groups of binary
+// lengths ending with a 0 bit
+func (w *BitWriter) WriteEliasOmega(n uint64) (error) {
+    if n == 0 {
+        return fmt.Errorf("invalid value(%v)", n)
+    }
+
+    // at most 64, 6, 3 and 2 bits long groups for a 64-bit value
+    var groups [8]uint64
+    count := 0
+    for ; n > 1; n = uint64(64 - CountLeadZero64(n) - 1) {
+        groups[count] = n
+        count++
+    }
+    for i := count - 1; i >= 0; i-- {
+        w.WriteBits(groups[i], 64 - CountLeadZero64(groups[i]))
+    }
+    w.WriteBits(0, 1)
+    return nil
+}
+
+// ReadEliasOmega read an Elias omega code, it return ErrOverflow if the value does
+// not fit in 64 bits
+func (r *BitReader) ReadEliasOmega() (uint64, error) {
+    pos := r.pos
+    n := uint64(1)
+    for {
+        bit, err := r.ReadBit()
+        if err == nil && bit && n > 63 {
+            err = ErrOverflow
+        }
+        if err != nil {
+            r.pos = pos
+            return 0, err
+        }
+        if !bit {
+            return n, nil
+        }
+
+        low, err := r.ReadBits(uint(n))
+        if err != nil {
+            r.pos = pos
+            return 0, err
+        }
+        n = (uint64(1) << n) | low
+    }
+}
+
+// bit length and cutoff of the truncated binary code for remainders below m, the
+// first cutoff remainders use one bit less
+func truncatedBinary(m uint64) (uint, uint64) {
+    bits := 64 - CountLeadZero64(m - 1)
+    return bits, (uint64(1) << bits) - m
+}
+
+// WriteGolomb write n with Golomb parameter m > 0, the quotient n / m in unary
+// followed by the remainder in truncated binary
+func (w *BitWriter) WriteGolomb(n uint64, m uint64) (error) {
+    if m == 0 {
+        return fmt.Errorf("invalid parameter(%v)", m)
+    }
+
+    w.writeZeroRun(n / m)
+    bits, cutoff := truncatedBinary(m)
+    if rem := n % m; rem < cutoff {
+        w.WriteBits(rem, bits - 1)
+    } else {
+        w.WriteBits(rem + cutoff, bits)
+    }
+    return nil
+}
+
+// ReadGolomb read a Golomb code with parameter m > 0, it return ErrOverflow if the
+// value does not fit in 64 bits
+func (r *BitReader) ReadGolomb(m uint64) (uint64, error) {
+    if m == 0 {
+        return 0, fmt.Errorf("invalid parameter(%v)", m)
+    }
+
+    pos := r.pos
+    quo, err := r.readZeroRun()
+    if err == nil && quo > ^uint64(0) / m {
+        err = ErrOverflow
+    }
+
+    var rem uint64
+    if bits, cutoff := truncatedBinary(m); err == nil && bits > 0 {
+        if rem, err = r.ReadBits(bits - 1); err == nil && rem >= cutoff {
+            var bit uint64
+            bit, err = r.ReadBits(1)
+            rem = ((rem << 1) | bit) - cutoff
+        }
+    }
+    if err == nil && quo * m > ^uint64(0) - rem {
+        err = ErrOverflow
+    }
+    r.restore(pos, err)
+    if err != nil {
+        return 0, err
+    }
+    return quo * m + rem, nil
+}
+
+// WriteRice write n with Rice parameter k, the Golomb code for m = 2^k with the
+// quotient n >> k in unary followed by the k low bits
+func (w *BitWriter) WriteRice(n uint64, k uint) (error) {
+    if k > 63 {
+        return fmt.Errorf("invalid parameter(%v)", k)
+    }
+
+    w.writeZeroRun(n >> k)
+    w.WriteBits(n, k)
+    return nil
+}
+
+// ReadRice read a Rice code with parameter k, it return ErrOverflow if the value
+// does not fit in 64 bits
+func (r *BitReader) ReadRice(k uint) (uint64, error) {
+    if k > 63 {
+        return 0, fmt.Errorf("invalid parameter(%v)", k)
+    }
+
+    pos := r.pos
+    quo, err := r.readZeroRun()
+    if err == nil && quo > ^uint64(0) >> k {
+        err = ErrOverflow
+    }
+
+    var low uint64
+    if err == nil {
+        low, err = r.ReadBits(k)
+    }
+    r.restore(pos, err)
+    if err != nil {
+        return 0, err
+    }
+    return (quo << k) | low, nil
+}
+
+// WriteExpGolomb write n with the exp-Golomb code of order k, the Elias gamma code
+// of n + 2^k without its first k 0 bits. Order 0 is ue(v) of H.264. It return
+// error if n + 2^k does not fit in 64 bits
+func (w *BitWriter) WriteExpGolomb(n uint64, k uint) (error) {
+    if k > 63 || n > ^uint64(0) - (uint64(1) << k) {
+        return fmt.Errorf("invalid value(%v) or order(%v)", n, k)
+    }
+
+    n += uint64(1) << k
+    length := 64 - CountLeadZero64(n)
+    w.WriteBits(0, length - 1 - k)
+    w.WriteBits(n, length)
+    return nil
+}
+
+// real implementation for ReadExpGolomb, the position is restored by the caller
+func (r *BitReader) readExpGolomb(k uint) (uint64, error) {
+    zeros, err := r.readZeroRun()
+    if err != nil {
+        return 0, err
+    }
+    if zeros + uint64(k) > 63 {
+        return 0, ErrOverflow
+    }
+
+    length := uint(zeros) + k
+    low, err := r.ReadBits(length)
+    return ((uint64(1) << length) | low) - (uint64(1) << k), err
+}
+
+// ReadExpGolomb read an exp-Golomb code of order k, it return ErrOverflow if the
+// value does not fit in 64 bits
+func (r *BitReader) ReadExpGolomb(k uint) (uint64, error) {
+    if k > 63 {
+        return 0, fmt.Errorf("invalid order(%v)", k)
+    }
+
+    pos := r.pos
+    n, err := r.readExpGolomb(k)
+    r.restore(pos, err)
+    return n, err
+}
+
+// WriteSignedExpGolomb write v with the exp-Golomb code of order k after mapping
+// 1, -1, 2, -2 to 1, 2, 3, 4 as se(v) of H.264. It return error for math.MinInt64
+func (w *BitWriter) WriteSignedExpGolomb(v int64, k uint) (error) {
+    if v == -1 << 63 {
+        return fmt.Errorf("invalid value(%v)", v)
+    }
+
+    n := uint64(-v) << 1
+    if v > 0 {
+        n = (uint64(v) << 1) - 1
+    }
+    return w.WriteExpGolomb(n, k)
+}
+
+// ReadSignedExpGolomb read a signed exp-Golomb code of order k
+func (r *BitReader) ReadSignedExpGolomb(k uint) (int64, error) {
+    n, err := r.ReadExpGolomb(k)
+    if err != nil {
+        return 0, err
+    }
+
+    if n & 1 == 1 {
+        return int64(n >> 1) + 1, nil
+    }
+    return -int64(n >> 1), nil
+}
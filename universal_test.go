@@ -0,0 +1,295 @@
+package bitops
+
+import (
+    "errors"
+    "fmt"
+    "math"
+    "testing"
+)
+
+// write value with code and return the bits as a string of 0 and 1
+func codeString(write func(w *BitWriter) (error)) (string, error) {
+    w := NewBitWriter()
+    if err := write(w); err != nil {
+        return "", err
+    }
+
+    s := ""
+    r := NewBitReader(w.Bytes())
+    for i := uint64(0); i < w.Len(); i++ {
+        bit, _ := r.ReadBit()
+        if bit {
+            s += "1"
+        } else {
+            s += "0"
+        }
+    }
+    return s, nil
+}
+
+// build the bytes of a string of 0 and 1
+func codeBytes(s string) ([]byte) {
+    w := NewBitWriter()
+    for _, c := range s {
+        w.WriteBit(c == '1')
+    }
+    return w.Bytes()
+}
+
+func TestUnary(t *testing.T) {
+    s, _ := codeString(func(w *BitWriter) (error) {
+        w.WriteUnary(3)
+        w.WriteUnary(0)
+        return nil
+    })
+    if s != "00011" {
+        t.Fail()
+        t.Logf("get %s", s)
+    }
+
+    w := NewBitWriter()
+    w.WriteUnary(200)
+    w.WriteUnary(64)
+    r := NewBitReader(w.Bytes())
+    if n, err := r.ReadUnary(); err != nil || n != 200 {
+        t.Fail()
+        t.Logf("expect 200 but get %d", n)
+    }
+    if n, err := r.ReadUnary(); err != nil || n != 64 {
+        t.Fail()
+        t.Logf("expect 64 but get %d", n)
+    }
+    if _, err := r.ReadUnary(); err != ErrTruncated {
+        t.Fail()
+        t.Log("only padding is left")
+    }
+}
+
+func TestEliasCodes(t *testing.T) {
+    vectors := []struct {
+        n     uint64
+        gamma string
+        delta string
+        omega string
+    }{
+        {1, "1", "1", "0"},
+        {2, "010", "0100", "100"},
+        {4, "00100", "01100", "101000"},
+        {10, "0001010", "00100010", "1110100"},
+        {17, "000010001", "001010001", "10100100010"},
+    }
+
+    for _, v := range vectors {
+        if s, err := codeString(func(w *BitWriter) (error) { return w.WriteEliasGamma(v.n) }); err != nil || s != v.gamma {
+            t.Fail()
+            t.Logf("gamma %d expect %s but get %s", v.n, v.gamma, s)
+        }
+        if s, err := codeString(func(w *BitWriter) (error) { return w.WriteEliasDelta(v.n) }); err != nil || s != v.delta {
+            t.Fail()
+            t.Logf("delta %d expect %s but get %s", v.n, v.delta, s)
+        }
+        if s, err := codeString(func(w *BitWriter) (error) { return w.WriteEliasOmega(v.n) }); err != nil || s != v.omega {
+            t.Fail()
+            t.Logf("omega %d expect %s but get %s", v.n, v.omega, s)
+        }
+    }
+
+    w := NewBitWriter()
+    if w.WriteEliasGamma(0) == nil || w.WriteEliasDelta(0) == nil || w.WriteEliasOmega(0) == nil || w.Len() != 0 {
+        t.Fail()
+        t.Log("0 has no Elias code")
+    }
+
+    values := []uint64{1, 2, 3, 255, 256, 1 << 32, math.MaxUint64 >> 1, math.MaxUint64}
+    for _, n := range values {
+        w.WriteEliasGamma(n)
+        w.WriteEliasDelta(n)
+        w.WriteEliasOmega(n)
+    }
+    r := NewBitReader(w.Bytes())
+    for _, n := range values {
+        g, err1 := r.ReadEliasGamma()
+        d, err2 := r.ReadEliasDelta()
+        o, err3 := r.ReadEliasOmega()
+        if err := errors.Join(err1, err2, err3); err != nil || g != n || d != n || o != n {
+            t.Fail()
+            t.Logf("%x get %x %x %x %v", n, g, d, o, err)
+        }
+    }
+}
+
+func TestEliasErrors(t *testing.T) {
+    //65-bit gamma value
+    r := NewBitReader(codeBytes(fmt.Sprintf("%064b1", 0)))
+    if _, err := r.ReadEliasGamma(); err != ErrOverflow || r.Pos() != 0 {
+        t.Fail()
+        t.Logf("gamma overflow get %v", err)
+    }
+
+    //delta length 65
+    w := NewBitWriter()
+    w.WriteEliasGamma(65)
+    w.WriteBits(0, 64)
+    r = NewBitReader(w.Bytes())
+    if _, err := r.ReadEliasDelta(); err != ErrOverflow || r.Pos() != 0 {
+        t.Fail()
+        t.Logf("delta overflow get %v", err)
+    }
+
+    //omega groups 1 -> 3 -> 15 -> 65535 -> a 65536-bit group
+    r = NewBitReader(codeBytes("11" + "1111" + "1111111111111111" + "1"))
+    if _, err := r.ReadEliasOmega(); err != ErrOverflow || r.Pos() != 0 {
+        t.Fail()
+        t.Logf("omega overflow get %v", err)
+    }
+
+    r = NewBitReader(codeBytes("00001"))
+    if _, err := r.ReadEliasGamma(); err != ErrTruncated || r.Pos() != 0 {
+        t.Fail()
+        t.Logf("gamma truncated get %v", err)
+    }
+}
+
+// values with a short quotient for Golomb parameter m
+func golombValues(m uint64) ([]uint64) {
+    values := []uint64{0, 1, m - 1, m, 1000 % (m * 8)}
+    if m > 1 << 62 {
+        values = append(values, math.MaxUint64)
+    }
+    return values
+}
+
+func TestGolombRice(t *testing.T) {
+    vectors := []struct {
+        n      uint64
+        m      uint64
+        expect string
+    }{
+        {0, 3, "10"},
+        {1, 3, "110"},
+        {2, 3, "111"},
+        {7, 3, "00110"},
+        {9, 10, "11111"},
+        {10, 10, "01000"},
+        {5, 1, "000001"},
+        {6, 4, "0110"},
+    }
+
+    for _, v := range vectors {
+        s, err := codeString(func(w *BitWriter) (error) { return w.WriteGolomb(v.n, v.m) })
+        if err != nil || s != v.expect {
+            t.Fail()
+            t.Logf("Golomb %d with m %d expect %s but get %s", v.n, v.m, v.expect, s)
+        }
+        r := NewBitReader(codeBytes(v.expect))
+        if ret, err := r.ReadGolomb(v.m); err != nil || ret != v.n {
+            t.Fail()
+            t.Logf("read Golomb %s with m %d get %d %v", v.expect, v.m, ret, err)
+        }
+    }
+
+    if s, _ := codeString(func(w *BitWriter) (error) { return w.WriteRice(6, 2) }); s != "0110" {
+        t.Fail()
+        t.Logf("Rice 6 with k 2 get %s", s)
+    }
+
+    w := NewBitWriter()
+    if w.WriteGolomb(1, 0) == nil || w.WriteRice(1, 64) == nil {
+        t.Fail()
+        t.Log("invalid parameters")
+    }
+    for _, m := range []uint64{1, 5, 1 << 20, math.MaxUint64, 1 << 63 + 1} {
+        for _, n := range golombValues(m) {
+            w.WriteGolomb(n, m)
+        }
+    }
+    for k := uint(0); k < 64; k += 9 {
+        for _, n := range []uint64{0, 1, 1000, math.MaxUint64 >> (63 - k)} {
+            w.WriteRice(n, k)
+        }
+    }
+
+    r := NewBitReader(w.Bytes())
+    for _, m := range []uint64{1, 5, 1 << 20, math.MaxUint64, 1 << 63 + 1} {
+        for _, n := range golombValues(m) {
+            if ret, err := r.ReadGolomb(m); err != nil || ret != n {
+                t.Fatalf("Golomb %x with m %x get %x %v", n, m, ret, err)
+            }
+        }
+    }
+    for k := uint(0); k < 64; k += 9 {
+        for _, n := range []uint64{0, 1, 1000, math.MaxUint64 >> (63 - k)} {
+            if ret, err := r.ReadRice(k); err != nil || ret != n {
+                t.Fatalf("Rice %x with k %d get %x %v", n, k, ret, err)
+            }
+        }
+    }
+
+    //quotient 4 with m 2^62 overflow
+    r = NewBitReader(codeBytes("00001" + fmt.Sprintf("%062b", 0)))
+    if _, err := r.ReadGolomb(1 << 62); err != ErrOverflow || r.Pos() != 0 {
+        t.Fail()
+        t.Logf("Golomb overflow get %v", err)
+    }
+    r = NewBitReader(codeBytes("00001" + fmt.Sprintf("%062b", 0)))
+    if _, err := r.ReadRice(62); err != ErrOverflow {
+        t.Fail()
+        t.Logf("Rice overflow get %v", err)
+    }
+}
+
+func TestExpGolomb(t *testing.T) {
+    //ue(v) and se(v) tables of H.264 9.1
+    unsigned := []string{"1", "010", "011", "00100", "00101", "00110", "00111", "0001000"}
+    signed := []int64{0, 1, -1, 2, -2, 3, -3, 4}
+    for n, expect := range unsigned {
+        if s, err := codeString(func(w *BitWriter) (error) { return w.WriteExpGolomb(uint64(n), 0) }); err != nil || s != expect {
+            t.Fail()
+            t.Logf("ue %d expect %s but get %s", n, expect, s)
+        }
+        if s, err := codeString(func(w *BitWriter) (error) { return w.WriteSignedExpGolomb(signed[n], 0) }); err != nil || s != expect {
+            t.Fail()
+            t.Logf("se %d expect %s but get %s", signed[n], expect, s)
+        }
+        r := NewBitReader(codeBytes(expect))
+        if v, err := r.ReadSignedExpGolomb(0); err != nil || v != signed[n] {
+            t.Fail()
+            t.Logf("read se %s get %d", expect, v)
+        }
+    }
+
+    //order 2
+    if s, _ := codeString(func(w *BitWriter) (error) { return w.WriteExpGolomb(5, 2) }); s != "01001" {
+        t.Fail()
+        t.Logf("order 2 of 5 get %s", s)
+    }
+
+    w := NewBitWriter()
+    if w.WriteExpGolomb(math.MaxUint64, 0) == nil || w.WriteSignedExpGolomb(math.MinInt64, 0) == nil {
+        t.Fail()
+        t.Log("value out of range")
+    }
+    for k := uint(0); k < 64; k += 7 {
+        w.WriteExpGolomb(math.MaxUint64 - (1 << k), k)
+        w.WriteExpGolomb(3, k)
+        w.WriteSignedExpGolomb(math.MaxInt64 >> 1, k)
+        w.WriteSignedExpGolomb(-(math.MaxInt64 >> 1), k)
+    }
+
+    r := NewBitReader(w.Bytes())
+    for k := uint(0); k < 64; k += 7 {
+        a, err1 := r.ReadExpGolomb(k)
+        b, err2 := r.ReadExpGolomb(k)
+        c, err3 := r.ReadSignedExpGolomb(k)
+        d, err4 := r.ReadSignedExpGolomb(k)
+        if errors.Join(err1, err2, err3, err4) != nil || a != math.MaxUint64 - (1 << k) || b != 3 || c != math.MaxInt64 >> 1 || d != -(math.MaxInt64 >> 1) {
+            t.Fatalf("order %d get %x %d %d %d", k, a, b, c, d)
+        }
+    }
+
+    r = NewBitReader(codeBytes(fmt.Sprintf("%063b1%063b", 0, 0)))
+    if _, err := r.ReadExpGolomb(1); err != ErrOverflow || r.Pos() != 0 {
+        t.Fail()
+        t.Logf("overflow get %v", err)
+    }
+}
@@ -0,0 +1,348 @@
+package bitops
+
+import (
+    "errors"
+    "fmt"
+    "io"
+)
+
+var (
+    // ErrOverlong is returned when an encoding use more bytes than allowed
+    ErrOverlong = errors.New("overlong encoding")
+    // ErrOverflow is returned when a decoded value does not fit the requested width
+    ErrOverflow = errors.New("value overflow")
+    // ErrMalformed is returned when a byte can not appear at its position
+    ErrMalformed = errors.New("malformed encoding")
+)
+
+// VarintError report why a variable-length integer can not be decoded. Offset is
+// the index of the offending byte counted from the first byte of the encoding
+type VarintError struct {
+    Offset int
+    Err    error
+}
+
+func (e *VarintError) Error() (string) {
+    return fmt.Sprintf("varint byte %d: %v", e.Offset, e.Err)
+}
+
+// Unwrap return ErrTruncated, ErrOverlong, ErrOverflow or ErrMalformed
+func (e *VarintError) Unwrap() (error) {
+    return e.Err
+}
+
+// byte source over a slice so slices and BitReader share the decoders
+type sliceByteReader struct {
+    buf []byte
+    pos int
+}
+
+func (s *sliceByteReader) ReadByte() (byte, error) {
+    if s.pos >= len(s.buf) {
+        return 0, ErrTruncated
+    }
+
+    s.pos++
+    return s.buf[s.pos - 1], nil
+}
+
+// ZigZagEncode32 map signed to unsigned so small magnitudes get small codes,
+// 0, -1, 1, -2 become 0, 1, 2, 3
+func ZigZagEncode32(value int32) (uint32) {
+    return uint32(value << 1) ^ uint32(value >> 31)
+}
+
+// ZigZagEncode64 map signed to unsigned so small magnitudes get small codes,
+// 0, -1, 1, -2 become 0, 1, 2, 3
+func ZigZagEncode64(value int64) (uint64) {
+    return uint64(value << 1) ^ uint64(value >> 63)
+}
+
+// ZigZagDecode32 reverse ZigZagEncode32
+func ZigZagDecode32(value uint32) (int32) {
+    return int32(value >> 1) ^ -int32(value & 1)
+}
+
+// ZigZagDecode64 reverse ZigZagEncode64
+func ZigZagDecode64(value uint64) (int64) {
+    return int64(value >> 1) ^ -int64(value & 1)
+}
+
+// AppendULEB128 append the unsigned LEB128 encoding of value to dst, it is also
+// the protobuf varint
+func AppendULEB128(dst []byte, value uint64) ([]byte) {
+    for value >= 0x80 {
+        dst = append(dst, byte(value) | 0x80)
+        value >>= 7
+    }
+    return append(dst, byte(value))
+}
+
+// AppendSLEB128 append the signed LEB128 encoding of value to dst
+func AppendSLEB128(dst []byte, value int64) ([]byte) {
+    for {
+        b := byte(value) & 0x7F
+        value >>= 7
+        // stop once the remaining bits are the sign extension of bit 6
+        if (value == 0 && b & 0x40 == 0) || (value == -1 && b & 0x40 != 0) {
+            return append(dst, b)
+        }
+        dst = append(dst, b | 0x80)
+    }
+}
+
+// real implementation of the LEB128 decoders. An integer of bits width takes at
+// most ceil(bits/7) bytes and the unused bits of the last byte must be 0 for
+// unsigned values or copies of the sign bit for signed ones
+func readLEB128(r io.ByteReader, bits uint, signed bool) (uint64, int, error) {
+    if bits == 0 || bits > 64 {
+        return 0, 0, fmt.Errorf("invalid bits(%v)", bits)
+    }
+
+    last := int((bits + 6) / 7) - 1
+    var value uint64
+    var shift uint
+    for i := 0; ; i++ {
+        b, err := r.ReadByte()
+        if err != nil {
+            return 0, i, &VarintError{Offset: i, Err: ErrTruncated}
+        }
+
+        if i == last {
+            if b & 0x80 != 0 {
+                return 0, i + 1, &VarintError{Offset: i, Err: ErrOverlong}
+            }
+            // bits bits - 1 and above of this byte, as stored in it
+            rest := bits - 1 - shift
+            if signed {
+                if top := int8(b << 1) >> (rest + 1); top != 0 && top != -1 {
+                    return 0, i + 1, &VarintError{Offset: i, Err: ErrOverflow}
+                }
+            } else if b >> (rest + 1) != 0 {
+                return 0, i + 1, &VarintError{Offset: i, Err: ErrOverflow}
+            }
+        }
+
+        value |= uint64(b & 0x7F) << shift
+        shift += 7
+        if b & 0x80 == 0 {
+            if signed && b & 0x40 != 0 && shift < 64 {
+                value |= ^uint64(0) << shift
+            }
+            return value, i + 1, nil
+        }
+    }
+}
+
+// DecodeULEB128 decode an unsigned LEB128 integer of at most bits bits from src
+// and return the value and the number of bytes read. Use bits 32 for WebAssembly
+// u32 and 64 for protobuf varints
+func DecodeULEB128(src []byte, bits uint) (uint64, int, error) {
+    return readLEB128(&sliceByteReader{buf: src}, bits, false)
+}
+
+// DecodeSLEB128 decode a signed LEB128 integer of at most bits bits from src and
+// return the value and the number of bytes read
+func DecodeSLEB128(src []byte, bits uint) (int64, int, error) {
+    value, n, err := readLEB128(&sliceByteReader{buf: src}, bits, true)
+    return int64(value), n, err
+}
+
+// AppendUTF8Varint append value with the original UTF-8 scheme, the count of
+// leading 1 in the first byte give the length and each following byte carry 6
+// bits. It return error if value does not fit in 31 bits
+func AppendUTF8Varint(dst []byte, value uint64) ([]byte, error) {
+    if value < 0x80 {
+        return append(dst, byte(value)), nil
+    }
+    if value > 0x7FFFFFFF {
+        return dst, fmt.Errorf("invalid value(%v)", value)
+    }
+
+    // a length of n bytes hold 5n + 1 bits
+    length := (uint(64 - CountLeadZero64(value)) + 3) / 5
+    dst = append(dst, byte(uint16(0xFF00) >> length) | byte(value >> (6 * (length - 1))))
+    for i := int(length) - 2; i >= 0; i-- {
+        dst = append(dst, 0x80 | byte(value >> (6 * uint(i))) & 0x3F)
+    }
+    return dst, nil
+}
+
+// real implementation of the UTF-8 style decoders
+func readUTF8Varint(r io.ByteReader) (uint64, int, error) {
+    b, err := r.ReadByte()
+    if err != nil {
+        return 0, 0, &VarintError{Offset: 0, Err: ErrTruncated}
+    }
+
+    length := CountLeadOne32(uint32(b) << 24)
+    if length == 0 {
+        return uint64(b), 1, nil
+    }
+    if length == 1 || length > 6 {
+        return 0, 1, &VarintError{Offset: 0, Err: ErrMalformed}
+    }
+
+    value := uint64(b) & (0x7F >> length)
+    for i := 1; i < int(length); i++ {
+        if b, err = r.ReadByte(); err != nil {
+            return 0, i, &VarintError{Offset: i, Err: ErrTruncated}
+        }
+        if b & 0xC0 != 0x80 {
+            return 0, i + 1, &VarintError{Offset: i, Err: ErrMalformed}
+        }
+        value = (value << 6) | uint64(b & 0x3F)
+    }
+
+    // values which fit in one byte less are overlong, 1 byte hold 7 bits and
+    // n > 1 bytes hold 5n + 1 bits
+    lowest := uint64(0x80)
+    if length > 2 {
+        lowest = uint64(1) << (5 * (length - 1) + 1)
+    }
+    if value < lowest {
+        return 0, int(length), &VarintError{Offset: 0, Err: ErrOverlong}
+    }
+    return value, int(length), nil
+}
+
+// DecodeUTF8Varint decode a UTF-8 style integer from src and return the value and
+// the number of bytes read. Encodings longer than needed are ErrOverlong
+func DecodeUTF8Varint(src []byte) (uint64, int, error) {
+    return readUTF8Varint(&sliceByteReader{buf: src})
+}
+
+// QUICVarintLen return the shortest QUIC encoding length of value, 1, 2, 4 or 8,
+// and 0 if value does not fit in 62 bits
+func QUICVarintLen(value uint64) (int) {
+    switch {
+    case value < 1 << 6:
+        return 1
+    case value < 1 << 14:
+        return 2
+    case value < 1 << 30:
+        return 4
+    case value < 1 << 62:
+        return 8
+    }
+    return 0
+}
+
+// AppendQUICVarint append value in QUIC format, a 2-bit length prefix followed by
+// a big-endian integer of 6, 14, 30 or 62 bits. It return error if value does not
+// fit in 62 bits
+func AppendQUICVarint(dst []byte, value uint64) ([]byte, error) {
+    length := QUICVarintLen(value)
+    if length == 0 {
+        return dst, fmt.Errorf("invalid value(%v)", value)
+    }
+
+    prefix := uint64(CountTrailZero32(uint32(length))) << 62
+    value |= prefix >> (64 - 8 * uint(length))
+    for i := length - 1; i >= 0; i-- {
+        dst = append(dst, byte(value >> (8 * uint(i))))
+    }
+    return dst, nil
+}
+
+// real implementation of the QUIC decoders
+func readQUICVarint(r io.ByteReader) (uint64, int, error) {
+    b, err := r.ReadByte()
+    if err != nil {
+        return 0, 0, &VarintError{Offset: 0, Err: ErrTruncated}
+    }
+
+    length := 1 << (b >> 6)
+    value := uint64(b & 0x3F)
+    for i := 1; i < length; i++ {
+        if b, err = r.ReadByte(); err != nil {
+            return 0, i, &VarintError{Offset: i, Err: ErrTruncated}
+        }
+        value = (value << 8) | uint64(b)
+    }
+    return value, length, nil
+}
+
+// DecodeQUICVarint decode a QUIC integer from src and return the value and the
+// number of bytes read. QUIC allow longer than needed encodings, compare the
+// length with QUICVarintLen where the minimum is required
+func DecodeQUICVarint(src []byte) (uint64, int, error) {
+    return readQUICVarint(&sliceByteReader{buf: src})
+}
+
+// WriteULEB128 write the unsigned LEB128 encoding of value
+func (w *BitWriter) WriteULEB128(value uint64) {
+    var buf [10]byte
+    for _, b := range AppendULEB128(buf[:0], value) {
+        w.WriteByte(b)
+    }
+}
+
+// WriteSLEB128 write the signed LEB128 encoding of value
+func (w *BitWriter) WriteSLEB128(value int64) {
+    var buf [10]byte
+    for _, b := range AppendSLEB128(buf[:0], value) {
+        w.WriteByte(b)
+    }
+}
+
+// WriteUTF8Varint write value with the UTF-8 style encoding
+func (w *BitWriter) WriteUTF8Varint(value uint64) (error) {
+    var buf [6]byte
+    enc, err := AppendUTF8Varint(buf[:0], value)
+    for _, b := range enc {
+        w.WriteByte(b)
+    }
+    return err
+}
+
+// WriteQUICVarint write value with the QUIC encoding
+func (w *BitWriter) WriteQUICVarint(value uint64) (error) {
+    var buf [8]byte
+    enc, err := AppendQUICVarint(buf[:0], value)
+    for _, b := range enc {
+        w.WriteByte(b)
+    }
+    return err
+}
+
+// rewind the reader when a decoder fail so nothing is consumed
+func (r *BitReader) restore(pos uint64, err error) {
+    if err != nil {
+        r.pos = pos
+    }
+}
+
+// ReadULEB128 read an unsigned LEB128 integer of at most bits bits. Nothing is
+// consumed if error occurs
+func (r *BitReader) ReadULEB128(bits uint) (uint64, error) {
+    pos := r.pos
+    value, _, err := readLEB128(r, bits, false)
+    r.restore(pos, err)
+    return value, err
+}
+
+// ReadSLEB128 read a signed LEB128 integer of at most bits bits. Nothing is
+// consumed if error occurs
+func (r *BitReader) ReadSLEB128(bits uint) (int64, error) {
+    pos := r.pos
+    value, _, err := readLEB128(r, bits, true)
+    r.restore(pos, err)
+    return int64(value), err
+}
+
+// ReadUTF8Varint read a UTF-8 style integer. Nothing is consumed if error occurs
+func (r *BitReader) ReadUTF8Varint() (uint64, error) {
+    pos := r.pos
+    value, _, err := readUTF8Varint(r)
+    r.restore(pos, err)
+    return value, err
+}
+
+// ReadQUICVarint read a QUIC integer. Nothing is consumed if error occurs
+func (r *BitReader) ReadQUICVarint() (uint64, error) {
+    pos := r.pos
+    value, _, err := readQUICVarint(r)
+    r.restore(pos, err)
+    return value, err
+}
@@ -0,0 +1,268 @@
+package bitops
+
+import (
+    "bytes"
+    "errors"
+    "math"
+    "testing"
+)
+
+func TestZigZag(t *testing.T) {
+    vectors := []struct {
+        value  int64
+        expect uint64
+    }{
+        {0, 0}, {-1, 1}, {1, 2}, {-2, 3}, {math.MaxInt64, math.MaxUint64 - 1}, {math.MinInt64, math.MaxUint64},
+    }
+
+    for _, v := range vectors {
+        if ret := ZigZagEncode64(v.value); ret != v.expect || ZigZagDecode64(ret) != v.value {
+            t.Fail()
+            t.Logf("%d expect %d but get %d", v.value, v.expect, ret)
+        }
+    }
+    if ret := ZigZagEncode32(math.MinInt32); ret != math.MaxUint32 || ZigZagDecode32(ret) != math.MinInt32 {
+        t.Fail()
+        t.Logf("MinInt32 get %x", ret)
+    }
+}
+
+func TestULEB128(t *testing.T) {
+    vectors := []struct {
+        value  uint64
+        expect []byte
+    }{
+        {0, []byte{0x00}},
+        {127, []byte{0x7F}},
+        {128, []byte{0x80, 0x01}},
+        {624485, []byte{0xE5, 0x8E, 0x26}},
+        {math.MaxUint64, []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x01}},
+    }
+
+    for _, v := range vectors {
+        enc := AppendULEB128(nil, v.value)
+        if !bytes.Equal(enc, v.expect) {
+            t.Fail()
+            t.Logf("%d expect % x but get % x", v.value, v.expect, enc)
+        }
+        if ret, n, err := DecodeULEB128(enc, 64); err != nil || ret != v.value || n != len(enc) {
+            t.Fail()
+            t.Logf("decode % x get %d %d %v", enc, ret, n, err)
+        }
+    }
+
+    errVectors := []struct {
+        src    []byte
+        bits   uint
+        expect error
+        offset int
+    }{
+        {[]byte{0x80, 0x80}, 64, ErrTruncated, 2},
+        {[]byte{}, 32, ErrTruncated, 0},
+        {[]byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x00}, 32, ErrOverlong, 4},
+        {[]byte{0xFF, 0xFF, 0xFF, 0xFF, 0x1F}, 32, ErrOverflow, 4},
+        {[]byte{0x80, 0x02}, 8, ErrOverflow, 1},
+        {[]byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x03}, 64, ErrOverflow, 9},
+    }
+
+    for _, v := range errVectors {
+        _, _, err := DecodeULEB128(v.src, v.bits)
+        var verr *VarintError
+        if !errors.As(err, &verr) || !errors.Is(err, v.expect) || verr.Offset != v.offset {
+            t.Fail()
+            t.Logf("% x with %d bits expect %v at %d but get %v", v.src, v.bits, v.expect, v.offset, err)
+        }
+    }
+
+    //padded but within the limit is accepted, as in WebAssembly
+    if ret, n, err := DecodeULEB128([]byte{0x83, 0x80, 0x80, 0x80, 0x00}, 32); err != nil || ret != 3 || n != 5 {
+        t.Fail()
+        t.Logf("padded get %d %d %v", ret, n, err)
+    }
+    if ret, _, err := DecodeULEB128([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0x0F}, 32); err != nil || ret != math.MaxUint32 {
+        t.Fail()
+        t.Logf("max uint32 get %x %v", ret, err)
+    }
+    if _, _, err := DecodeULEB128([]byte{0}, 65); err == nil {
+        t.Fail()
+        t.Log("invalid bits")
+    }
+}
+
+func TestSLEB128(t *testing.T) {
+    vectors := []struct {
+        value  int64
+        expect []byte
+    }{
+        {0, []byte{0x00}},
+        {63, []byte{0x3F}},
+        {64, []byte{0xC0, 0x00}},
+        {-1, []byte{0x7F}},
+        {-64, []byte{0x40}},
+        {-65, []byte{0xBF, 0x7F}},
+        {-123456, []byte{0xC0, 0xBB, 0x78}},
+        {math.MinInt64, []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x7F}},
+        {math.MaxInt64, []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x00}},
+    }
+
+    for _, v := range vectors {
+        enc := AppendSLEB128(nil, v.value)
+        if !bytes.Equal(enc, v.expect) {
+            t.Fail()
+            t.Logf("%d expect % x but get % x", v.value, v.expect, enc)
+        }
+        if ret, n, err := DecodeSLEB128(enc, 64); err != nil || ret != v.value || n != len(enc) {
+            t.Fail()
+            t.Logf("decode % x get %d %d %v", enc, ret, n, err)
+        }
+    }
+
+    //int32 range, the last byte may only hold the sign extension
+    if ret, _, err := DecodeSLEB128([]byte{0x80, 0x80, 0x80, 0x80, 0x78}, 32); err != nil || ret != math.MinInt32 {
+        t.Fail()
+        t.Logf("MinInt32 get %d %v", ret, err)
+    }
+    if _, _, err := DecodeSLEB128([]byte{0x80, 0x80, 0x80, 0x80, 0x70}, 32); !errors.Is(err, ErrOverflow) {
+        t.Fail()
+        t.Logf("below MinInt32 get %v", err)
+    }
+    if _, _, err := DecodeSLEB128([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0x08}, 32); !errors.Is(err, ErrOverflow) {
+        t.Fail()
+        t.Logf("above MaxInt32 get %v", err)
+    }
+    if _, _, err := DecodeSLEB128([]byte{0xFF, 0x7F}, 7); !errors.Is(err, ErrOverlong) {
+        t.Fail()
+        t.Logf("2 bytes for 7 bits get %v", err)
+    }
+}
+
+func TestUTF8Varint(t *testing.T) {
+    vectors := []struct {
+        value  uint64
+        expect []byte
+    }{
+        {0x24, []byte{0x24}},
+        {0xA2, []byte{0xC2, 0xA2}},
+        {0x20AC, []byte{0xE2, 0x82, 0xAC}},
+        {0x10348, []byte{0xF0, 0x90, 0x8D, 0x88}},
+        {0x7FFFFFFF, []byte{0xFD, 0xBF, 0xBF, 0xBF, 0xBF, 0xBF}},
+    }
+
+    for _, v := range vectors {
+        enc, err := AppendUTF8Varint(nil, v.value)
+        if err != nil || !bytes.Equal(enc, v.expect) {
+            t.Fail()
+            t.Logf("%x expect % x but get % x", v.value, v.expect, enc)
+        }
+        if ret, n, err := DecodeUTF8Varint(enc); err != nil || ret != v.value || n != len(enc) {
+            t.Fail()
+            t.Logf("decode % x get %x %d %v", enc, ret, n, err)
+        }
+    }
+
+    if _, err := AppendUTF8Varint(nil, 0x80000000); err == nil {
+        t.Fail()
+        t.Log("32-bit value")
+    }
+
+    errVectors := []struct {
+        src    []byte
+        expect error
+        offset int
+    }{
+        {[]byte{0xC0, 0x80}, ErrOverlong, 0},
+        {[]byte{0xE0, 0x9F, 0xBF}, ErrOverlong, 0},
+        {[]byte{0x80}, ErrMalformed, 0},
+        {[]byte{0xFE}, ErrMalformed, 0},
+        {[]byte{0xE2, 0x82, 0x41}, ErrMalformed, 2},
+        {[]byte{0xE2, 0x82}, ErrTruncated, 2},
+    }
+
+    for _, v := range errVectors {
+        _, _, err := DecodeUTF8Varint(v.src)
+        var verr *VarintError
+        if !errors.As(err, &verr) || verr.Err != v.expect || verr.Offset != v.offset {
+            t.Fail()
+            t.Logf("% x expect %v at %d but get %v", v.src, v.expect, v.offset, err)
+        }
+    }
+}
+
+func TestQUICVarint(t *testing.T) {
+    //examples of RFC 9000 appendix A.1
+    vectors := []struct {
+        value  uint64
+        expect []byte
+    }{
+        {151288809941952652, []byte{0xC2, 0x19, 0x7C, 0x5E, 0xFF, 0x14, 0xE8, 0x8C}},
+        {494878333, []byte{0x9D, 0x7F, 0x3E, 0x7D}},
+        {15293, []byte{0x7B, 0xBD}},
+        {37, []byte{0x25}},
+    }
+
+    for _, v := range vectors {
+        enc, err := AppendQUICVarint(nil, v.value)
+        if err != nil || !bytes.Equal(enc, v.expect) || QUICVarintLen(v.value) != len(enc) {
+            t.Fail()
+            t.Logf("%d expect % x but get % x", v.value, v.expect, enc)
+        }
+        if ret, n, err := DecodeQUICVarint(enc); err != nil || ret != v.value || n != len(enc) {
+            t.Fail()
+            t.Logf("decode % x get %d %d %v", enc, ret, n, err)
+        }
+    }
+
+    //a longer encoding is valid in QUIC
+    if ret, n, err := DecodeQUICVarint([]byte{0x40, 0x25}); err != nil || ret != 37 || n != 2 {
+        t.Fail()
+        t.Logf("2-byte 37 get %d %d %v", ret, n, err)
+    }
+    if _, _, err := DecodeQUICVarint([]byte{0x9D, 0x7F}); !errors.Is(err, ErrTruncated) {
+        t.Fail()
+        t.Logf("truncated get %v", err)
+    }
+    if _, err := AppendQUICVarint(nil, 1 << 62); err == nil || QUICVarintLen(1 << 62) != 0 {
+        t.Fail()
+        t.Log("63-bit value")
+    }
+}
+
+func TestBitStreamVarint(t *testing.T) {
+    w := NewBitWriter()
+    w.WriteBits(1, 3)
+    w.WriteULEB128(624485)
+    w.WriteSLEB128(-123456)
+    w.WriteUTF8Varint(0x20AC)
+    w.WriteQUICVarint(15293)
+    w.WriteBits(0x2, 2)
+
+    r := NewBitReader(w.Bytes())
+    r.ReadBits(3)
+    if v, err := r.ReadULEB128(32); err != nil || v != 624485 {
+        t.Fail()
+        t.Logf("ULEB128 get %d %v", v, err)
+    }
+    if v, err := r.ReadSLEB128(64); err != nil || v != -123456 {
+        t.Fail()
+        t.Logf("SLEB128 get %d %v", v, err)
+    }
+    if v, err := r.ReadUTF8Varint(); err != nil || v != 0x20AC {
+        t.Fail()
+        t.Logf("UTF-8 get %x %v", v, err)
+    }
+    if v, err := r.ReadQUICVarint(); err != nil || v != 15293 {
+        t.Fail()
+        t.Logf("QUIC get %d %v", v, err)
+    }
+
+    //the 2 remaining bits and padding can not hold a QUIC integer
+    pos := r.Pos()
+    if _, err := r.ReadQUICVarint(); !errors.Is(err, ErrTruncated) || r.Pos() != pos {
+        t.Fail()
+        t.Logf("truncated get %v and position %d", err, r.Pos())
+    }
+    if v, err := r.ReadBits(2); err != nil || v != 0x2 {
+        t.Fail()
+        t.Logf("tail get %x %v", v, err)
+    }
+}